@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"kdebug/internal/baseline"
+	"kdebug/internal/diff"
+	"kdebug/internal/exitcode"
+	"kdebug/internal/output"
+)
+
+var baselineCmd = &cobra.Command{
+	Use:   "baseline",
+	Short: "Manage baseline files of accepted findings",
+	Long: `A baseline file lists findings that are known and accepted, so that CI only
+fails on new problems. Run any command with --baseline FILE and the failures
+and warnings the file matches are reported as SUPPRESSED, with the
+justification from the file, instead of FAILED or WARNING. Suppressed
+findings do not count towards --fail-on.
+
+Entries match by check ID, context and resource, and may expire:
+
+  schema_version: "1"
+  suppressions:
+    - check: POD-LOGS
+      resource: {kind: Pod, namespace: legacy, name: "batch-*"}
+      justification: Legacy batch jobs log errors on retry (OPS-142)
+      expires: "2026-12-31"`,
+}
+
+var baselineGenerateCmd = &cobra.Command{
+	Use:   "generate REPORT...",
+	Short: "Create a baseline accepting every finding of saved reports",
+	Long: `Create a baseline file accepting every failure and warning of reports saved
+with -o json or -o yaml. Each finding becomes an entry for its check,
+resource, context and status. Pods with generated names, such as those of a
+Deployment, are matched by their controller instead of their name, so that
+the entry survives the next rollout. Findings that a previous baseline already
+suppressed keep their justification and expiry date, so a baseline can be
+regenerated without losing them.
+
+The baseline is printed as YAML, or as JSON with -o json.`,
+	Example: `  # Accept the current findings, then fail CI only on new ones
+  kdebug pod --all -n production -o json > report.json
+  kdebug baseline generate report.json --justification "Known issues (OPS-142)" --output-file .kdebug-baseline.yaml
+  kdebug pod --all -n production --baseline .kdebug-baseline.yaml
+
+  # Accept findings for a limited time
+  kdebug baseline generate report.json --expires 2026-12-31`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runBaselineGenerate,
+}
+
+func init() {
+	rootCmd.AddCommand(baselineCmd)
+	baselineCmd.AddCommand(baselineGenerateCmd)
+
+	baselineGenerateCmd.Flags().String("justification", "", `justification recorded with every entry (default "Existing finding accepted on <today>")`)
+	baselineGenerateCmd.Flags().String("expires", "", "last day the entries apply, as YYYY-MM-DD")
+}
+
+func runBaselineGenerate(cmd *cobra.Command, args []string) error {
+	justification, _ := cmd.Flags().GetString("justification")
+	expires, _ := cmd.Flags().GetString("expires")
+
+	if justification == "" {
+		justification = "Existing finding accepted on " + time.Now().Format(baseline.DateFormat)
+	}
+	if expires != "" {
+		if _, err := time.Parse(baseline.DateFormat, expires); err != nil {
+			return exitcode.UsageError(fmt.Errorf("--expires must be a YYYY-MM-DD date, got %q", expires))
+		}
+	}
+
+	var results []diff.Result
+	for _, path := range args {
+		reportResults, err := diff.Load(path)
+		if err != nil {
+			return exitcode.UsageError(err)
+		}
+		results = append(results, reportResults...)
+	}
+
+	file := baseline.Generate(results, justification, expires)
+	if len(file.Suppressions) == 0 {
+		fmt.Fprintln(os.Stderr, "No failures or warnings to accept")
+	}
+
+	if output.NewOutputManager(outputFormat, verbose).Format == output.FormatJSON {
+		encoder := json.NewEncoder(commandOutput())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(file)
+	}
+
+	encoder := yaml.NewEncoder(commandOutput())
+	encoder.SetIndent(2)
+	if err := encoder.Encode(file); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// loadBaseline loads the file given with --baseline.
+func loadBaseline() error {
+	if baselineFile == "" {
+		return nil
+	}
+
+	loaded, err := baseline.Load(baselineFile)
+	if err != nil {
+		return exitcode.UsageError(err)
+	}
+	suppressions = loaded
+	return nil
+}
+
+// warnExpiredBaseline warns about baseline entries that no longer suppress
+// anything because they expired.
+func warnExpiredBaseline(outputMgr *output.OutputManager) {
+	if suppressions == nil {
+		return
+	}
+	for _, entry := range suppressions.Expired() {
+		outputMgr.PrintWarning(fmt.Sprintf("Baseline entry for %s in %s expired on %s", entry, suppressions.Path, entry.Expires))
+	}
+}
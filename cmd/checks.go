@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"kdebug/internal/checks"
+	"kdebug/internal/exitcode"
+	"kdebug/internal/output"
+)
+
+var checksCmd = &cobra.Command{
+	Use:   "checks",
+	Short: "Inspect the diagnostic checks kdebug can run",
+}
+
+var checksListCmd = &cobra.Command{
+	Use:   "list [command]",
+	Short: "List every registered check with its ID, category, severity and required permissions",
+	Long: `List the checks run by the pod, service, ingress and cluster commands.
+
+Every check has a stable ID and a short alias; both are accepted by
+--checks and --skip-checks on the command that runs it. Checks that are
+not enabled by default only run when selected with --checks.`,
+	Example: `  # List all checks
+  kdebug checks list
+
+  # List the pod checks with their inputs and RBAC permissions
+  kdebug checks list pod --verbose
+
+  # Export the registry as JSON
+  kdebug checks list -o json`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"pod", "service", "ingress", "cluster"},
+	RunE:      runChecksList,
+}
+
+func init() {
+	rootCmd.AddCommand(checksCmd)
+	checksCmd.AddCommand(checksListCmd)
+}
+
+func runChecksList(cmd *cobra.Command, args []string) error {
+	registered := checks.Default.All()
+	if len(args) == 1 {
+		registered = checks.Default.ForCommand(args[0])
+		if len(registered) == 0 {
+			return exitcode.UsageError(fmt.Errorf("no checks registered for command %q", args[0]))
+		}
+	}
+
+	switch format := output.NewOutputManager(outputFormat, verbose).Format; format {
+	case output.FormatTable:
+	case output.FormatJSON:
+		encoder := json.NewEncoder(commandOutput())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(registered)
+	case output.FormatYAML:
+		encoder := yaml.NewEncoder(commandOutput())
+		defer func() {
+			if err := encoder.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error closing YAML encoder: %v\n", err)
+			}
+		}()
+		return encoder.Encode(registered)
+	default:
+		return exitcode.UsageError(fmt.Errorf("checks list supports only -o table, json and yaml, got %s", format))
+	}
+
+	w := tabwriter.NewWriter(commandOutput(), 0, 0, 2, ' ', 0)
+	header := "ID\tCOMMAND\tALIAS\tCATEGORY\tSEVERITY\tDEFAULT\tDESCRIPTION"
+	if verbose {
+		header += "\tINPUTS\tPERMISSIONS"
+	}
+	fmt.Fprintln(w, header)
+
+	for _, check := range registered {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%t\t%s", check.ID, check.Command, check.Alias, check.Category, check.Severity, check.DefaultEnabled, check.Description)
+		if verbose {
+			permissions := make([]string, 0, len(check.Permissions))
+			for _, permission := range check.Permissions {
+				permissions = append(permissions, permission.String())
+			}
+			fmt.Fprintf(w, "\t%s\t%s", strings.Join(check.Inputs, ","), strings.Join(permissions, "; "))
+		}
+		fmt.Fprintln(w)
+	}
+
+	return w.Flush()
+}
+
+// addCheckFlags adds the --checks and --skip-checks flags shared by every
+// diagnostic command.
+func addCheckFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("checks", nil, fmt.Sprintf("Comma-separated check IDs or aliases to run (see 'kdebug checks list %s')", cmd.Name()))
+	cmd.Flags().StringSlice("skip-checks", nil, "Comma-separated check IDs or aliases to skip")
+}
+
+// checkFlags returns the values of --checks and --skip-checks, rejecting
+// names that are not registered for the command.
+func checkFlags(cmd *cobra.Command) (only, skip []string, err error) {
+	only, _ = cmd.Flags().GetStringSlice("checks")
+	skip, _ = cmd.Flags().GetStringSlice("skip-checks")
+
+	if _, err := checks.Select(cmd.Name(), only, skip); err != nil {
+		return nil, nil, exitcode.UsageError(err)
+	}
+
+	return only, skip, nil
+}
+
+// checkRules describes the registered checks for the output formats that
+// catalogue them.
+func checkRules() map[string]output.Rule {
+	rules := make(map[string]output.Rule)
+	for _, check := range checks.Default.All() {
+		rules[check.ID] = output.Rule{
+			ID:          check.ID,
+			Description: check.Description,
+			Category:    string(check.Category),
+			Severity:    check.Severity,
+		}
+	}
+	return rules
+}
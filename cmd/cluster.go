@@ -8,6 +8,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"kdebug/internal/client"
+	"kdebug/internal/exitcode"
 	"kdebug/internal/output"
 	"kdebug/pkg/cluster"
 )
@@ -48,7 +49,9 @@ func init() {
 	rootCmd.AddCommand(clusterCmd)
 
 	// Cluster-specific flags
-	clusterCmd.Flags().Bool("nodes-only", false, "check only node health (skip control plane and DNS checks)")
+	clusterCmd.Flags().Bool("nodes-only", false, "check only node health (shorthand for --checks connectivity,nodes)")
+	addCheckFlags(clusterCmd)
+	usePlugins(clusterCmd)
 	clusterCmd.Flags().Duration("timeout", 30*time.Second, "timeout for cluster checks")
 }
 
@@ -58,12 +61,31 @@ func runClusterDiagnostics(cmd *cobra.Command, args []string) error {
 	nodesOnly, _ := cmd.Flags().GetBool("nodes-only")
 	timeout, _ := cmd.Flags().GetDuration("timeout")
 
+	selected, skipped, err := checkFlags(cmd)
+	if err != nil {
+		return err
+	}
+	if nodesOnly {
+		if len(selected) > 0 {
+			return exitcode.UsageError(fmt.Errorf("--nodes-only cannot be combined with --checks"))
+		}
+		selected = []string{"CLUSTER-CONNECTIVITY", "CLUSTER-NODES"}
+	}
+	config := cluster.DiagnosticConfig{
+		Checks:       selected,
+		SkipChecks:   skipped,
+		CheckTimeout: checkTimeout,
+		SlowResponse: settings.Thresholds.Connectivity.SlowResponse,
+		CustomChecks: customChecks,
+		Plugins:      plugins,
+	}
+
 	// Create context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	// Initialize output manager
-	outputMgr := output.NewOutputManager(outputFormat, verbose)
+	outputMgr := newOutputManager(outputFormat, verbose)
 
 	// Print initial info
 	if verbose {
@@ -74,11 +96,35 @@ func runClusterDiagnostics(cmd *cobra.Command, args []string) error {
 			outputMgr.PrintInfo(fmt.Sprintf("Using kubeconfig: %s", kubeconfig))
 		}
 
-		fmt.Println()
+		outputMgr.PrintBlankLine()
+	}
+
+	contexts, err := targetContexts(kubeconfig)
+	if err != nil {
+		return err
+	}
+	if len(contexts) > 0 {
+		target := "cluster"
+		if nodesOnly {
+			target = "cluster (nodes only)"
+		}
+
+		_, err := runAcrossContexts(ctx, outputMgr, kubeconfig, target, contexts,
+			func(ctx context.Context, k8sClient *client.KubernetesClient, contextOutput *output.OutputManager) ([]*output.DiagnosticReport, error) {
+				report, err := cluster.NewClusterDiagnostic(k8sClient, contextOutput).RunChecks(ctx, config)
+				if err != nil {
+					return nil, err
+				}
+				if nodesOnly {
+					report.Target = "cluster (nodes only)"
+				}
+				return []*output.DiagnosticReport{report}, nil
+			})
+		return err
 	}
 
 	// Initialize Kubernetes client
-	k8sClient, err := client.NewKubernetesClient(kubeconfig)
+	k8sClient, err := newKubernetesClient(kubeconfig)
 	if err != nil {
 		outputMgr.PrintError("Failed to initialize Kubernetes client", err)
 		return err
@@ -89,22 +135,20 @@ func runClusterDiagnostics(cmd *cobra.Command, args []string) error {
 		outputMgr.PrintError("Failed to connect to Kubernetes cluster", err)
 		outputMgr.PrintInfo("Please check your kubeconfig and cluster connectivity")
 
-		return err
+		return exitcode.ConnectivityError(err)
 	}
 
 	// Initialize cluster diagnostic
 	clusterDiag := cluster.NewClusterDiagnostic(k8sClient, outputMgr)
 
 	// Run diagnostics
-	report, err := clusterDiag.RunDiagnostics(ctx)
+	report, err := clusterDiag.RunChecks(ctx, config)
 	if err != nil {
 		outputMgr.PrintError("Failed to run cluster diagnostics", err)
 		return err
 	}
-
-	// Filter results if nodes-only flag is set
 	if nodesOnly {
-		report = filterNodeChecksOnly(report)
+		report.Target = "cluster (nodes only)"
 	}
 
 	// Print results
@@ -112,81 +156,25 @@ func runClusterDiagnostics(cmd *cobra.Command, args []string) error {
 		outputMgr.PrintError("Failed to print diagnostic report", err)
 		return err
 	}
+	printAPIRequestStats(outputMgr, k8sClient)
 
 	// Print additional information based on results
 	if report.Summary.Failed > 0 {
-		fmt.Println()
+		outputMgr.PrintBlankLine()
 		outputMgr.PrintWarning("Some critical issues were found that may affect cluster functionality")
 		outputMgr.PrintInfo("Review the failed checks above and follow the suggested actions")
-
-		// Exit with non-zero code if there are critical failures
-		return fmt.Errorf("cluster health check failed: %d critical issues found", report.Summary.Failed)
 	}
 
 	if report.Summary.Warnings > 0 {
-		fmt.Println()
+		outputMgr.PrintBlankLine()
 		outputMgr.PrintWarning("Some warnings were found that should be addressed")
 		outputMgr.PrintInfo("These issues may not immediately affect functionality but should be monitored")
 	}
 
 	if report.Summary.Failed == 0 && report.Summary.Warnings == 0 {
-		fmt.Println()
+		outputMgr.PrintBlankLine()
 		outputMgr.PrintSuccess("Cluster appears to be healthy!")
 	}
 
-	return nil
-}
-
-// filterNodeChecksOnly filters the report to include only node-related checks
-func filterNodeChecksOnly(report *output.DiagnosticReport) *output.DiagnosticReport {
-	var filteredChecks []output.CheckResult
-
-	for _, check := range report.Checks {
-		// Include connectivity check and node-related checks
-		if check.Name == "API Server Connectivity" ||
-			containsAny(check.Name, []string{"Node", "node"}) {
-			filteredChecks = append(filteredChecks, check)
-		}
-	}
-
-	// Update the report
-	newReport := *report
-	newReport.Checks = filteredChecks
-	newReport.Target = "cluster (nodes only)"
-
-	// Recalculate summary
-	newSummary := output.Summary{}
-	for _, check := range filteredChecks {
-		newSummary.Total++
-
-		switch check.Status {
-		case output.StatusPassed:
-			newSummary.Passed++
-		case output.StatusFailed:
-			newSummary.Failed++
-		case output.StatusWarning:
-			newSummary.Warnings++
-		case output.StatusSkipped:
-			newSummary.Skipped++
-		}
-	}
-
-	newReport.Summary = newSummary
-
-	return &newReport
-}
-
-// containsAny checks if the string contains any of the given substrings
-func containsAny(s string, substrings []string) bool {
-	for _, substr := range substrings {
-		if len(s) >= len(substr) {
-			for i := 0; i <= len(s)-len(substr); i++ {
-				if s[i:i+len(substr)] == substr {
-					return true
-				}
-			}
-		}
-	}
-
-	return false
+	return checkFindings(report)
 }
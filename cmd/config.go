@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"kdebug/internal/checks"
+	"kdebug/internal/config"
+	"kdebug/internal/custom"
+	"kdebug/internal/exitcode"
+	"kdebug/internal/output"
+	"kdebug/pkg/ingress"
+	"kdebug/pkg/pod"
+)
+
+// loadConfig loads the configuration files, or the file given with --config,
+// and applies them: custom checks, plugins and check defaults go to the
+// registry, and the default output format and namespace to flags not given
+// on the command line. Plugins are only loaded for the commands that use
+// them.
+func loadConfig(cmd *cobra.Command) error {
+	var err error
+	if configFile != "" {
+		settings, err = config.LoadFile(configFile)
+	} else {
+		settings, err = config.Load()
+	}
+	if err != nil {
+		return exitcode.UsageError(err)
+	}
+
+	// Custom and plugin checks are registered first, so the configuration
+	// can enable, disable and rate them like built-in checks
+	if customChecks, err = custom.Load(append(slices.Clone(settings.CustomChecks), customCheckPaths...)); err != nil {
+		return exitcode.UsageError(err)
+	}
+	if err := custom.Register(checks.Default, customChecks); err != nil {
+		return exitcode.UsageError(fmt.Errorf("invalid custom checks: %w", err))
+	}
+	enable, disable, severity := settings.Checks.Enable, settings.Checks.Disable, settings.Checks.Severity
+	if usesPlugins(cmd) {
+		loadPlugins()
+	} else {
+		// Without describing the plugins, their check IDs cannot be told
+		// from typos; the commands that run checks validate them
+		enable, disable, severity = registeredIDs(enable), registeredIDs(disable), registeredSeverities(severity)
+	}
+
+	if err := checks.Default.Configure(enable, disable, severity); err != nil {
+		return exitcode.UsageError(fmt.Errorf("invalid configuration in %s: %w", strings.Join(settings.Sources, ", "), err))
+	}
+
+	for name, value := range map[string]string{"output": settings.Output, "namespace": settings.Namespace} {
+		flag := cmd.Flag(name)
+		if value == "" || flag == nil || flag.Changed {
+			continue
+		}
+		if err := flag.Value.Set(value); err != nil {
+			return exitcode.UsageError(fmt.Errorf("invalid configuration for --%s: %w", name, err))
+		}
+	}
+
+	return nil
+}
+
+// registeredIDs drops the check IDs that are not registered.
+func registeredIDs(ids []string) []string {
+	return slices.DeleteFunc(slices.Clone(ids), func(id string) bool {
+		return !isRegistered(id)
+	})
+}
+
+// registeredSeverities drops the severities of checks that are not
+// registered.
+func registeredSeverities(severity map[string]output.Severity) map[string]output.Severity {
+	registered := make(map[string]output.Severity, len(severity))
+	for id, value := range severity {
+		if isRegistered(id) {
+			registered[id] = value
+		}
+	}
+	return registered
+}
+
+// isRegistered reports whether a check ID is registered, ignoring case.
+func isRegistered(id string) bool {
+	id = strings.ToUpper(strings.TrimSpace(id))
+	return slices.ContainsFunc(checks.Default.All(), func(check checks.Check) bool {
+		return check.ID == id
+	})
+}
+
+// podLogPatterns returns the configured log patterns for the pod command.
+func podLogPatterns() []pod.LogPattern {
+	patterns := make([]pod.LogPattern, 0, len(settings.LogPatterns))
+	for _, pattern := range settings.LogPatterns {
+		patterns = append(patterns, pod.LogPattern{
+			Pattern:    pattern.Regexp(),
+			Message:    pattern.Message,
+			Suggestion: pattern.Suggestion,
+		})
+	}
+	return patterns
+}
+
+// ingressTLSExpectations returns the configured expectations of the ingress
+// TLS check.
+func ingressTLSExpectations() ingress.TLSExpectations {
+	tls := settings.Thresholds.TLS
+	return ingress.TLSExpectations{
+		SecretTypes:       tls.SecretTypes,
+		RequiredKeys:      tls.RequiredKeys,
+		AllowMissingHosts: tls.RequireHosts != nil && !*tls.RequireHosts,
+	}
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"kdebug/internal/client"
+	"kdebug/internal/exitcode"
+	"kdebug/internal/multicontext"
+	"kdebug/internal/output"
+)
+
+// targetContexts returns the contexts selected with --contexts or
+// --all-contexts, or nil when the command should run against a single cluster.
+func targetContexts(kubeconfig string) ([]string, error) {
+	if len(kubeContexts) == 0 && !allContexts {
+		return nil, nil
+	}
+
+	switch {
+	case len(kubeContexts) > 0 && allContexts:
+		return nil, exitcode.UsageError(fmt.Errorf("--contexts and --all-contexts are mutually exclusive"))
+	case kubeContext != "":
+		return nil, exitcode.UsageError(fmt.Errorf("--context cannot be combined with --contexts or --all-contexts"))
+	case fromSnapshot != "":
+		return nil, exitcode.UsageError(fmt.Errorf("--contexts and --all-contexts cannot be combined with --from-snapshot"))
+	}
+
+	if allContexts {
+		contexts, err := client.ListContexts(kubeconfig)
+		return contexts, exitcode.UsageError(err)
+	}
+
+	return kubeContexts, nil
+}
+
+// runAcrossContexts runs diagnose against every context concurrently and
+// prints one combined report. It returns a connectivity error when any
+// context could not be diagnosed, and otherwise applies --fail-on to the
+// combined findings.
+func runAcrossContexts(ctx context.Context, outputMgr *output.OutputManager, kubeconfig, target string, contexts []string, diagnose multicontext.DiagnoseFunc) (*output.MultiContextReport, error) {
+	outputMgr.PrintInfo(fmt.Sprintf("Running diagnostics against %d contexts...", len(contexts)))
+
+	var (
+		mu      sync.Mutex
+		clients = make(map[string]*client.KubernetesClient, len(contexts))
+	)
+	newClient := func(contextName string) (*client.KubernetesClient, error) {
+		k8sClient, err := client.NewKubernetesClientWithOptions(clientOptions(kubeconfig, contextName))
+		if err == nil {
+			mu.Lock()
+			clients[contextName] = k8sClient
+			mu.Unlock()
+		}
+		return k8sClient, err
+	}
+
+	report := multicontext.Run(ctx, target, contexts, outputMgr, newClient, diagnose)
+
+	if err := outputMgr.PrintMultiContextReport(report); err != nil {
+		return report, fmt.Errorf("failed to print report: %w", err)
+	}
+
+	if outputMgr.Verbose {
+		for _, contextName := range report.ContextNames() {
+			if k8sClient, ok := clients[contextName]; ok {
+				outputMgr.PrintInfo(fmt.Sprintf("API requests made in context %s: %d", contextName, k8sClient.Requests.Count()))
+			}
+		}
+	}
+
+	if errors := report.Errors(); errors > 0 {
+		return report, exitcode.ConnectivityError(fmt.Errorf("diagnostics failed in %d of %d contexts", errors, len(contexts)))
+	}
+
+	return report, failPolicy.Check(report.Summary)
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"kdebug/internal/diff"
+	"kdebug/internal/exitcode"
+	"kdebug/internal/output"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff BEFORE AFTER",
+	Short: "Compare two saved JSON or YAML reports",
+	Long: `Compare two reports saved with -o json or -o yaml, for example taken
+before and after a rollout, and print what changed:
+
+• New failures: checks that fail now but did not fail before
+• Resolved: failures and warnings that pass now or are no longer reported
+• Status changes: any other change of status, such as new warnings
+• Detail changes: checks with the same status but different details
+
+Checks are matched by their ID, the resource they are about and, for
+multi-context reports, the context. Pods with generated names are matched
+by their controller, so that a rollout alone is not a change. Single reports, report sets and
+multi-context reports can be compared.`,
+	Example: `  # Compare the findings before and after a rollout
+  kdebug pod --all -n production -o json > before.json
+  kubectl rollout restart deployment/web -n production
+  kdebug pod --all -n production -o json > after.json
+  kdebug diff before.json after.json
+
+  # Fail a pipeline when the rollout introduced new failures
+  kdebug diff before.json after.json --fail-on-regression`,
+	Args: cobra.ExactArgs(2),
+	RunE: runDiff,
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().Bool("fail-on-regression", false, "exit with code 3 when AFTER has failures that BEFORE did not have")
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	failOnRegression, _ := cmd.Flags().GetBool("fail-on-regression")
+
+	before, err := diff.Load(args[0])
+	if err != nil {
+		return exitcode.UsageError(err)
+	}
+	after, err := diff.Load(args[1])
+	if err != nil {
+		return exitcode.UsageError(err)
+	}
+
+	report := diff.Compare(before, after)
+	report.Before = args[0]
+	report.After = args[1]
+
+	if err := printDiff(report); err != nil {
+		return err
+	}
+
+	if failOnRegression && report.Regressed() {
+		return &exitcode.ExitError{
+			Code: exitcode.Failures,
+			Err:  fmt.Errorf("%d new failure(s) since %s", len(report.NewFailures), args[0]),
+		}
+	}
+
+	return nil
+}
+
+func printDiff(report *diff.Report) error {
+	switch output.NewOutputManager(outputFormat, verbose).Format {
+	case output.FormatJSON:
+		encoder := json.NewEncoder(commandOutput())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case output.FormatYAML:
+		encoder := yaml.NewEncoder(commandOutput())
+		defer func() {
+			if err := encoder.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error closing YAML encoder: %v\n", err)
+			}
+		}()
+		return encoder.Encode(report)
+	}
+
+	out := commandOutput()
+	fmt.Fprintf(out, "Comparing %s with %s\n", report.Before, report.After)
+
+	sections := []struct {
+		title   string
+		changes []diff.Change
+	}{
+		{"New failures", report.NewFailures},
+		{"Resolved", report.Resolved},
+		{"Status changes", report.StatusChanges},
+		{"Detail changes", report.DetailChanges},
+	}
+
+	for _, section := range sections {
+		if len(section.changes) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(out, "\n%s (%d):\n", section.title, len(section.changes))
+		w := tabwriter.NewWriter(commandOutput(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  BEFORE\tAFTER\tCHECK\tRESOURCE\tMESSAGE")
+		for _, change := range section.changes {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n",
+				diffStatus(change.BeforeStatus), diffStatus(change.AfterStatus),
+				diffCheck(change), diffResource(change), change.Message)
+			for _, detail := range change.DetailChanges {
+				fmt.Fprintf(w, "  \t\t\t\t%s: %q → %q\n", detail.Key, detail.Before, detail.After)
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	summary := report.Summary
+	fmt.Fprintf(out, "\nSummary: %d new failures, %d resolved, %d status changes, %d detail changes, %d unchanged\n",
+		summary.NewFailures, summary.Resolved, summary.StatusChanges, summary.DetailChanges, summary.Unchanged)
+
+	return nil
+}
+
+// diffStatus shows a missing status as a dash.
+func diffStatus(status output.CheckStatus) string {
+	if status == "" {
+		return "-"
+	}
+	return string(status)
+}
+
+func diffCheck(change diff.Change) string {
+	if change.ID == "" {
+		return change.Name
+	}
+	return change.ID
+}
+
+func diffResource(change diff.Change) string {
+	resource := change.Target
+	if change.Resource != nil {
+		resource = change.Resource.String()
+	}
+	if change.Context != "" {
+		resource = change.Context + "/" + resource
+	}
+	return resource
+}
@@ -3,12 +3,12 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"kdebug/internal/client"
+	"kdebug/internal/exitcode"
 	"kdebug/internal/output"
 	"kdebug/pkg/ingress"
 )
@@ -47,7 +47,6 @@ Examples:
 var (
 	ingressAll           bool
 	ingressAllNamespaces bool
-	ingressChecks        []string
 	ingressOutputFormat  string
 	ingressVerbose       bool
 	ingressTimeout       time.Duration
@@ -59,8 +58,9 @@ func init() {
 	// Flags
 	ingressCmd.Flags().BoolVar(&ingressAll, "all", false, "Diagnose all ingress resources in namespace(s)")
 	ingressCmd.Flags().BoolVar(&ingressAllNamespaces, "all-namespaces", false, "Analyze ingress resources across all namespaces")
-	ingressCmd.Flags().StringSliceVar(&ingressChecks, "checks", []string{}, "Comma-separated list of checks to run (existence,config,backends,endpoints,ssl)")
-	ingressCmd.Flags().StringVarP(&ingressOutputFormat, "output", "o", "table", "Output format (table, json, yaml)")
+	addCheckFlags(ingressCmd)
+	usePlugins(ingressCmd)
+	ingressCmd.Flags().StringVarP(&ingressOutputFormat, "output", "o", "table", "Output format (table, json, yaml, sarif, junit, html, markdown, ndjson, prometheus, template=TEMPLATE, template-file=FILE, jsonpath=EXPR)")
 	ingressCmd.Flags().BoolVarP(&ingressVerbose, "verbose", "v", false, "Enable verbose output")
 	ingressCmd.Flags().DurationVar(&ingressTimeout, "timeout", 30*time.Second, "Timeout for diagnosis operations")
 
@@ -73,6 +73,11 @@ func runIngressDiagnosis(cmd *cobra.Command, args []string) error {
 	kubeconfig, _ := cmd.Flags().GetString("kubeconfig")
 	namespace, _ := cmd.Flags().GetString("namespace")
 
+	checks, skipChecks, err := checkFlags(cmd)
+	if err != nil {
+		return err
+	}
+
 	// Set defaults
 	if namespace == "" {
 		namespace = "default"
@@ -82,28 +87,71 @@ func runIngressDiagnosis(cmd *cobra.Command, args []string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), ingressTimeout)
 	defer cancel()
 
-	// Initialize Kubernetes client
-	kubeClient, err := client.NewKubernetesClient(kubeconfig)
-	if err != nil {
-		return fmt.Errorf("failed to create Kubernetes client: %w", err)
-	}
-
 	// Initialize output manager
-	outputMgr := output.NewOutputManager(ingressOutputFormat, ingressVerbose)
-
-	// Initialize ingress diagnostic
-	ingressDiag := ingress.NewIngressDiagnostic(kubeClient, outputMgr)
+	outputMgr := newOutputManager(ingressOutputFormat, ingressVerbose)
 
 	// Prepare diagnostic configuration
 	config := ingress.DiagnosticConfig{
 		Namespace:     namespace,
 		AllNamespaces: ingressAllNamespaces,
 		All:           ingressAll,
-		Checks:        ingressChecks,
+		Checks:        checks,
+		SkipChecks:    skipChecks,
 		Timeout:       ingressTimeout,
+		CheckTimeout:  checkTimeout,
+		Parallelism:   parallelism,
+		TLS:           ingressTLSExpectations(),
+		CustomChecks:  customChecks,
+		Plugins:       plugins,
+	}
+
+	contexts, err := targetContexts(kubeconfig)
+	if err != nil {
+		return err
+	}
+	if len(contexts) > 0 {
+		if len(args) == 0 && !ingressAll {
+			return exitcode.UsageError(fmt.Errorf("please specify an ingress name or use --all flag"))
+		}
+
+		target := fmt.Sprintf("ingresses in namespace %s", namespace)
+		switch {
+		case len(args) == 1:
+			target = fmt.Sprintf("ingress/%s", args[0])
+			config.IngressName = args[0]
+		case ingressAllNamespaces:
+			target = "ingresses in all namespaces"
+		}
+
+		_, err := runAcrossContexts(ctx, outputMgr, kubeconfig, target, contexts,
+			func(ctx context.Context, k8sClient *client.KubernetesClient, contextOutput *output.OutputManager) ([]*output.DiagnosticReport, error) {
+				ingressDiag := ingress.NewIngressDiagnostic(k8sClient, contextOutput)
+				if len(args) == 1 {
+					report, err := ingressDiag.DiagnoseIngress(ctx, args[0], config)
+					return []*output.DiagnosticReport{report}, err
+				}
+				return ingressDiag.DiagnoseAllIngresses(ctx, config)
+			})
+		return err
+	}
+
+	// Initialize Kubernetes client
+	kubeClient, err := newKubernetesClient(kubeconfig)
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
 	}
 
+	// Test connectivity
+	if err := kubeClient.TestConnection(ctx); err != nil {
+		outputMgr.PrintError("Kubernetes connectivity check failed", err)
+		return exitcode.ConnectivityError(err)
+	}
+
+	// Initialize ingress diagnostic
+	ingressDiag := ingress.NewIngressDiagnostic(kubeClient, outputMgr)
+
 	// Handle specific ingress vs. all ingresses
+	var reports []*output.DiagnosticReport
 	if len(args) == 1 {
 		// Diagnose specific ingress
 		ingressName := args[0]
@@ -118,41 +166,38 @@ func runIngressDiagnosis(cmd *cobra.Command, args []string) error {
 		if err := outputMgr.PrintReport(report); err != nil {
 			return fmt.Errorf("failed to print report: %w", err)
 		}
+		reports = append(reports, report)
 
 	} else if ingressAll {
 		// Diagnose all ingresses
-		reports, err := ingressDiag.DiagnoseAllIngresses(ctx, config)
+		reports, err = ingressDiag.DiagnoseAllIngresses(ctx, config)
 		if err != nil {
 			return fmt.Errorf("failed to diagnose ingresses: %w", err)
 		}
 
-		// Print each report
-		for _, report := range reports {
-			if err := outputMgr.PrintReport(report); err != nil {
-				return fmt.Errorf("failed to print report: %w", err)
-			}
-			// Add separator between reports for table format
-			if ingressOutputFormat == "table" && len(reports) > 1 {
-				fmt.Println(output.ColorDim + strings.Repeat("─", 80) + output.ColorReset)
-			}
+		// Print the reports
+		if err := outputMgr.PrintReports(reports); err != nil {
+			return fmt.Errorf("failed to print report: %w", err)
 		}
 
 		// Print summary if multiple ingresses were analyzed
-		if len(reports) > 1 {
-			printIngressSummary(reports)
+		if len(reports) > 1 && outputMgr.Format == output.FormatTable {
+			printIngressSummary(outputMgr, reports)
 		}
 
 	} else {
-		return fmt.Errorf("please specify an ingress name or use --all flag")
+		return exitcode.UsageError(fmt.Errorf("please specify an ingress name or use --all flag"))
 	}
 
-	return nil
+	printAPIRequestStats(outputMgr, kubeClient)
+
+	return checkFindings(reports...)
 }
 
 // printIngressSummary prints a summary of multiple ingress diagnoses
-func printIngressSummary(reports []*output.DiagnosticReport) {
+func printIngressSummary(outputMgr *output.OutputManager, reports []*output.DiagnosticReport) {
 	total := len(reports)
-	var totalChecks, passed, failed, warnings, skipped int
+	var totalChecks, passed, failed, warnings, skipped, suppressed int
 
 	for _, report := range reports {
 		totalChecks += report.Summary.Total
@@ -160,19 +205,24 @@ func printIngressSummary(reports []*output.DiagnosticReport) {
 		failed += report.Summary.Failed
 		warnings += report.Summary.Warnings
 		skipped += report.Summary.Skipped
+		suppressed += report.Summary.Suppressed
 	}
 
-	fmt.Printf("\n📊 Ingress Diagnostics Summary:\n")
-	fmt.Printf("   Total Ingresses: %d\n", total)
-	fmt.Printf("   Total Checks: %d\n", totalChecks)
-	fmt.Printf("   ✅ Passed: %d\n", passed)
-	fmt.Printf("   ❌ Failed: %d\n", failed)
-	fmt.Printf("   ⚠️  Warnings: %d\n", warnings)
-	fmt.Printf("   ⏭️  Skipped: %d\n", skipped)
+	w := outputMgr.Out
+	fmt.Fprintf(w, "\n%sIngress Diagnostics Summary:\n", outputMgr.Icon("📊"))
+	fmt.Fprintf(w, "   Total Ingresses: %d\n", total)
+	fmt.Fprintf(w, "   Total Checks: %d\n", totalChecks)
+	fmt.Fprintf(w, "   %sPassed: %d\n", outputMgr.Icon("✅"), passed)
+	fmt.Fprintf(w, "   %sFailed: %d\n", outputMgr.Icon("❌"), failed)
+	fmt.Fprintf(w, "   %sWarnings: %d\n", outputMgr.Icon("⚠️ "), warnings)
+	fmt.Fprintf(w, "   %sSkipped: %d\n", outputMgr.Icon("⏭️ "), skipped)
+	if suppressed > 0 {
+		fmt.Fprintf(w, "   %sSuppressed: %d\n", outputMgr.Icon("🔕"), suppressed)
+	}
 
 	// Calculate health percentage
 	if totalChecks > 0 {
 		healthPercent := float64(passed) / float64(totalChecks) * 100
-		fmt.Printf("   Health Score: %.1f%%\n", healthPercent)
+		fmt.Fprintf(w, "   Health Score: %.1f%%\n", healthPercent)
 	}
 }
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"kdebug/internal/checks"
+	"kdebug/internal/config"
+	"kdebug/internal/output"
+	"kdebug/internal/plugin"
+)
+
+var pluginCmd = &cobra.Command{
+	Use:   "plugin",
+	Short: "Inspect external check plugins",
+	Long: `Check plugins are executables named kdebug-check-<name>, written in any
+language. kdebug looks for them in the directories given with --plugin-dir
+and the configuration's pluginDirs, then in ~/.config/kdebug/plugins, then
+on PATH; the first plugin of a name wins.
+
+kdebug runs "<plugin> describe" to learn the checks a plugin provides, and
+registers them like built-in checks. Only the commands that run checks and
+plugin list describe plugins. Each check then runs as "<plugin> run"
+with the target objects and cluster information as JSON on stdin, and
+prints CheckResult JSON on stdout. Plugins that fail, print invalid output
+or exceed --check-timeout are reported as SKIPPED without affecting other
+checks. --no-plugins disables plugins.`,
+}
+
+var pluginListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the discovered plugins and the checks they provide",
+	Example: `  # List the plugins and any that failed to load
+  kdebug plugin list
+
+  # Include the plugins of a directory
+  kdebug plugin list --plugin-dir ./plugins`,
+	Args: cobra.NoArgs,
+	RunE: runPluginList,
+}
+
+func init() {
+	rootCmd.AddCommand(pluginCmd)
+	pluginCmd.AddCommand(pluginListCmd)
+	usePlugins(pluginListCmd)
+}
+
+func runPluginList(cmd *cobra.Command, args []string) error {
+	// The output manager warns about plugins that failed to load
+	newOutputManager(outputFormat, verbose)
+	if len(discoveredPlugins) == 0 {
+		fmt.Fprintln(os.Stderr, "No plugins found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(commandOutput(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPATH\tCHECKS")
+	for _, discovered := range discoveredPlugins {
+		var ids []string
+		for _, check := range plugins {
+			if check.Plugin == discovered {
+				ids = append(ids, check.ID)
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", discovered.Name, discovered.Path, strings.Join(ids, ","))
+	}
+	return w.Flush()
+}
+
+// pluginsAnnotation marks the commands that load the check plugins: those
+// that run checks, and plugin list. Other commands never start a plugin.
+const pluginsAnnotation = "kdebug/plugins"
+
+// usePlugins makes a command load the check plugins before it runs.
+func usePlugins(cmd *cobra.Command) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = make(map[string]string)
+	}
+	cmd.Annotations[pluginsAnnotation] = "true"
+}
+
+// usesPlugins reports whether a command loads the check plugins.
+func usesPlugins(cmd *cobra.Command) bool {
+	_, ok := cmd.Annotations[pluginsAnnotation]
+	return ok
+}
+
+// loadPlugins discovers the check plugins and registers their checks.
+// Plugins that cannot be loaded are left out and reported as warnings.
+func loadPlugins() {
+	discoveredPlugins, plugins, pluginErrors = nil, nil, nil
+	if noPlugins {
+		return
+	}
+
+	dirs := append(slices.Clone(pluginDirs), settings.PluginDirs...)
+	if dir := plugin.DefaultDir(config.UserPath()); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	discoveredPlugins = plugin.Discover(dirs, os.Getenv("PATH"))
+
+	loaded, errs := plugin.Load(context.Background(), discoveredPlugins)
+	pluginErrors = append(pluginErrors, errs...)
+	plugins, errs = plugin.Register(checks.Default, loaded)
+	pluginErrors = append(pluginErrors, errs...)
+}
+
+// warnPluginErrors warns about plugins that could not be loaded.
+func warnPluginErrors(outputMgr *output.OutputManager) {
+	for _, err := range pluginErrors {
+		outputMgr.PrintWarning(fmt.Sprintf("Ignoring plugin: %v", err))
+	}
+}
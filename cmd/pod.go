@@ -8,6 +8,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"kdebug/internal/client"
+	"kdebug/internal/exitcode"
 	"kdebug/internal/output"
 	"kdebug/pkg/pod"
 )
@@ -42,7 +43,10 @@ root causes and provide actionable remediation suggestions.`,
   kdebug pod myapp-pod --checks=scheduling,images,rbac
 
   # Include detailed log analysis for crashed pods
-  kdebug pod myapp-pod --include-logs --log-lines 50`,
+  kdebug pod myapp-pod --include-logs --log-lines 50
+
+  # Re-check denied requests with the pod's own service account identity
+  kdebug pod myapp-pod --as-service-account --include-logs`,
 	RunE: runPodDiagnostics,
 }
 
@@ -51,42 +55,94 @@ func init() {
 
 	// Pod-specific flags
 	podCmd.Flags().BoolP("all", "a", false, "Diagnose all pods in the specified namespace")
-	podCmd.Flags().StringSlice("checks", []string{}, "Comma-separated list of checks to run (scheduling,images,rbac,logs,init-containers)")
 	podCmd.Flags().Bool("include-logs", false, "Include container log analysis for failed pods")
 	podCmd.Flags().Int("log-lines", 20, "Number of recent log lines to analyze (when --include-logs is enabled)")
 	podCmd.Flags().Duration("timeout", 30*time.Second, "Timeout for pod diagnostics")
 	podCmd.Flags().Bool("watch", false, "Watch pod status and re-run diagnostics on changes")
 	podCmd.Flags().StringSlice("containers", []string{}, "Specific containers to analyze (default: all containers)")
+	addCheckFlags(podCmd)
+	usePlugins(podCmd)
+	podCmd.Flags().Bool("as-service-account", false, "Also run the RBAC checks as the pod's own service account (requires permission to impersonate it)")
 }
 
 func runPodDiagnostics(cmd *cobra.Command, args []string) error {
 	// Parse flags
 	allPods, _ := cmd.Flags().GetBool("all")
-	checks, _ := cmd.Flags().GetStringSlice("checks")
 	includeLogs, _ := cmd.Flags().GetBool("include-logs")
 	logLines, _ := cmd.Flags().GetInt("log-lines")
 	timeout, _ := cmd.Flags().GetDuration("timeout")
 	watch, _ := cmd.Flags().GetBool("watch")
 	containers, _ := cmd.Flags().GetStringSlice("containers")
+	asServiceAccount, _ := cmd.Flags().GetBool("as-service-account")
 
 	// Get global flags
-	outputFormat, _ := cmd.Flags().GetString("outputFormat")
+	outputFormat, _ := cmd.Flags().GetString("output")
 	verbose, _ := cmd.Flags().GetBool("verbose")
 	kubeconfig, _ := cmd.Flags().GetString("kubeconfig")
 	namespace, _ := cmd.Flags().GetString("namespace")
 
+	checks, skipChecks, err := checkFlags(cmd)
+	if err != nil {
+		return err
+	}
+
 	// Validate arguments
 	if !allPods && len(args) == 0 {
-		return fmt.Errorf("pod name is required when --all is not specified")
+		return exitcode.UsageError(fmt.Errorf("pod name is required when --all is not specified"))
 	}
 
 	if allPods && len(args) > 0 {
-		return fmt.Errorf("cannot specify pod name when using --all flag")
+		return exitcode.UsageError(fmt.Errorf("cannot specify pod name when using --all flag"))
 	}
 
 	// Initialize dependencies
-	outputManager := output.NewOutputManager(outputFormat, verbose)
-	k8sClient, err := client.NewKubernetesClient(kubeconfig)
+	outputManager := newOutputManager(outputFormat, verbose)
+
+	// Create diagnostic configuration
+	config := pod.DiagnosticConfig{
+		Namespace:        namespace,
+		Checks:           checks,
+		SkipChecks:       skipChecks,
+		IncludeLogs:      includeLogs,
+		LogLines:         logLines,
+		Timeout:          timeout,
+		Containers:       containers,
+		AsServiceAccount: asServiceAccount,
+		CheckTimeout:     checkTimeout,
+		Parallelism:      parallelism,
+		LogPatterns:      podLogPatterns(),
+		CustomChecks:     customChecks,
+		Plugins:          plugins,
+	}
+
+	contexts, err := targetContexts(kubeconfig)
+	if err != nil {
+		return err
+	}
+	if len(contexts) > 0 {
+		if watch {
+			return exitcode.UsageError(fmt.Errorf("--watch cannot be combined with --contexts or --all-contexts"))
+		}
+
+		target := fmt.Sprintf("pods in namespace %s", namespace)
+		if !allPods {
+			target = fmt.Sprintf("pod/%s", args[0])
+		}
+
+		_, err := runAcrossContexts(context.Background(), outputManager, kubeconfig, target, contexts,
+			func(ctx context.Context, k8sClient *client.KubernetesClient, contextOutput *output.OutputManager) ([]*output.DiagnosticReport, error) {
+				diagnostic := pod.NewPodDiagnostic(k8sClient, contextOutput)
+				if allPods {
+					report, err := diagnostic.DiagnoseAllPods(config)
+					return []*output.DiagnosticReport{report}, err
+				}
+				report, err := diagnostic.DiagnosePod(args[0], config)
+				return []*output.DiagnosticReport{report}, err
+			})
+		return err
+	}
+
+	k8sClient, err := newKubernetesClient(kubeconfig)
 	if err != nil {
 		outputManager.PrintError("Failed to initialize Kubernetes client", err)
 		return fmt.Errorf("failed to create Kubernetes client: %w", err)
@@ -96,21 +152,11 @@ func runPodDiagnostics(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
 	if err := k8sClient.TestConnection(ctx); err != nil {
 		outputManager.PrintError("Kubernetes connectivity check failed", err)
-		return err
+		return exitcode.ConnectivityError(err)
 	}
 
 	outputManager.PrintInfo("Initializing pod diagnostics...")
 
-	// Create diagnostic configuration
-	config := pod.DiagnosticConfig{
-		Namespace:   namespace,
-		Checks:      checks,
-		IncludeLogs: includeLogs,
-		LogLines:    logLines,
-		Timeout:     timeout,
-		Containers:  containers,
-	}
-
 	// Initialize pod diagnostic
 	diagnostic := pod.NewPodDiagnostic(k8sClient, outputManager)
 
@@ -139,10 +185,9 @@ func runPodDiagnostics(cmd *cobra.Command, args []string) error {
 
 	// Output results
 	if err := outputManager.PrintReport(report); err != nil {
-		outputManager.PrintWarning(fmt.Sprintf("Failed to print report: %v", err))
+		return fmt.Errorf("failed to print report: %w", err)
 	}
+	printAPIRequestStats(outputManager, k8sClient)
 
-	// Don't return error for diagnostic findings - just print summary
-	// This allows the command to exit cleanly while still reporting issues
-	return nil
+	return checkFindings(report)
 }
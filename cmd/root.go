@@ -4,9 +4,23 @@ Copyright © 2025 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"fmt"
+	"io"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"k8s.io/client-go/rest"
+
+	"kdebug/internal/baseline"
+	"kdebug/internal/client"
+	"kdebug/internal/config"
+	"kdebug/internal/custom"
+	"kdebug/internal/exitcode"
+	"kdebug/internal/output"
+	"kdebug/internal/plugin"
+	"kdebug/internal/snapshot"
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -25,17 +39,90 @@ Examples:
   kdebug pod myapp-123 -n production      # Debug a specific pod
   kdebug service myservice                 # Check service and endpoints
   kdebug ingress my-ingress                # Diagnose ingress routing issues
-  kdebug dns                               # Test DNS resolution`,
-	Version: "1.0.1",
+  kdebug dns                               # Test DNS resolution
+  kdebug pod --all --from-snapshot dump/   # Diagnose offline from a snapshot
+  kdebug cluster --all-contexts            # Diagnose every kubeconfig context`,
+	Version:           "1.0.1",
+	PersistentPreRunE: preRun,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	err := rootCmd.Execute()
+	if closeErr := closeOutputFile(); err == nil && closeErr != nil {
+		err = closeErr
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
 	if err != nil {
-		os.Exit(1)
+		os.Exit(exitCode(err))
+	}
+}
+
+// exitCode returns the process exit code for an error returned by a command.
+// Errors raised before the command started, such as unknown commands or a
+// wrong number of arguments, are usage errors.
+func exitCode(err error) int {
+	if !commandStarted {
+		return exitcode.Usage
 	}
+	return exitcode.Code(err)
+}
+
+// preRun validates the global flags once cobra has parsed them. From here on
+// errors are reported without the usage text, since they are not caused by
+// how the command was invoked unless marked as usage errors.
+func preRun(cmd *cobra.Command, args []string) error {
+	commandStarted = true
+	cmd.SilenceUsage = true
+
+	if err := loadConfig(cmd); err != nil {
+		return err
+	}
+	if err := loadBaseline(); err != nil {
+		return err
+	}
+
+	policy, err := exitcode.ParseFailOn(failOn)
+	if err != nil {
+		return err
+	}
+	failPolicy = policy
+
+	if parallelism < 1 {
+		return exitcode.UsageError(fmt.Errorf("--parallelism must be at least 1"))
+	}
+	if checkTimeout < 0 {
+		return exitcode.UsageError(fmt.Errorf("--check-timeout must not be negative"))
+	}
+	// ingress has its own --output flag
+	format := outputFormat
+	if flag := cmd.Flag("output"); flag != nil {
+		format = flag.Value.String()
+	}
+	if err := output.ValidateFormat(format); err != nil {
+		return exitcode.UsageError(err)
+	}
+	if colorMode, err = output.ParseColorMode(colorFlag); err != nil {
+		return exitcode.UsageError(err)
+	}
+	if prometheusTextfile != "" && output.OutputFormat(strings.ToLower(format)) != output.FormatPrometheus {
+		return exitcode.UsageError(fmt.Errorf("--prometheus-textfile requires -o prometheus"))
+	}
+
+	if outputFileName != "" {
+		if outputFile, err = os.Create(outputFileName); err != nil {
+			return exitcode.UsageError(fmt.Errorf("failed to create output file: %w", err))
+		}
+	}
+
+	return nil
+}
+
+// checkFindings applies the --fail-on policy to the findings of the reports
+// a command printed.
+func checkFindings(reports ...*output.DiagnosticReport) error {
+	return failPolicy.CheckReports(reports)
 }
 
 var (
@@ -44,12 +131,177 @@ var (
 	namespace    string
 	outputFormat string
 	verbose      bool
+	fromSnapshot string
+	kubeContext  string
+	kubeContexts []string
+	allContexts  bool
+	qps          float32
+	burst        int
+	chunkSize    int64
+	asUser       string
+	asGroups     []string
+	asUID        string
+	failOn       string
+	parallelism  int
+	checkTimeout time.Duration
+
+	configFile              string
+	customCheckPaths        []string
+	pluginDirs              []string
+	noPlugins               bool
+	baselineFile            string
+	outputFileName          string
+	colorFlag               string
+	junitWarningsAsFailures bool
+	prometheusTextfile      string
+
+	// failPolicy is the parsed --fail-on value
+	failPolicy = exitcode.FailOnFailed
+
+	// colorMode is the parsed --color value
+	colorMode = output.ColorModeAuto
+
+	// settings is the loaded configuration file
+	settings = &config.Config{}
+
+	// suppressions is the baseline loaded with --baseline
+	suppressions *baseline.Baseline
+
+	// customChecks are the custom checks loaded from the configuration and
+	// --custom-checks
+	customChecks []*custom.Check
+
+	// discoveredPlugins are the check plugins found, plugins the checks
+	// they provide and pluginErrors why plugins could not be loaded
+	discoveredPlugins []*plugin.Plugin
+	plugins           []*plugin.Check
+	pluginErrors      []error
+
+	// outputFile is the file opened for --output-file
+	outputFile *os.File
+
+	// commandStarted is set once flags and arguments were accepted
+	commandStarted bool
 )
 
 func init() {
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return exitcode.UsageError(err)
+	})
+
 	// Global persistent flags that apply to all commands
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "configuration file to use instead of ~/.config/kdebug/config.yaml and .kdebug.yaml")
 	rootCmd.PersistentFlags().StringVar(&kubeconfig, "kubeconfig", "", "path to kubeconfig file (defaults to $HOME/.kube/config)")
 	rootCmd.PersistentFlags().StringVarP(&namespace, "namespace", "n", "default", "Kubernetes namespace")
-	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "table", "output format: table, json, yaml")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "table", "output format: table, json, yaml, sarif, junit, html, markdown, ndjson, prometheus, template=TEMPLATE, template-file=FILE, jsonpath=EXPR")
+	rootCmd.PersistentFlags().StringVar(&outputFileName, "output-file", "", "write the output to this file instead of stdout")
+	rootCmd.PersistentFlags().StringVar(&colorFlag, "color", string(output.ColorModeAuto), "color output: auto (terminals unless NO_COLOR is set), always, never")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output for debugging")
+	rootCmd.PersistentFlags().StringVar(&fromSnapshot, "from-snapshot", "", "diagnose offline from a cluster snapshot directory or .tar.gz archive instead of a live cluster")
+	rootCmd.PersistentFlags().StringVar(&kubeContext, "context", "", "kubeconfig context to use (defaults to the current context)")
+	rootCmd.PersistentFlags().StringSliceVar(&kubeContexts, "contexts", nil, "comma-separated kubeconfig contexts to diagnose concurrently, combined into one report")
+	rootCmd.PersistentFlags().BoolVar(&allContexts, "all-contexts", false, "diagnose every context in the kubeconfig concurrently, combined into one report")
+	rootCmd.PersistentFlags().Float32Var(&qps, "qps", 0, "maximum API requests per second (0 uses the client-go default of 5)")
+	rootCmd.PersistentFlags().IntVar(&burst, "burst", 0, "maximum burst of API requests above --qps (0 uses the client-go default of 10)")
+	rootCmd.PersistentFlags().StringVar(&asUser, "as", "", "username or service account (system:serviceaccount:<namespace>:<name>) to impersonate")
+	rootCmd.PersistentFlags().StringArrayVar(&asGroups, "as-group", nil, "group to impersonate; can be repeated to specify multiple groups")
+	rootCmd.PersistentFlags().StringVar(&asUID, "as-uid", "", "UID to impersonate")
+	rootCmd.PersistentFlags().StringSliceVar(&customCheckPaths, "custom-checks", nil, "comma-separated files or directories of custom check definitions, loaded after those of the configuration")
+	rootCmd.PersistentFlags().StringSliceVar(&pluginDirs, "plugin-dir", nil, "comma-separated directories searched for kdebug-check-* plugins before ~/.config/kdebug/plugins and PATH")
+	rootCmd.PersistentFlags().BoolVar(&noPlugins, "no-plugins", false, "do not run check plugins")
+	rootCmd.PersistentFlags().StringVar(&baselineFile, "baseline", "", "baseline file of accepted findings, which are reported as SUPPRESSED and do not count towards --fail-on")
+	rootCmd.PersistentFlags().StringVar(&failOn, "fail-on", string(exitcode.FailOnFailed), "lowest finding level that makes kdebug exit non-zero: never, failed, warning")
+	rootCmd.PersistentFlags().IntVar(&parallelism, "parallelism", 4, "number of pods, services or ingresses diagnosed concurrently with --all")
+	rootCmd.PersistentFlags().DurationVar(&checkTimeout, "check-timeout", 10*time.Second, "deadline for each check; a check that exceeds it is reported as SKIPPED (0 disables)")
+	rootCmd.PersistentFlags().BoolVar(&junitWarningsAsFailures, "junit-warnings-as-failures", false, "report warnings as failures rather than system-out with -o junit")
+	rootCmd.PersistentFlags().StringVar(&prometheusTextfile, "prometheus-textfile", "", "with -o prometheus, atomically replace this file with the metrics instead of printing them, for node-exporter's textfile collector")
+	rootCmd.PersistentFlags().Int64Var(&chunkSize, "chunk-size", client.DefaultChunkSize, "number of objects fetched per LIST request (0 disables pagination)")
+}
+
+// newKubernetesClient returns a client for the live cluster, or one backed by
+// the snapshot given with --from-snapshot. Errors are configuration errors.
+func newKubernetesClient(kubeconfig string) (*client.KubernetesClient, error) {
+	k8sClient, err := loadKubernetesClient(kubeconfig)
+	return k8sClient, exitcode.UsageError(err)
+}
+
+func loadKubernetesClient(kubeconfig string) (*client.KubernetesClient, error) {
+	if fromSnapshot != "" {
+		if kubeContext != "" {
+			return nil, fmt.Errorf("--context cannot be combined with --from-snapshot")
+		}
+		if asUser != "" || len(asGroups) > 0 || asUID != "" {
+			return nil, fmt.Errorf("--as, --as-group and --as-uid cannot be combined with --from-snapshot")
+		}
+		return snapshot.LoadClient(fromSnapshot)
+	}
+
+	return client.NewKubernetesClientWithOptions(clientOptions(kubeconfig, kubeContext))
+}
+
+// clientOptions returns the options for a live client built from the global
+// connection flags.
+func clientOptions(kubeconfig, contextName string) client.Options {
+	return client.Options{
+		Kubeconfig: kubeconfig,
+		Context:    contextName,
+		QPS:        qps,
+		Burst:      burst,
+		ChunkSize:  chunkSize,
+		Impersonate: rest.ImpersonationConfig{
+			UserName: asUser,
+			Groups:   asGroups,
+			UID:      asUID,
+		},
+	}
+}
+
+// newOutputManager returns an output manager that knows the kdebug version
+// and the registered checks.
+func newOutputManager(format string, verbose bool) *output.OutputManager {
+	outputMgr := output.NewOutputManager(format, verbose)
+	outputMgr.Version = rootCmd.Version
+	outputMgr.Rules = checkRules()
+	outputMgr.WarningsAsFailures = junitWarningsAsFailures
+	outputMgr.TextfilePath = prometheusTextfile
+	outputMgr.Out = commandOutput()
+	outputMgr.Color = colorMode.Enabled(outputMgr.Out)
+	outputMgr.ASCII = !outputMgr.Color
+	if suppressions != nil {
+		outputMgr.Baseline = suppressions
+		warnExpiredBaseline(outputMgr)
+	}
+	warnPluginErrors(outputMgr)
+	return outputMgr
+}
+
+// commandOutput returns where commands write their output: the file given
+// with --output-file, or stdout.
+func commandOutput() io.Writer {
+	if outputFile != nil {
+		return outputFile
+	}
+	return os.Stdout
+}
+
+// closeOutputFile closes the file opened for --output-file once the command
+// returned.
+func closeOutputFile() error {
+	if outputFile == nil {
+		return nil
+	}
+	if err := outputFile.Close(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return nil
+}
+
+// printAPIRequestStats reports how many API requests a run made in verbose
+// mode. Clients that do not talk to an API server report nothing.
+func printAPIRequestStats(outputMgr *output.OutputManager, k8sClient *client.KubernetesClient) {
+	if !outputMgr.Verbose || k8sClient.Requests == nil {
+		return
+	}
+
+	outputMgr.PrintInfo(fmt.Sprintf("API requests made: %d", k8sClient.Requests.Count()))
 }
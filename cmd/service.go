@@ -8,6 +8,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"kdebug/internal/client"
+	"kdebug/internal/exitcode"
 	"kdebug/internal/output"
 	"kdebug/pkg/service"
 )
@@ -45,7 +46,8 @@ func init() {
 
 	// Service-specific flags
 	serviceCmd.Flags().BoolP("all", "a", false, "Diagnose all services in the specified namespace")
-	serviceCmd.Flags().StringSlice("checks", []string{}, "Comma-separated list of checks to run (config,selector,endpoints,ports)")
+	addCheckFlags(serviceCmd)
+	usePlugins(serviceCmd)
 	serviceCmd.Flags().Bool("test-dns", false, "Include DNS resolution testing for the service")
 	serviceCmd.Flags().Bool("all-namespaces", false, "Check services across all namespaces")
 	serviceCmd.Flags().Duration("timeout", 30*time.Second, "Timeout for service diagnostics")
@@ -54,66 +56,104 @@ func init() {
 func runServiceDiagnostics(cmd *cobra.Command, args []string) error {
 	// Parse flags
 	allServices, _ := cmd.Flags().GetBool("all")
-	checks, _ := cmd.Flags().GetStringSlice("checks")
 	testDNS, _ := cmd.Flags().GetBool("test-dns")
 	allNamespaces, _ := cmd.Flags().GetBool("all-namespaces")
 	timeout, _ := cmd.Flags().GetDuration("timeout")
 
 	// Get global flags
-	outputFormat, _ := cmd.Flags().GetString("outputFormat")
+	outputFormat, _ := cmd.Flags().GetString("output")
 	verbose, _ := cmd.Flags().GetBool("verbose")
 	kubeconfig, _ := cmd.Flags().GetString("kubeconfig")
 	namespace, _ := cmd.Flags().GetString("namespace")
 
+	checks, skipChecks, err := checkFlags(cmd)
+	if err != nil {
+		return err
+	}
+
 	// Validate arguments
 	if !allServices && !allNamespaces && len(args) == 0 {
-		return fmt.Errorf("service name is required when --all and --all-namespaces are not specified")
+		return exitcode.UsageError(fmt.Errorf("service name is required when --all and --all-namespaces are not specified"))
 	}
 
 	if len(args) > 1 {
-		return fmt.Errorf("only one service name is supported")
+		return exitcode.UsageError(fmt.Errorf("only one service name is supported"))
 	}
 
 	// Create context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	// Initialize Kubernetes client
-	kubeClient, err := client.NewKubernetesClient(kubeconfig)
-	if err != nil {
-		return fmt.Errorf("failed to create Kubernetes client: %w", err)
-	}
-
 	// Initialize output manager
-	outputMgr := output.NewOutputManager(outputFormat, verbose)
-
-	// Initialize service diagnostic
-	serviceDiag := service.NewServiceDiagnostic(kubeClient, outputMgr)
+	outputMgr := newOutputManager(outputFormat, verbose)
 
 	// Create diagnostic configuration
 	config := service.DiagnosticConfig{
 		Namespace:     namespace,
 		Checks:        checks,
+		SkipChecks:    skipChecks,
 		TestDNS:       testDNS,
 		AllNamespaces: allNamespaces,
 		Timeout:       timeout,
 		Verbose:       verbose,
+		CheckTimeout:  checkTimeout,
+		Parallelism:   parallelism,
+		CustomChecks:  customChecks,
+		Plugins:       plugins,
 	}
 
+	contexts, err := targetContexts(kubeconfig)
+	if err != nil {
+		return err
+	}
+	if len(contexts) > 0 {
+		target := fmt.Sprintf("services in namespace %s", namespace)
+		switch {
+		case allNamespaces:
+			target = "services in all namespaces"
+		case !allServices:
+			target = fmt.Sprintf("service/%s", args[0])
+		}
+
+		_, err := runAcrossContexts(ctx, outputMgr, kubeconfig, target, contexts,
+			func(ctx context.Context, k8sClient *client.KubernetesClient, contextOutput *output.OutputManager) ([]*output.DiagnosticReport, error) {
+				serviceDiag := service.NewServiceDiagnostic(k8sClient, contextOutput)
+				if allServices || allNamespaces {
+					return serviceDiag.DiagnoseAllServices(ctx, config)
+				}
+				report, err := serviceDiag.DiagnoseService(ctx, args[0], config)
+				return []*output.DiagnosticReport{report}, err
+			})
+		return err
+	}
+
+	// Initialize Kubernetes client
+	kubeClient, err := newKubernetesClient(kubeconfig)
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	// Test connectivity
+	if err := kubeClient.TestConnection(ctx); err != nil {
+		outputMgr.PrintError("Kubernetes connectivity check failed", err)
+		return exitcode.ConnectivityError(err)
+	}
+
+	// Initialize service diagnostic
+	serviceDiag := service.NewServiceDiagnostic(kubeClient, outputMgr)
+
 	// Run diagnostics
+	var reports []*output.DiagnosticReport
 	if allServices || allNamespaces {
 		// Diagnose all services
-		reports, err := serviceDiag.DiagnoseAllServices(ctx, config)
+		reports, err = serviceDiag.DiagnoseAllServices(ctx, config)
 		if err != nil {
 			return fmt.Errorf("failed to diagnose services: %w", err)
 		}
 
 		// Print results
-		for _, report := range reports {
-			if err := outputMgr.PrintReport(report); err != nil {
-				return fmt.Errorf("failed to print report: %w", err)
-			}
-			fmt.Println() // Add spacing between reports
+		if err := outputMgr.PrintReports(reports); err != nil {
+			return fmt.Errorf("failed to print report: %w", err)
 		}
 
 		// Print summary
@@ -133,9 +173,12 @@ func runServiceDiagnostics(cmd *cobra.Command, args []string) error {
 		if err := outputMgr.PrintReport(report); err != nil {
 			return fmt.Errorf("failed to print report: %w", err)
 		}
+		reports = append(reports, report)
 	}
 
-	return nil
+	printAPIRequestStats(outputMgr, kubeClient)
+
+	return checkFindings(reports...)
 }
 
 // printServicesSummary prints a summary of all service diagnostic results.
@@ -159,16 +202,17 @@ func printServicesSummary(outputMgr *output.OutputManager, reports []*output.Dia
 		}
 	}
 
-	fmt.Printf("\n📊 Service Health Summary:\n")
-	fmt.Printf("   Total Services: %d\n", totalServices)
-	fmt.Printf("   ✅ Healthy: %d\n", healthyServices)
-	fmt.Printf("   ⚠️  Warnings: %d\n", warningServices)
-	fmt.Printf("   ❌ Unhealthy: %d\n", unhealthyServices)
+	w := outputMgr.Out
+	fmt.Fprintf(w, "\n%sService Health Summary:\n", outputMgr.Icon("📊"))
+	fmt.Fprintf(w, "   Total Services: %d\n", totalServices)
+	fmt.Fprintf(w, "   %sHealthy: %d\n", outputMgr.Icon("✅"), healthyServices)
+	fmt.Fprintf(w, "   %sWarnings: %d\n", outputMgr.Icon("⚠️ "), warningServices)
+	fmt.Fprintf(w, "   %sUnhealthy: %d\n", outputMgr.Icon("❌"), unhealthyServices)
 
 	if unhealthyServices > 0 {
-		fmt.Printf("\n🎯 Priority Actions:\n")
-		fmt.Printf("   1. Investigate services with failed checks\n")
-		fmt.Printf("   2. Verify pod health and readiness for services with endpoint issues\n")
-		fmt.Printf("   3. Check service selectors match pod labels\n")
+		fmt.Fprintf(w, "\n%sPriority Actions:\n", outputMgr.Icon("🎯"))
+		fmt.Fprintf(w, "   1. Investigate services with failed checks\n")
+		fmt.Fprintf(w, "   2. Verify pod health and readiness for services with endpoint issues\n")
+		fmt.Fprintf(w, "   3. Check service selectors match pod labels\n")
 	}
 }
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"kdebug/internal/exitcode"
+	"kdebug/internal/snapshot"
+	"kdebug/pkg/pod"
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Capture everything kdebug reads into a reproducible archive",
+	Long: `Capture the cluster state read by the pod, service, ingress and cluster
+diagnostics and by custom checks into a single versioned archive:
+
+• Pods, Nodes, Events, Services, Endpoints, EndpointSlices, Ingresses and ServiceAccounts as YAML
+• Every other kind custom checks can target or relate to, such as Deployments
+  and ConfigMaps; ConfigMap data is captured unredacted unless
+  --redact-configmaps is set
+• TLS secrets referenced by ingresses, with all secret values redacted
+• Bounded container logs of failing pods
+• The API server version and a manifest describing the archive
+
+Attach the archive to an incident ticket and reproduce the exact kdebug
+findings later with --from-snapshot.`,
+	Example: `  # Capture the current namespace
+  kdebug snapshot -n production -f incident-1234.tar.gz
+
+  # Capture all namespaces without container logs
+  kdebug snapshot --all-namespaces --include-logs=false
+
+  # Keep ConfigMap values out of an archive shared outside the team
+  kdebug snapshot -n production --redact-configmaps
+
+  # Reproduce the findings later
+  kdebug pod --all -n production --from-snapshot incident-1234.tar.gz`,
+	Args: cobra.NoArgs,
+	RunE: runSnapshot,
+}
+
+func init() {
+	rootCmd.AddCommand(snapshotCmd)
+
+	snapshotCmd.Flags().StringP("file", "f", "", "archive to write (default kdebug-snapshot-<timestamp>.tar.gz)")
+	snapshotCmd.Flags().Bool("all-namespaces", false, "capture all namespaces")
+	snapshotCmd.Flags().Bool("include-logs", true, "capture container logs of failing pods")
+	snapshotCmd.Flags().Int("log-lines", 200, "maximum number of log lines captured per container")
+	snapshotCmd.Flags().Int64("log-bytes", 256*1024, "maximum number of log bytes captured per container")
+	snapshotCmd.Flags().Bool("redact-configmaps", false, "drop the values of ConfigMaps, keeping their keys")
+	snapshotCmd.Flags().Duration("timeout", 2*time.Minute, "timeout for capturing the snapshot")
+}
+
+func runSnapshot(cmd *cobra.Command, args []string) error {
+	file, _ := cmd.Flags().GetString("file")
+	allNamespaces, _ := cmd.Flags().GetBool("all-namespaces")
+	includeLogs, _ := cmd.Flags().GetBool("include-logs")
+	logLines, _ := cmd.Flags().GetInt("log-lines")
+	logBytes, _ := cmd.Flags().GetInt64("log-bytes")
+	redactConfigMaps, _ := cmd.Flags().GetBool("redact-configmaps")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+
+	if fromSnapshot != "" {
+		return exitcode.UsageError(fmt.Errorf("cannot capture a snapshot with --from-snapshot"))
+	}
+	if len(kubeContexts) > 0 || allContexts {
+		return exitcode.UsageError(fmt.Errorf("a snapshot captures a single cluster; use --context to select it"))
+	}
+
+	outputMgr := newOutputManager(outputFormat, verbose)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	k8sClient, err := newKubernetesClient(kubeconfig)
+	if err != nil {
+		outputMgr.PrintError("Failed to initialize Kubernetes client", err)
+		return err
+	}
+
+	if err := k8sClient.TestConnection(ctx); err != nil {
+		outputMgr.PrintError("Failed to connect to Kubernetes cluster", err)
+		return exitcode.ConnectivityError(err)
+	}
+
+	opts := snapshot.CaptureOptions{
+		LogLines:      logLines,
+		MaxLogBytes:   logBytes,
+		KdebugVersion: rootCmd.Version,
+
+		RedactConfigMaps: redactConfigMaps,
+	}
+	if includeLogs {
+		opts.LogsFor = pod.IsPodFailing
+	}
+	if !allNamespaces {
+		opts.Namespaces = []string{namespace}
+	}
+
+	outputMgr.PrintInfo("Capturing cluster snapshot...")
+
+	snap, err := snapshot.Capture(ctx, k8sClient, opts)
+	if err != nil {
+		outputMgr.PrintError("Failed to capture snapshot", err)
+		return err
+	}
+
+	if file == "" {
+		file = fmt.Sprintf("kdebug-snapshot-%s.tar.gz", time.Now().UTC().Format("20060102-150405"))
+	}
+
+	archive, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot archive: %w", err)
+	}
+	defer archive.Close()
+
+	if err := snap.WriteArchive(archive); err != nil {
+		return fmt.Errorf("failed to write snapshot archive: %w", err)
+	}
+
+	for _, warning := range snap.Manifest.Warnings {
+		outputMgr.PrintWarning(warning)
+	}
+
+	outputMgr.PrintSuccess(fmt.Sprintf("Snapshot with %d objects and %d container logs written to %s",
+		len(snap.Objects), len(snap.Logs), file))
+
+	return archive.Close()
+}
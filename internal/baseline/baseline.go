@@ -0,0 +1,327 @@
+// Package baseline loads baseline files, which list accepted findings so that
+// CI only fails on new problems, and generates them from saved reports.
+//
+// A baseline entry matches results by check ID, kubeconfig context and
+// resource, where empty fields match anything and the other fields may use
+// path.Match wildcards. Resources with generated names, such as the pods of a
+// Deployment, are matched by their controller instead of their name. Matching failures and warnings are reported as
+// SUPPRESSED with the entry's justification until the entry expires.
+package baseline
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"kdebug/internal/diff"
+	"kdebug/internal/output"
+)
+
+// SchemaVersion is the version of the baseline file format.
+const SchemaVersion = "1"
+
+// DateFormat is the format of expiry dates.
+const DateFormat = "2006-01-02"
+
+// File is the schema of a baseline file.
+type File struct {
+	SchemaVersion string  `json:"schema_version" yaml:"schema_version"`
+	Suppressions  []Entry `json:"suppressions" yaml:"suppressions"`
+}
+
+// Entry accepts the findings it matches.
+type Entry struct {
+	// Check is the check ID, e.g. POD-LOGS
+	Check string `json:"check,omitempty" yaml:"check,omitempty"`
+
+	// Context is the kubeconfig context of multi-context runs
+	Context string `json:"context,omitempty" yaml:"context,omitempty"`
+
+	Resource *Resource `json:"resource,omitempty" yaml:"resource,omitempty"`
+
+	// Status limits the entry to FAILED or WARNING results, so that a
+	// warning that becomes a failure is reported again
+	Status output.CheckStatus `json:"status,omitempty" yaml:"status,omitempty"`
+
+	// Justification explains why the finding is accepted; it is shown
+	// with every suppressed result
+	Justification string `json:"justification" yaml:"justification"`
+
+	// Expires is the last day the entry applies, as YYYY-MM-DD
+	Expires string `json:"expires,omitempty" yaml:"expires,omitempty"`
+}
+
+// Resource matches the resource a finding is about.
+type Resource struct {
+	Kind      string `json:"kind,omitempty" yaml:"kind,omitempty"`
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Name      string `json:"name,omitempty" yaml:"name,omitempty"`
+
+	// Controller matches the workload controlling a resource with a
+	// generated name; resources without a controller never match it
+	Controller *Controller `json:"controller,omitempty" yaml:"controller,omitempty"`
+}
+
+// Controller matches the workload controlling a resource, e.g. the
+// Deployment of a pod.
+type Controller struct {
+	Kind string `json:"kind,omitempty" yaml:"kind,omitempty"`
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+}
+
+// Baseline is a loaded baseline file. It implements output.Suppressor.
+type Baseline struct {
+	// Path is the file the baseline was loaded from
+	Path string
+
+	entries []Entry
+	now     func() time.Time
+}
+
+// Load loads and validates a baseline file. Unknown fields are an error, so
+// that misspelt matchers do not silently accept every finding.
+func Load(path string) (*Baseline, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline file: %w", err)
+	}
+	defer file.Close()
+
+	var doc File
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&doc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid baseline file %s: %w", path, err)
+	}
+	if err := doc.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid baseline file %s: %w", path, err)
+	}
+
+	return &Baseline{Path: path, entries: doc.Suppressions, now: time.Now}, nil
+}
+
+// Validate checks that every entry matches something specific, explains
+// itself and has a valid status and expiry date.
+func (f *File) Validate() error {
+	if f.SchemaVersion != "" && f.SchemaVersion != SchemaVersion {
+		return fmt.Errorf("unsupported schema_version %q (want %s)", f.SchemaVersion, SchemaVersion)
+	}
+
+	for i, entry := range f.Suppressions {
+		if entry.Check == "" && entry.Resource == nil {
+			return fmt.Errorf("suppressions[%d]: check or resource is required", i)
+		}
+		if strings.TrimSpace(entry.Justification) == "" {
+			return fmt.Errorf("suppressions[%d]: justification is required", i)
+		}
+		switch entry.Status {
+		case "", output.StatusFailed, output.StatusWarning:
+		default:
+			return fmt.Errorf("suppressions[%d]: status must be %s or %s, got %q", i, output.StatusFailed, output.StatusWarning, entry.Status)
+		}
+		if entry.Expires != "" {
+			if _, err := time.Parse(DateFormat, entry.Expires); err != nil {
+				return fmt.Errorf("suppressions[%d]: expires must be a YYYY-MM-DD date, got %q", i, entry.Expires)
+			}
+		}
+		for _, pattern := range []string{entry.Check, entry.Context} {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("suppressions[%d]: invalid pattern %q", i, pattern)
+			}
+		}
+		if entry.Resource != nil {
+			patterns := []string{entry.Resource.Kind, entry.Resource.Namespace, entry.Resource.Name}
+			if controller := entry.Resource.Controller; controller != nil {
+				patterns = append(patterns, controller.Kind, controller.Name)
+			}
+			for _, pattern := range patterns {
+				if _, err := path.Match(pattern, ""); err != nil {
+					return fmt.Errorf("suppressions[%d]: invalid pattern %q", i, pattern)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Suppress marks the failures and warnings matched by an active entry as
+// suppressed. The first matching entry provides the justification.
+func (b *Baseline) Suppress(contextName string, results []output.CheckResult) {
+	today := b.today()
+	for i := range results {
+		result := &results[i]
+		if result.Status != output.StatusFailed && result.Status != output.StatusWarning {
+			continue
+		}
+
+		for _, entry := range b.entries {
+			if entry.expired(today) || !entry.matches(contextName, *result) {
+				continue
+			}
+
+			result.Suppression = &output.Suppression{
+				Status:        result.Status,
+				Justification: entry.Justification,
+				Expires:       entry.Expires,
+			}
+			result.Status = output.StatusSuppressed
+			break
+		}
+	}
+}
+
+// Expired returns the entries whose expiry date has passed.
+func (b *Baseline) Expired() []Entry {
+	today := b.today()
+
+	var expired []Entry
+	for _, entry := range b.entries {
+		if entry.expired(today) {
+			expired = append(expired, entry)
+		}
+	}
+	return expired
+}
+
+// today returns the current date as YYYY-MM-DD.
+func (b *Baseline) today() string {
+	now := time.Now
+	if b.now != nil {
+		now = b.now
+	}
+	return now().Format(DateFormat)
+}
+
+// expired reports whether the entry no longer applies on the given day; an
+// entry applies through its expiry date. Dates in DateFormat sort in time
+// order.
+func (e Entry) expired(today string) bool {
+	return e.Expires != "" && e.Expires < today
+}
+
+// matches reports whether the entry accepts a result.
+func (e Entry) matches(contextName string, result output.CheckResult) bool {
+	if e.Status != "" && e.Status != result.Status {
+		return false
+	}
+	if !match(e.Check, result.ID) || !match(e.Context, contextName) {
+		return false
+	}
+	if e.Resource == nil {
+		return true
+	}
+
+	resource := result.Resource
+	if resource == nil {
+		return false
+	}
+	if controller := e.Resource.Controller; controller != nil {
+		if resource.Controller == nil ||
+			!match(controller.Kind, resource.Controller.Kind) || !match(controller.Name, resource.Controller.Name) {
+			return false
+		}
+	}
+	return match(e.Resource.Kind, resource.Kind) &&
+		match(e.Resource.Namespace, resource.Namespace) &&
+		match(e.Resource.Name, resource.Name)
+}
+
+// String describes the entry for warnings.
+func (e Entry) String() string {
+	parts := []string{}
+	if e.Check != "" {
+		parts = append(parts, e.Check)
+	}
+	if e.Context != "" {
+		parts = append(parts, "context "+e.Context)
+	}
+	if e.Resource != nil {
+		ref := output.ResourceRef{Kind: e.Resource.Kind, Namespace: e.Resource.Namespace, Name: e.Resource.Name}
+		description := ref.String()
+		if controller := e.Resource.Controller; controller != nil {
+			description += " of " + output.ResourceRef{Kind: controller.Kind, Name: controller.Name}.String()
+		}
+		parts = append(parts, description)
+	}
+	return strings.Join(parts, " on ")
+}
+
+// match reports whether value matches a pattern, ignoring case; an empty
+// pattern matches anything.
+func match(pattern, value string) bool {
+	if pattern == "" {
+		return true
+	}
+	ok, err := path.Match(strings.ToLower(pattern), strings.ToLower(value))
+	return err == nil && ok
+}
+
+// Generate creates a baseline accepting every failure and warning of the
+// results, one entry per check, context, resource and status. Resources with
+// generated names, such as the pods of a Deployment, are matched by their
+// controller instead of their name, so that the entry still matches after
+// the next rollout without matching the pods of other workloads. Results that are already suppressed keep their
+// justification and expiry date.
+func Generate(results []diff.Result, justification, expires string) *File {
+	file := &File{SchemaVersion: SchemaVersion, Suppressions: []Entry{}}
+	seen := make(map[string]bool)
+
+	for _, result := range results {
+		entry := Entry{
+			Check:         result.ID,
+			Context:       result.Context,
+			Status:        result.Status,
+			Justification: justification,
+			Expires:       expires,
+		}
+		switch result.Status {
+		case output.StatusFailed, output.StatusWarning:
+		case output.StatusSuppressed:
+			if result.Suppression == nil {
+				continue
+			}
+			entry.Status = result.Suppression.Status
+			entry.Justification = result.Suppression.Justification
+			entry.Expires = result.Suppression.Expires
+		default:
+			continue
+		}
+		if ref := result.Resource; ref != nil {
+			entry.Resource = &Resource{Kind: ref.Kind, Namespace: ref.Namespace, Name: ref.Name}
+			// Generated names change with every rollout; accept every
+			// resource of the controller instead
+			if ref.Controller != nil {
+				entry.Resource.Name = ""
+				entry.Resource.Controller = &Controller{Kind: ref.Controller.Kind, Name: ref.Controller.Name}
+			}
+		}
+		if entry.Check == "" && entry.Resource == nil {
+			continue
+		}
+
+		key := entry.Context + "|" + entry.Check + "|" + entry.String() + "|" + string(entry.Status)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		file.Suppressions = append(file.Suppressions, entry)
+	}
+
+	sort.SliceStable(file.Suppressions, func(i, j int) bool {
+		a, b := file.Suppressions[i], file.Suppressions[j]
+		if a.Context != b.Context {
+			return a.Context < b.Context
+		}
+		return a.Check < b.Check
+	})
+
+	return file
+}
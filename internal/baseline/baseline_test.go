@@ -0,0 +1,222 @@
+package baseline
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"kdebug/internal/diff"
+	"kdebug/internal/output"
+)
+
+func writeBaseline(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "baseline.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func podResult(id string, status output.CheckStatus, namespace, pod string) output.CheckResult {
+	return output.CheckResult{
+		ID:       id,
+		Name:     id,
+		Status:   status,
+		Resource: &output.ResourceRef{Kind: "Pod", Namespace: namespace, Name: pod},
+	}
+}
+
+func TestSuppress(t *testing.T) {
+	baseline, err := Load(writeBaseline(t, `
+schema_version: "1"
+suppressions:
+  - check: POD-LOGS
+    resource: {kind: Pod, namespace: legacy, name: "batch-*"}
+    justification: Legacy batch jobs log errors on retry
+  - check: pod-restarts
+    status: WARNING
+    justification: Restarts are expected during the migration
+    expires: "2026-03-31"
+  - check: POD-IMAGE-PULL
+    context: staging
+    justification: Staging pulls from a private mirror
+  - check: POD-NETWORK
+    justification: Expired
+    expires: "2026-01-31"
+`))
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	baseline.now = func() time.Time { return time.Date(2026, 3, 31, 23, 0, 0, 0, time.UTC) }
+
+	results := []output.CheckResult{
+		podResult("POD-LOGS", output.StatusFailed, "legacy", "batch-7f9c"),
+		podResult("POD-LOGS", output.StatusFailed, "legacy", "api"),
+		podResult("POD-RESTARTS", output.StatusWarning, "default", "web"),
+		podResult("POD-RESTARTS", output.StatusFailed, "default", "web"),
+		podResult("POD-IMAGE-PULL", output.StatusFailed, "default", "web"),
+		podResult("POD-NETWORK", output.StatusWarning, "default", "web"),
+		podResult("POD-LOGS", output.StatusPassed, "legacy", "batch-1"),
+	}
+	baseline.Suppress("staging", results)
+
+	want := []output.CheckStatus{
+		output.StatusSuppressed,
+		output.StatusFailed,
+		output.StatusSuppressed,
+		output.StatusFailed,
+		output.StatusSuppressed,
+		output.StatusWarning,
+		output.StatusPassed,
+	}
+	for i, status := range want {
+		if results[i].Status != status {
+			t.Errorf("results[%d] (%s on %s) = %s, want %s", i, results[i].ID, results[i].Resource, results[i].Status, status)
+		}
+	}
+
+	suppression := results[2].Suppression
+	if suppression == nil || suppression.Status != output.StatusWarning || suppression.Expires != "2026-03-31" ||
+		suppression.Justification != "Restarts are expected during the migration" {
+		t.Errorf("Unexpected suppression: %+v", suppression)
+	}
+
+	other := []output.CheckResult{podResult("POD-IMAGE-PULL", output.StatusFailed, "default", "web")}
+	baseline.Suppress("production", other)
+	if other[0].Status != output.StatusFailed {
+		t.Errorf("Expected the staging entry not to apply to production, got %s", other[0].Status)
+	}
+
+	expired := baseline.Expired()
+	if len(expired) != 1 || expired[0].Check != "POD-NETWORK" {
+		t.Errorf("Expected POD-NETWORK to have expired, got %+v", expired)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown field":         "suppressions:\n  - chek: POD-LOGS\n    justification: j\n",
+		"no matcher":            "suppressions:\n  - justification: j\n",
+		"no justification":      "suppressions:\n  - check: POD-LOGS\n",
+		"suppressed status":     "suppressions:\n  - check: POD-LOGS\n    status: PASSED\n    justification: j\n",
+		"invalid date":          "suppressions:\n  - check: POD-LOGS\n    justification: j\n    expires: 31/03/2026\n",
+		"invalid pattern":       "suppressions:\n  - check: 'POD-[LOGS'\n    justification: j\n",
+		"unknown schema":        "schema_version: \"2\"\nsuppressions: []\n",
+		"invalid resource glob": "suppressions:\n  - resource: {name: '[web'}\n    justification: j\n",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := writeBaseline(t, content)
+			_, err := Load(path)
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			if !strings.Contains(err.Error(), path) {
+				t.Errorf("Expected the error to name the file, got %v", err)
+			}
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	suppressed := podResult("POD-LOGS", output.StatusSuppressed, "legacy", "batch-1")
+	suppressed.Suppression = &output.Suppression{Status: output.StatusFailed, Justification: "Accepted earlier", Expires: "2027-01-01"}
+
+	results := []diff.Result{
+		{CheckResult: podResult("POD-RESTARTS", output.StatusWarning, "default", "web"), Context: "prod"},
+		{CheckResult: podResult("POD-RESTARTS", output.StatusWarning, "default", "web"), Context: "prod"},
+		{CheckResult: podResult("POD-RESTARTS", output.StatusPassed, "default", "api"), Context: "prod"},
+		{CheckResult: podResult("POD-IMAGE-PULL", output.StatusFailed, "default", "web"), Context: "prod"},
+		{CheckResult: output.CheckResult{ID: "CLUSTER-DNS", Status: output.StatusWarning}, Context: "prod"},
+		{CheckResult: suppressed, Context: "prod"},
+	}
+
+	file := Generate(results, "Accepted on 2026-10-16", "")
+	if len(file.Suppressions) != 4 {
+		t.Fatalf("Expected 4 entries, got %+v", file.Suppressions)
+	}
+
+	if entry := file.Suppressions[0]; entry.Check != "CLUSTER-DNS" || entry.Resource != nil || entry.Context != "prod" {
+		t.Errorf("Expected a cluster-wide entry first, got %+v", entry)
+	}
+	if entry := file.Suppressions[1]; entry.Check != "POD-IMAGE-PULL" || entry.Status != output.StatusFailed ||
+		entry.Resource == nil || entry.Resource.Name != "web" || entry.Justification != "Accepted on 2026-10-16" {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+	if entry := file.Suppressions[2]; entry.Check != "POD-LOGS" || entry.Status != output.StatusFailed ||
+		entry.Justification != "Accepted earlier" || entry.Expires != "2027-01-01" {
+		t.Errorf("Expected the existing suppression to be kept, got %+v", entry)
+	}
+	if err := file.Validate(); err != nil {
+		t.Errorf("Expected the generated baseline to be valid, got %v", err)
+	}
+
+	// The generated baseline suppresses the results it was generated from
+	baseline := &Baseline{entries: file.Suppressions}
+	current := []output.CheckResult{podResult("POD-IMAGE-PULL", output.StatusFailed, "default", "web")}
+	baseline.Suppress("prod", current)
+	if current[0].Status != output.StatusSuppressed {
+		t.Errorf("Expected the generated entry to match, got %s", current[0].Status)
+	}
+}
+
+func TestGenerateMatchesRenamedPods(t *testing.T) {
+	deploymentPod := func(pod string) output.CheckResult {
+		result := podResult("POD-IMAGE-PULL", output.StatusFailed, "default", pod)
+		result.Resource.Controller = &output.ControllerRef{Kind: "Deployment", Name: "web"}
+		return result
+	}
+
+	before := Generate([]diff.Result{
+		{CheckResult: deploymentPod("web-7d4b8c6f9-abcde")},
+		{CheckResult: deploymentPod("web-7d4b8c6f9-fghij")},
+	}, "Accepted", "")
+	if len(before.Suppressions) != 1 || before.Suppressions[0].Resource.Name != "" ||
+		*before.Suppressions[0].Resource.Controller != (Controller{Kind: "Deployment", Name: "web"}) {
+		t.Fatalf("Expected a single controller entry for the deployment's pods, got %+v", before.Suppressions)
+	}
+
+	// After a rollout the pods are renamed, but the baseline is unchanged
+	// and still accepts their findings
+	renamed := []output.CheckResult{deploymentPod("web-5f6c7d8e9-klmno")}
+	after := Generate([]diff.Result{{CheckResult: renamed[0]}}, "Accepted", "")
+	if len(after.Suppressions) != 1 || after.Suppressions[0].String() != before.Suppressions[0].String() {
+		t.Errorf("Expected regenerating after the rollout to give the same entry, got %+v", after.Suppressions)
+	}
+
+	baseline := &Baseline{entries: before.Suppressions}
+	baseline.Suppress("", renamed)
+	if renamed[0].Status != output.StatusSuppressed {
+		t.Errorf("Expected the renamed pod's finding to stay suppressed, got %s", renamed[0].Status)
+	}
+}
+
+func TestGenerateDoesNotMatchOtherWorkloads(t *testing.T) {
+	controlledPod := func(controller, pod string) output.CheckResult {
+		result := podResult("POD-IMAGE-PULL", output.StatusFailed, "default", pod)
+		result.Resource.Controller = &output.ControllerRef{Kind: "Deployment", Name: controller}
+		return result
+	}
+
+	file := Generate([]diff.Result{{CheckResult: controlledPod("web", "web-7d4b8c6f9-abcde")}}, "Accepted", "")
+	if err := file.Validate(); err != nil {
+		t.Fatalf("Expected the generated baseline to be valid, got %v", err)
+	}
+
+	// Pods of web-api share the web- prefix but belong to another workload;
+	// a bare pod named like a web pod has no controller at all
+	current := []output.CheckResult{
+		controlledPod("web-api", "web-api-5f6c7d8e9-klmno"),
+		podResult("POD-IMAGE-PULL", output.StatusFailed, "default", "web-7d4b8c6f9-pqrst"),
+	}
+	(&Baseline{entries: file.Suppressions}).Suppress("", current)
+	for _, result := range current {
+		if result.Status != output.StatusFailed {
+			t.Errorf("Expected %s not to be suppressed by the entry for deployment web", result.Resource)
+		}
+	}
+}
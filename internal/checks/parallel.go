@@ -0,0 +1,30 @@
+package checks
+
+import "sync"
+
+// Map calls fn for every item on up to parallelism goroutines and returns the
+// results in the order of items. A parallelism below one runs the items
+// sequentially.
+func Map[T, R any](parallelism int, items []T, fn func(T) R) []R {
+	results := make([]R, len(items))
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	var (
+		wg      sync.WaitGroup
+		workers = make(chan struct{}, parallelism)
+	)
+	for i, item := range items {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(i int, item T) {
+			defer wg.Done()
+			defer func() { <-workers }()
+			results[i] = fn(item)
+		}(i, item)
+	}
+	wg.Wait()
+
+	return results
+}
@@ -0,0 +1,38 @@
+package checks
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMap(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5, 6, 7, 8}
+
+	var running, peak int32
+	results := Map(3, items, func(item int) int {
+		now := atomic.AddInt32(&running, 1)
+		for {
+			old := atomic.LoadInt32(&peak)
+			if now <= old || atomic.CompareAndSwapInt32(&peak, old, now) {
+				break
+			}
+		}
+		time.Sleep(time.Duration(10-item) * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return item * item
+	})
+
+	for i, item := range items {
+		if results[i] != item*item {
+			t.Errorf("results[%d] = %d, want %d", i, results[i], item*item)
+		}
+	}
+	if peak > 3 {
+		t.Errorf("Expected at most 3 concurrent calls, got %d", peak)
+	}
+
+	if got := Map(0, items[:2], func(item int) int { return item }); len(got) != 2 {
+		t.Errorf("Expected a parallelism below one to run sequentially, got %v", got)
+	}
+}
@@ -0,0 +1,468 @@
+// Package checks is the registry of every diagnostic check kdebug runs.
+//
+// Each diagnostic package registers its checks with a stable ID, the command
+// that runs them, a category, whether they run by default, the inputs they
+// read and the RBAC permissions those reads need. Commands select checks with
+// a Selection built from --checks and --skip-checks, and run them in
+// registration order with Run. Configure applies the defaults of a
+// configuration file on top of those declared at registration.
+package checks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"kdebug/internal/output"
+)
+
+// Category groups related checks.
+type Category string
+
+const (
+	CategoryAvailability  Category = "availability"
+	CategoryConfiguration Category = "configuration"
+	CategoryControlPlane  Category = "control-plane"
+	CategoryNetworking    Category = "networking"
+	CategoryResources     Category = "resources"
+	CategoryRuntime       Category = "runtime"
+	CategoryScheduling    Category = "scheduling"
+	CategorySecurity      Category = "security"
+)
+
+// Known reports whether the category is one of the categories above.
+func (c Category) Known() bool {
+	switch c {
+	case CategoryAvailability, CategoryConfiguration, CategoryControlPlane, CategoryNetworking,
+		CategoryResources, CategoryRuntime, CategoryScheduling, CategorySecurity:
+		return true
+	default:
+		return false
+	}
+}
+
+var idPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]*(-[A-Z0-9]+)*$`)
+
+// ValidID reports whether id has the form of a check ID: upper-case letters,
+// digits and dashes, e.g. POD-IMAGE-PULL.
+func ValidID(id string) bool {
+	return idPattern.MatchString(id)
+}
+
+// Permission is an RBAC permission a check needs.
+type Permission struct {
+	Verbs    []string `json:"verbs" yaml:"verbs"`
+	Resource string   `json:"resource" yaml:"resource"`
+	Group    string   `json:"group,omitempty" yaml:"group,omitempty"`
+}
+
+// String renders the permission like a Role rule, e.g. "get,list pods".
+func (p Permission) String() string {
+	resource := p.Resource
+	if p.Group != "" {
+		resource += "." + p.Group
+	}
+	return strings.Join(p.Verbs, ",") + " " + resource
+}
+
+// Check describes a registered diagnostic check.
+type Check struct {
+	// ID is the stable, upper-case identifier, e.g. POD-IMAGE-PULL
+	ID string `json:"id" yaml:"id"`
+
+	// Name is the display name of the check, e.g. Image Pull
+	Name string `json:"name" yaml:"name"`
+
+	// Command is the kdebug command that runs the check
+	Command string `json:"command" yaml:"command"`
+
+	// Alias is the short name accepted by --checks within the command
+	Alias string `json:"alias" yaml:"alias"`
+
+	Category Category `json:"category" yaml:"category"`
+
+	// Severity is the severity of a failed result; warnings are reported
+	// one level lower and passed or skipped results as info
+	Severity output.Severity `json:"severity" yaml:"severity"`
+
+	Description    string       `json:"description" yaml:"description"`
+	DefaultEnabled bool         `json:"defaultEnabled" yaml:"defaultEnabled"`
+	Inputs         []string     `json:"inputs" yaml:"inputs"`
+	Permissions    []Permission `json:"permissions" yaml:"permissions"`
+}
+
+// Registry holds checks in registration order.
+type Registry struct {
+	mu     sync.RWMutex
+	checks []Check
+}
+
+// Default is the registry the diagnostic packages register with.
+var Default = &Registry{}
+
+// Register adds a check to the default registry and returns it.
+func Register(check Check) Check {
+	return Default.Register(check)
+}
+
+// Register adds a check and returns it, so packages can declare checks as
+// package variables. It panics when the ID or the command's alias is taken.
+func (r *Registry) Register(check Check) Check {
+	if err := r.Add(check); err != nil {
+		panic("checks: " + err.Error())
+	}
+	return check
+}
+
+// Add adds a check defined at runtime, such as a custom check. It returns an
+// error when the ID or the command's alias is taken.
+func (r *Registry) Add(check Check) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.checks {
+		if existing.ID == check.ID {
+			return fmt.Errorf("duplicate check ID %s", check.ID)
+		}
+		if existing.Command == check.Command && strings.EqualFold(existing.Alias, check.Alias) {
+			return fmt.Errorf("duplicate alias %q for command %s", check.Alias, check.Command)
+		}
+	}
+
+	r.checks = append(r.checks, check)
+	return nil
+}
+
+// All returns every check grouped by command in alphabetical order, and in
+// registration order within a command.
+func (r *Registry) All() []Check {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	all := append([]Check(nil), r.checks...)
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Command < all[j].Command
+	})
+
+	return all
+}
+
+// ForCommand returns the checks run by a command in registration order.
+func (r *Registry) ForCommand(command string) []Check {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var checks []Check
+	for _, check := range r.checks {
+		if check.Command == command {
+			checks = append(checks, check)
+		}
+	}
+
+	return checks
+}
+
+// Configure changes the defaults of registered checks, as a configuration
+// file does: the checks in enable and disable run or not when none are
+// selected with --checks, and severity overrides the severity of a check's
+// failures. Checks are named by ID; unknown IDs are an error and leave the
+// registry unchanged.
+func (r *Registry) Configure(enable, disable []string, severity map[string]output.Severity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	index := make(map[string]int, len(r.checks))
+	for i, check := range r.checks {
+		index[check.ID] = i
+	}
+	lookup := func(id string) (int, error) {
+		i, ok := index[strings.ToUpper(strings.TrimSpace(id))]
+		if !ok {
+			return 0, fmt.Errorf("unknown check %q; run 'kdebug checks list' to see the available checks", id)
+		}
+		return i, nil
+	}
+
+	checks := append([]Check(nil), r.checks...)
+	for _, id := range enable {
+		i, err := lookup(id)
+		if err != nil {
+			return err
+		}
+		checks[i].DefaultEnabled = true
+	}
+	for _, id := range disable {
+		i, err := lookup(id)
+		if err != nil {
+			return err
+		}
+		checks[i].DefaultEnabled = false
+	}
+	for id, value := range severity {
+		i, err := lookup(id)
+		if err != nil {
+			return err
+		}
+		if value.Rank() < 0 {
+			return fmt.Errorf("unknown severity %q for check %s", value, checks[i].ID)
+		}
+		checks[i].Severity = value
+	}
+
+	r.checks = checks
+	return nil
+}
+
+// Lookup resolves a check ID or alias of a command, ignoring case.
+func (r *Registry) Lookup(command, name string) (Check, bool) {
+	for _, check := range r.ForCommand(command) {
+		if strings.EqualFold(check.ID, name) || strings.EqualFold(check.Alias, name) {
+			return check, true
+		}
+	}
+
+	return Check{}, false
+}
+
+// Selection decides which checks of a command run and how long each may take.
+type Selection struct {
+	only    map[string]bool
+	skip    map[string]bool
+	timeout time.Duration
+
+	// registered holds the checks as configured in the registry, which
+	// may differ from the copies the diagnostic packages bind
+	registered map[string]Check
+}
+
+// Select builds a selection for a command from the IDs or aliases given with
+// --checks (empty runs the default-enabled checks) and --skip-checks.
+func Select(command string, only, skip []string) (Selection, error) {
+	return Default.Select(command, only, skip)
+}
+
+// Select builds a selection for a command. Unknown names are an error.
+func (r *Registry) Select(command string, only, skip []string) (Selection, error) {
+	selection := Selection{only: make(map[string]bool), skip: make(map[string]bool), registered: make(map[string]Check)}
+	for _, check := range r.ForCommand(command) {
+		selection.registered[check.ID] = check
+	}
+
+	resolve := func(names []string, into map[string]bool) error {
+		for _, name := range names {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+
+			check, ok := r.Lookup(command, name)
+			if !ok {
+				return fmt.Errorf("unknown check %q for %s; run 'kdebug checks list %s' to see the available checks", name, command, command)
+			}
+			into[check.ID] = true
+		}
+		return nil
+	}
+
+	if err := resolve(only, selection.only); err != nil {
+		return Selection{}, err
+	}
+	if err := resolve(skip, selection.skip); err != nil {
+		return Selection{}, err
+	}
+
+	return selection, nil
+}
+
+// Enabled reports whether a check runs: explicitly selected checks run, as
+// do default-enabled checks when none were selected, unless skipped.
+func (s Selection) Enabled(check Check) bool {
+	if s.skip[check.ID] {
+		return false
+	}
+	if len(s.only) > 0 {
+		return s.only[check.ID]
+	}
+	return s.configured(check).DefaultEnabled
+}
+
+// configured returns a check as configured in the registry it was selected
+// from.
+func (s Selection) configured(check Check) Check {
+	if registered, ok := s.registered[check.ID]; ok {
+		return registered
+	}
+	return check
+}
+
+// WithTimeout returns the selection with a deadline for each check; zero
+// leaves checks bounded only by the caller's context.
+func (s Selection) WithTimeout(timeout time.Duration) Selection {
+	s.timeout = timeout
+	return s
+}
+
+// Explicit reports whether a check was selected by name with --checks.
+func (s Selection) Explicit(check Check) bool {
+	return s.only[check.ID] && !s.skip[check.ID]
+}
+
+// Runnable binds a registered check to its implementation for the input of
+// type T a diagnostic package gathers before running its checks.
+type Runnable[T any] struct {
+	Check
+
+	// Run executes the check and returns its results
+	Run func(ctx context.Context, input T) []output.CheckResult
+
+	// Applies optionally reports whether a check that was not selected
+	// explicitly is relevant to the input, e.g. TLS checks on an ingress
+	// without TLS. Explicitly selected checks always run.
+	Applies func(input T) bool
+}
+
+// Run executes the selected checks in order and concatenates their results,
+// stamping each result with the ID, category and severity of its check and
+// the check's wall-clock duration. A check that does not finish within the
+// selection's timeout, or before ctx is done, is reported as skipped.
+func Run[T any](ctx context.Context, runnables []Runnable[T], selection Selection, input T) []output.CheckResult {
+	var results []output.CheckResult
+
+	for _, runnable := range runnables {
+		if !selection.Enabled(runnable.Check) {
+			continue
+		}
+		if runnable.Applies != nil && !selection.Explicit(runnable.Check) && !runnable.Applies(input) {
+			continue
+		}
+
+		runnable.Check = selection.configured(runnable.Check)
+
+		results = append(results, runCheck(ctx, runnable, selection.timeout, input)...)
+	}
+
+	return results
+}
+
+// runCheck runs a single check under its deadline and times it.
+func runCheck[T any](ctx context.Context, runnable Runnable[T], timeout time.Duration, input T) []output.CheckResult {
+	if ctx.Err() != nil {
+		return stamped(runnable.Check, 0, timedOut(runnable.Check, "Diagnostics timed out before the check started"))
+	}
+
+	checkCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		checkCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// The check runs on its own goroutine so that one stuck in a call that
+	// ignores ctx is abandoned at its deadline instead of blocking the worker
+	done := make(chan []output.CheckResult, 1)
+	start := time.Now()
+	go func() {
+		done <- runnable.Run(checkCtx, input)
+	}()
+
+	var results []output.CheckResult
+	select {
+	case results = <-done:
+	case <-checkCtx.Done():
+	}
+	elapsed := time.Since(start)
+
+	// Results computed from cancelled API calls describe the timeout rather
+	// than the resource, so they are replaced
+	switch {
+	case ctx.Err() != nil:
+		results = []output.CheckResult{timedOut(runnable.Check, "Diagnostics timed out before the check finished")}
+	case checkCtx.Err() != nil:
+		results = []output.CheckResult{timedOut(runnable.Check, fmt.Sprintf("Check did not finish within %s", timeout))}
+	}
+
+	return stamped(runnable.Check, elapsed, results...)
+}
+
+// Unavailable reports the selected checks as skipped for a resource whose
+// input could not be gathered, so that the resource stays in the report
+// instead of silently dropping out of it. Inputs that ran out of time are
+// reported like checks that did.
+func Unavailable[T any](runnables []Runnable[T], selection Selection, message string, err error) []output.CheckResult {
+	var results []output.CheckResult
+
+	for _, runnable := range runnables {
+		if !selection.Enabled(runnable.Check) {
+			continue
+		}
+		check := selection.configured(runnable.Check)
+
+		result := output.CheckResult{Name: check.Name, Status: output.StatusSkipped, Message: message}
+		if errors.Is(err, context.DeadlineExceeded) {
+			result = timedOut(check, message)
+		}
+		result.Error = err.Error()
+		results = append(results, stamped(check, 0, result)...)
+	}
+
+	return results
+}
+
+// timedOut returns the result reported for a check that ran out of time.
+func timedOut(check Check, message string) output.CheckResult {
+	return output.CheckResult{
+		Name:       check.Name,
+		Status:     output.StatusSkipped,
+		Message:    message,
+		Suggestion: "Increase --check-timeout or --timeout, or reduce --parallelism if the API server is throttling requests",
+		Details: map[string]string{
+			"reason": "timeout",
+		},
+	}
+}
+
+// stamped stamps results with their check and duration.
+func stamped(check Check, elapsed time.Duration, results ...output.CheckResult) []output.CheckResult {
+	Stamp(check, results)
+	for i := range results {
+		results[i].DurationSeconds = elapsed.Seconds()
+	}
+	return results
+}
+
+// Stamp sets the ID, category and severity of results produced by a check.
+// A severity set by the check itself is kept.
+func Stamp(check Check, results []output.CheckResult) {
+	for i := range results {
+		results[i].ID = check.ID
+		results[i].Category = string(check.Category)
+		if results[i].Severity == "" {
+			results[i].Severity = ResultSeverity(check.Severity, results[i].Status)
+		}
+	}
+}
+
+// ResultSeverity returns the severity of a result with the given status from
+// a check whose failures have the given severity.
+func ResultSeverity(severity output.Severity, status output.CheckStatus) output.Severity {
+	switch status {
+	case output.StatusFailed:
+		return severity
+	case output.StatusWarning:
+		return severity.Lower()
+	default:
+		return output.SeverityInfo
+	}
+}
+
+// Single adapts a check implementation that returns exactly one result.
+func Single[T any](fn func(ctx context.Context, input T) output.CheckResult) func(context.Context, T) []output.CheckResult {
+	return func(ctx context.Context, input T) []output.CheckResult {
+		return []output.CheckResult{fn(ctx, input)}
+	}
+}
@@ -0,0 +1,341 @@
+package checks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"kdebug/internal/output"
+)
+
+func newTestRegistry() *Registry {
+	r := &Registry{}
+	r.Register(Check{ID: "POD-A", Command: "pod", Alias: "a", DefaultEnabled: true})
+	r.Register(Check{ID: "POD-B", Name: "Check B", Command: "pod", Alias: "b", DefaultEnabled: true})
+	r.Register(Check{ID: "POD-OPT", Command: "pod", Alias: "opt"})
+	r.Register(Check{ID: "CLUSTER-A", Command: "cluster", Alias: "a", DefaultEnabled: true})
+	return r
+}
+
+func TestRegistryRejectsDuplicates(t *testing.T) {
+	for name, check := range map[string]Check{
+		"duplicate ID":    {ID: "POD-A", Command: "pod", Alias: "other"},
+		"duplicate alias": {ID: "POD-C", Command: "pod", Alias: "A"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("Expected Register to panic")
+				}
+			}()
+			newTestRegistry().Register(check)
+		})
+	}
+}
+
+func TestRegistryAdd(t *testing.T) {
+	r := newTestRegistry()
+	if err := r.Add(Check{ID: "ORG-A", Command: "pod", Alias: "org-a"}); err != nil {
+		t.Fatalf("Add() unexpected error: %v", err)
+	}
+	if err := r.Add(Check{ID: "ORG-B", Command: "cluster", Alias: "A"}); err == nil {
+		t.Error("Expected a duplicate alias to be an error")
+	}
+	if len(r.All()) != 5 {
+		t.Errorf("Expected the rejected check not to be registered, got %v", r.All())
+	}
+}
+
+func TestValidID(t *testing.T) {
+	for id, want := range map[string]bool{
+		"POD-IMAGE-PULL": true,
+		"ORG1-K8S":       true,
+		"pod-logs":       false,
+		"POD--LOGS":      false,
+		"-POD":           false,
+		"1POD":           false,
+	} {
+		if got := ValidID(id); got != want {
+			t.Errorf("ValidID(%q) = %t, want %t", id, got, want)
+		}
+	}
+}
+
+func TestRegistryAllAndForCommand(t *testing.T) {
+	r := newTestRegistry()
+
+	all := r.All()
+	if len(all) != 4 || all[0].ID != "CLUSTER-A" || all[1].ID != "POD-A" || all[3].ID != "POD-OPT" {
+		t.Errorf("Expected checks grouped by command in registration order, got %v", all)
+	}
+
+	if pod := r.ForCommand("pod"); len(pod) != 3 {
+		t.Errorf("Expected 3 pod checks, got %d", len(pod))
+	}
+}
+
+func TestSelect(t *testing.T) {
+	r := newTestRegistry()
+	check := func(id string) Check {
+		c, _ := r.Lookup("pod", id)
+		return c
+	}
+
+	tests := []struct {
+		name       string
+		only, skip []string
+		want       map[string]bool
+	}{
+		{"defaults", nil, nil, map[string]bool{"POD-A": true, "POD-B": true, "POD-OPT": false}},
+		{"only by alias", []string{"b"}, nil, map[string]bool{"POD-A": false, "POD-B": true, "POD-OPT": false}},
+		{"opt-in by ID", []string{"pod-opt", "a"}, nil, map[string]bool{"POD-A": true, "POD-B": false, "POD-OPT": true}},
+		{"skip", nil, []string{"POD-A"}, map[string]bool{"POD-A": false, "POD-B": true, "POD-OPT": false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selection, err := r.Select("pod", tt.only, tt.skip)
+			if err != nil {
+				t.Fatalf("Select() unexpected error: %v", err)
+			}
+			for id, want := range tt.want {
+				if got := selection.Enabled(check(id)); got != want {
+					t.Errorf("Enabled(%s) = %v, want %v", id, got, want)
+				}
+			}
+		})
+	}
+
+	if _, err := r.Select("pod", []string{"CLUSTER-A"}, nil); err == nil {
+		t.Error("Expected an error for a check of another command")
+	}
+	if _, err := r.Select("cluster", nil, []string{"unknown"}); err == nil {
+		t.Error("Expected an error for an unknown check")
+	}
+}
+
+func TestRun(t *testing.T) {
+	r := newTestRegistry()
+	a, _ := r.Lookup("pod", "a")
+	b, _ := r.Lookup("pod", "b")
+
+	result := func(name string) func(context.Context, int) []output.CheckResult {
+		return Single(func(context.Context, int) output.CheckResult {
+			return output.CheckResult{Name: name}
+		})
+	}
+	runnables := []Runnable[int]{
+		{Check: a, Run: result("a")},
+		{Check: b, Run: result("b"), Applies: func(input int) bool { return input > 0 }},
+	}
+
+	run := func(only []string, input int) []string {
+		selection, err := r.Select("pod", only, nil)
+		if err != nil {
+			t.Fatalf("Select() unexpected error: %v", err)
+		}
+		var names []string
+		for _, result := range Run(context.Background(), runnables, selection, input) {
+			names = append(names, result.Name)
+		}
+		return names
+	}
+
+	if got := run(nil, 1); len(got) != 2 {
+		t.Errorf("Expected both checks to run, got %v", got)
+	}
+	if got := run(nil, 0); len(got) != 1 || got[0] != "a" {
+		t.Errorf("Expected the inapplicable check to be left out by default, got %v", got)
+	}
+	if got := run([]string{"b"}, 0); len(got) != 1 || got[0] != "b" {
+		t.Errorf("Expected an explicitly selected check to run, got %v", got)
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	r := newTestRegistry()
+	// Diagnostic packages bind the checks as registered
+	a, _ := r.Lookup("pod", "a")
+	opt, _ := r.Lookup("pod", "opt")
+
+	if err := r.Configure([]string{"pod-opt"}, []string{"POD-A"}, map[string]output.Severity{"POD-OPT": output.SeverityCritical}); err != nil {
+		t.Fatalf("Configure() unexpected error: %v", err)
+	}
+
+	runnables := []Runnable[int]{
+		{Check: a, Run: Single(func(context.Context, int) output.CheckResult {
+			return output.CheckResult{Name: "a", Status: output.StatusFailed}
+		})},
+		{Check: opt, Run: Single(func(context.Context, int) output.CheckResult {
+			return output.CheckResult{Name: "opt", Status: output.StatusFailed}
+		})},
+	}
+
+	selection, err := r.Select("pod", nil, nil)
+	if err != nil {
+		t.Fatalf("Select() unexpected error: %v", err)
+	}
+	results := Run(context.Background(), runnables, selection, 0)
+	if len(results) != 1 || results[0].Name != "opt" {
+		t.Fatalf("Expected only the enabled check to run, got %+v", results)
+	}
+	if results[0].Severity != output.SeverityCritical {
+		t.Errorf("Expected the severity override, got %s", results[0].Severity)
+	}
+
+	selection, err = r.Select("pod", []string{"a"}, nil)
+	if err != nil {
+		t.Fatalf("Select() unexpected error: %v", err)
+	}
+	if !selection.Enabled(a) {
+		t.Error("Expected --checks to run a check the configuration disables")
+	}
+
+	if pod := r.ForCommand("pod"); pod[0].DefaultEnabled || !pod[2].DefaultEnabled {
+		t.Errorf("Expected the registry to list the configured defaults, got %+v", pod)
+	}
+
+	for name, configure := range map[string]func() error{
+		"unknown ID":       func() error { return r.Configure(nil, []string{"POD-MISSING"}, nil) },
+		"unknown severity": func() error { return r.Configure(nil, nil, map[string]output.Severity{"POD-B": "urgent"}) },
+	} {
+		if err := configure(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if b, _ := r.Lookup("pod", "b"); b.Severity != "" {
+		t.Errorf("Expected a failed Configure to leave the registry unchanged, got %+v", b)
+	}
+}
+
+func TestStamp(t *testing.T) {
+	check := Check{ID: "POD-A", Category: CategoryRuntime, Severity: output.SeverityHigh}
+	results := []output.CheckResult{
+		{Status: output.StatusFailed},
+		{Status: output.StatusWarning},
+		{Status: output.StatusPassed},
+		{Status: output.StatusFailed, Severity: output.SeverityCritical},
+	}
+
+	Stamp(check, results)
+
+	want := []output.Severity{output.SeverityHigh, output.SeverityMedium, output.SeverityInfo, output.SeverityCritical}
+	for i, result := range results {
+		if result.ID != "POD-A" || result.Category != "runtime" {
+			t.Errorf("results[%d]: expected ID POD-A and category runtime, got %q and %q", i, result.ID, result.Category)
+		}
+		if result.Severity != want[i] {
+			t.Errorf("results[%d]: expected severity %s, got %s", i, want[i], result.Severity)
+		}
+	}
+
+	if got := ResultSeverity(output.SeverityLow, output.StatusWarning); got != output.SeverityLow {
+		t.Errorf("Expected warnings of a low-severity check to stay low, got %s", got)
+	}
+}
+
+func TestRunCheckTimeout(t *testing.T) {
+	r := newTestRegistry()
+	a, _ := r.Lookup("pod", "a")
+	b, _ := r.Lookup("pod", "b")
+
+	runnables := []Runnable[int]{
+		{Check: a, Run: Single(func(context.Context, int) output.CheckResult {
+			time.Sleep(5 * time.Millisecond)
+			return output.CheckResult{Name: "fast", Status: output.StatusPassed}
+		})},
+		{Check: b, Run: Single(func(ctx context.Context, _ int) output.CheckResult {
+			<-ctx.Done()
+			return output.CheckResult{Name: "slow", Status: output.StatusFailed, Error: ctx.Err().Error()}
+		})},
+	}
+
+	selection, err := r.Select("pod", nil, nil)
+	if err != nil {
+		t.Fatalf("Select() unexpected error: %v", err)
+	}
+
+	results := Run(context.Background(), runnables, selection.WithTimeout(50*time.Millisecond), 0)
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %+v", results)
+	}
+	if results[0].Name != "fast" || results[0].DurationSeconds < 0.005 {
+		t.Errorf("Expected the fast check to pass with its duration recorded, got %+v", results[0])
+	}
+	slow := results[1]
+	if slow.ID != "POD-B" || slow.Name != "Check B" || slow.Status != output.StatusSkipped || slow.Details["reason"] != "timeout" {
+		t.Errorf("Expected the slow check to be skipped on timeout, got %+v", slow)
+	}
+	if slow.DurationSeconds < 0.05 {
+		t.Errorf("Expected the slow check's duration to cover its deadline, got %v", slow.DurationSeconds)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, result := range Run(ctx, runnables, selection, 0) {
+		if result.Status != output.StatusSkipped {
+			t.Errorf("Expected checks to be skipped once the run is over, got %+v", result)
+		}
+	}
+}
+
+func TestRunCheckAbandonsChecksIgnoringContext(t *testing.T) {
+	r := newTestRegistry()
+	b, _ := r.Lookup("pod", "b")
+
+	stuck := make(chan struct{})
+	defer close(stuck)
+	runnables := []Runnable[int]{
+		{Check: b, Run: Single(func(context.Context, int) output.CheckResult {
+			<-stuck
+			return output.CheckResult{Name: "stuck", Status: output.StatusPassed}
+		})},
+	}
+
+	selection, err := r.Select("pod", []string{"b"}, nil)
+	if err != nil {
+		t.Fatalf("Select() unexpected error: %v", err)
+	}
+
+	done := make(chan []output.CheckResult)
+	go func() {
+		done <- Run(context.Background(), runnables, selection.WithTimeout(20*time.Millisecond), 0)
+	}()
+
+	select {
+	case results := <-done:
+		if len(results) != 1 || results[0].Status != output.StatusSkipped || results[0].Name != "Check B" {
+			t.Errorf("Expected the stuck check to be skipped on timeout, got %+v", results)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Run to return at the check's deadline")
+	}
+}
+
+func TestUnavailable(t *testing.T) {
+	r := newTestRegistry()
+	var runnables []Runnable[int]
+	for _, check := range r.ForCommand("pod") {
+		runnables = append(runnables, Runnable[int]{Check: check})
+	}
+
+	selection, err := r.Select("pod", nil, []string{"a"})
+	if err != nil {
+		t.Fatalf("Select() unexpected error: %v", err)
+	}
+
+	results := Unavailable(runnables, selection, "Failed to read the pod", errors.New("forbidden"))
+	if len(results) != 1 || results[0].ID != "POD-B" || results[0].Name != "Check B" {
+		t.Fatalf("Expected a result for the selected check only, got %+v", results)
+	}
+	if results[0].Status != output.StatusSkipped || results[0].Error != "forbidden" || results[0].Details["reason"] == "timeout" {
+		t.Errorf("Expected a skipped result with the error, got %+v", results[0])
+	}
+
+	results = Unavailable(runnables, selection, "Failed to read the pod", fmt.Errorf("get pod: %w", context.DeadlineExceeded))
+	if len(results) != 1 || results[0].Details["reason"] != "timeout" {
+		t.Errorf("Expected an input that ran out of time to be reported as a timeout, got %+v", results)
+	}
+}
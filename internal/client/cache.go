@@ -0,0 +1,738 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// Cache is a shared, list-once read cache consulted by all diagnostics.
+//
+// Bulk scans (--all) call Prefetch for the resources they are about to read,
+// which lists each resource once per namespace; every later lookup is then
+// served from memory, so diagnosing N objects costs a constant number of API
+// requests instead of N+1. Lookups in namespaces that were not prefetched
+// fall through to a single GET (or selector-scoped LIST) whose result is
+// memoised, which keeps single-object diagnosis as cheap as before.
+//
+// Returned objects are shared and must not be modified.
+type Cache struct {
+	clientset kubernetes.Interface
+	chunkSize int64
+	backoff   wait.Backoff
+
+	pods            *resourceCache[*corev1.Pod]
+	nodes           *resourceCache[*corev1.Node]
+	events          *resourceCache[*corev1.Event]
+	services        *resourceCache[*corev1.Service]
+	endpoints       *resourceCache[*corev1.Endpoints]
+	serviceAccounts *resourceCache[*corev1.ServiceAccount]
+	secrets         *resourceCache[*corev1.Secret]
+	endpointSlices  *resourceCache[*discoveryv1.EndpointSlice]
+	ingresses       *resourceCache[*networkingv1.Ingress]
+
+	// kinds holds the other kinds listed with Objects, by kind
+	kindsMu sync.Mutex
+	kinds   map[string]*resourceCache[cacheObject]
+}
+
+// Resource identifies a resource type that can be prefetched.
+type Resource string
+
+const (
+	ResourcePods            Resource = "pods"
+	ResourceNodes           Resource = "nodes"
+	ResourceEvents          Resource = "events"
+	ResourceServices        Resource = "services"
+	ResourceEndpoints       Resource = "endpoints"
+	ResourceServiceAccounts Resource = "serviceaccounts"
+	ResourceEndpointSlices  Resource = "endpointslices"
+	ResourceIngresses       Resource = "ingresses"
+)
+
+// DefaultChunkSize is the default page size for LIST requests.
+const DefaultChunkSize int64 = 500
+
+// DefaultRetryBackoff paces retries of transient API errors.
+var DefaultRetryBackoff = wait.Backoff{
+	Steps:    4,
+	Duration: 250 * time.Millisecond,
+	Factor:   2.0,
+	Jitter:   0.1,
+}
+
+// NewCache creates an empty cache in front of a clientset. LIST requests are
+// split into pages of chunkSize objects; zero disables pagination.
+func NewCache(clientset kubernetes.Interface, chunkSize int64) *Cache {
+	c := &Cache{
+		clientset: clientset,
+		chunkSize: chunkSize,
+		backoff:   DefaultRetryBackoff,
+	}
+	c.Reset()
+	return c
+}
+
+// Reset drops every cached object, e.g. before re-running diagnostics in
+// watch mode. It must not be called concurrently with lookups.
+func (c *Cache) Reset() {
+	core := c.clientset.CoreV1()
+	c.kinds = make(map[string]*resourceCache[cacheObject])
+
+	c.pods = newResourceCache(c, corev1.Resource("pods"),
+		func(ctx context.Context, ns string, opts metav1.ListOptions) ([]*corev1.Pod, string, error) {
+			list, err := core.Pods(ns).List(ctx, opts)
+			if err != nil {
+				return nil, "", err
+			}
+			return pointers(list.Items), list.Continue, nil
+		},
+		func(ctx context.Context, ns, name string) (*corev1.Pod, error) {
+			return core.Pods(ns).Get(ctx, name, metav1.GetOptions{})
+		})
+
+	c.nodes = newResourceCache(c, corev1.Resource("nodes"),
+		func(ctx context.Context, _ string, opts metav1.ListOptions) ([]*corev1.Node, string, error) {
+			list, err := core.Nodes().List(ctx, opts)
+			if err != nil {
+				return nil, "", err
+			}
+			return pointers(list.Items), list.Continue, nil
+		},
+		func(ctx context.Context, _, name string) (*corev1.Node, error) {
+			return core.Nodes().Get(ctx, name, metav1.GetOptions{})
+		})
+
+	c.events = newResourceCache(c, corev1.Resource("events"),
+		func(ctx context.Context, ns string, opts metav1.ListOptions) ([]*corev1.Event, string, error) {
+			list, err := core.Events(ns).List(ctx, opts)
+			if err != nil {
+				return nil, "", err
+			}
+			return pointers(list.Items), list.Continue, nil
+		},
+		func(ctx context.Context, ns, name string) (*corev1.Event, error) {
+			return core.Events(ns).Get(ctx, name, metav1.GetOptions{})
+		})
+
+	c.services = newResourceCache(c, corev1.Resource("services"),
+		func(ctx context.Context, ns string, opts metav1.ListOptions) ([]*corev1.Service, string, error) {
+			list, err := core.Services(ns).List(ctx, opts)
+			if err != nil {
+				return nil, "", err
+			}
+			return pointers(list.Items), list.Continue, nil
+		},
+		func(ctx context.Context, ns, name string) (*corev1.Service, error) {
+			return core.Services(ns).Get(ctx, name, metav1.GetOptions{})
+		})
+
+	c.endpoints = newResourceCache(c, corev1.Resource("endpoints"),
+		func(ctx context.Context, ns string, opts metav1.ListOptions) ([]*corev1.Endpoints, string, error) {
+			list, err := core.Endpoints(ns).List(ctx, opts)
+			if err != nil {
+				return nil, "", err
+			}
+			return pointers(list.Items), list.Continue, nil
+		},
+		func(ctx context.Context, ns, name string) (*corev1.Endpoints, error) {
+			return core.Endpoints(ns).Get(ctx, name, metav1.GetOptions{})
+		})
+
+	c.serviceAccounts = newResourceCache(c, corev1.Resource("serviceaccounts"),
+		func(ctx context.Context, ns string, opts metav1.ListOptions) ([]*corev1.ServiceAccount, string, error) {
+			list, err := core.ServiceAccounts(ns).List(ctx, opts)
+			if err != nil {
+				return nil, "", err
+			}
+			return pointers(list.Items), list.Continue, nil
+		},
+		func(ctx context.Context, ns, name string) (*corev1.ServiceAccount, error) {
+			return core.ServiceAccounts(ns).Get(ctx, name, metav1.GetOptions{})
+		})
+
+	c.secrets = newResourceCache(c, corev1.Resource("secrets"),
+		func(ctx context.Context, ns string, opts metav1.ListOptions) ([]*corev1.Secret, string, error) {
+			list, err := core.Secrets(ns).List(ctx, opts)
+			if err != nil {
+				return nil, "", err
+			}
+			return pointers(list.Items), list.Continue, nil
+		},
+		func(ctx context.Context, ns, name string) (*corev1.Secret, error) {
+			return core.Secrets(ns).Get(ctx, name, metav1.GetOptions{})
+		})
+
+	c.endpointSlices = newResourceCache(c, discoveryv1.Resource("endpointslices"),
+		func(ctx context.Context, ns string, opts metav1.ListOptions) ([]*discoveryv1.EndpointSlice, string, error) {
+			list, err := c.clientset.DiscoveryV1().EndpointSlices(ns).List(ctx, opts)
+			if err != nil {
+				return nil, "", err
+			}
+			return pointers(list.Items), list.Continue, nil
+		},
+		func(ctx context.Context, ns, name string) (*discoveryv1.EndpointSlice, error) {
+			return c.clientset.DiscoveryV1().EndpointSlices(ns).Get(ctx, name, metav1.GetOptions{})
+		})
+
+	c.ingresses = newResourceCache(c, networkingv1.Resource("ingresses"),
+		func(ctx context.Context, ns string, opts metav1.ListOptions) ([]*networkingv1.Ingress, string, error) {
+			list, err := c.clientset.NetworkingV1().Ingresses(ns).List(ctx, opts)
+			if err != nil {
+				return nil, "", err
+			}
+			return pointers(list.Items), list.Continue, nil
+		},
+		func(ctx context.Context, ns, name string) (*networkingv1.Ingress, error) {
+			return c.clientset.NetworkingV1().Ingresses(ns).Get(ctx, name, metav1.GetOptions{})
+		})
+}
+
+// Prefetch lists the given resources in a namespace (metav1.NamespaceAll for
+// every namespace) so that later lookups are served from memory. Nodes are
+// cluster-scoped and ignore the namespace. The first error is returned, but
+// every resource is attempted.
+func (c *Cache) Prefetch(ctx context.Context, namespace string, resources ...Resource) error {
+	var firstErr error
+	for _, resource := range resources {
+		var err error
+		switch resource {
+		case ResourcePods:
+			_, err = c.pods.list(ctx, namespace)
+		case ResourceNodes:
+			_, err = c.nodes.list(ctx, metav1.NamespaceAll)
+		case ResourceEvents:
+			_, err = c.events.list(ctx, namespace)
+		case ResourceServices:
+			_, err = c.services.list(ctx, namespace)
+		case ResourceEndpoints:
+			_, err = c.endpoints.list(ctx, namespace)
+		case ResourceServiceAccounts:
+			_, err = c.serviceAccounts.list(ctx, namespace)
+		case ResourceEndpointSlices:
+			_, err = c.endpointSlices.list(ctx, namespace)
+		case ResourceIngresses:
+			_, err = c.ingresses.list(ctx, namespace)
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// Pods lists the pods in a namespace.
+func (c *Cache) Pods(ctx context.Context, namespace string) ([]*corev1.Pod, error) {
+	return c.pods.list(ctx, namespace)
+}
+
+// PodsMatching lists the pods in a namespace that match a label selector.
+func (c *Cache) PodsMatching(ctx context.Context, namespace string, selector labels.Selector) ([]*corev1.Pod, error) {
+	return c.pods.listSelected(ctx, namespace, selector)
+}
+
+// Pod returns a single pod.
+func (c *Cache) Pod(ctx context.Context, namespace, name string) (*corev1.Pod, error) {
+	return c.pods.get(ctx, namespace, name)
+}
+
+// Nodes lists all nodes.
+func (c *Cache) Nodes(ctx context.Context) ([]*corev1.Node, error) {
+	return c.nodes.list(ctx, metav1.NamespaceAll)
+}
+
+// Node returns a single node.
+func (c *Cache) Node(ctx context.Context, name string) (*corev1.Node, error) {
+	return c.nodes.get(ctx, metav1.NamespaceNone, name)
+}
+
+// Events lists the events in a namespace.
+func (c *Cache) Events(ctx context.Context, namespace string) ([]*corev1.Event, error) {
+	return c.events.list(ctx, namespace)
+}
+
+// EventsFor returns the events recorded for an object.
+func (c *Cache) EventsFor(ctx context.Context, namespace, kind, name string) ([]corev1.Event, error) {
+	opts := metav1.ListOptions{
+		FieldSelector: fields.Set{"involvedObject.name": name, "involvedObject.kind": kind}.String(),
+	}
+
+	items, err := c.events.listFiltered(ctx, namespace, opts, func(event *corev1.Event) bool {
+		return event.InvolvedObject.Name == name && event.InvolvedObject.Kind == kind
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]corev1.Event, 0, len(items))
+	for _, event := range items {
+		events = append(events, *event)
+	}
+	return events, nil
+}
+
+// Services lists the services in a namespace.
+func (c *Cache) Services(ctx context.Context, namespace string) ([]*corev1.Service, error) {
+	return c.services.list(ctx, namespace)
+}
+
+// Service returns a single service.
+func (c *Cache) Service(ctx context.Context, namespace, name string) (*corev1.Service, error) {
+	return c.services.get(ctx, namespace, name)
+}
+
+// Endpoints returns the endpoints of a service.
+func (c *Cache) Endpoints(ctx context.Context, namespace, name string) (*corev1.Endpoints, error) {
+	return c.endpoints.get(ctx, namespace, name)
+}
+
+// ServiceAccount returns a single service account.
+func (c *Cache) ServiceAccount(ctx context.Context, namespace, name string) (*corev1.ServiceAccount, error) {
+	return c.serviceAccounts.get(ctx, namespace, name)
+}
+
+// Secret returns a single secret. Secrets are never listed in bulk.
+func (c *Cache) Secret(ctx context.Context, namespace, name string) (*corev1.Secret, error) {
+	return c.secrets.get(ctx, namespace, name)
+}
+
+// EndpointSlices lists the endpoint slices in a namespace.
+func (c *Cache) EndpointSlices(ctx context.Context, namespace string) ([]*discoveryv1.EndpointSlice, error) {
+	return c.endpointSlices.list(ctx, namespace)
+}
+
+// EndpointSlicesFor returns the endpoint slices that back a service.
+func (c *Cache) EndpointSlicesFor(ctx context.Context, namespace, serviceName string) ([]*discoveryv1.EndpointSlice, error) {
+	selector := labels.SelectorFromSet(labels.Set{discoveryv1.LabelServiceName: serviceName})
+	return c.endpointSlices.listSelected(ctx, namespace, selector)
+}
+
+// Ingresses lists the ingresses in a namespace.
+func (c *Cache) Ingresses(ctx context.Context, namespace string) ([]*networkingv1.Ingress, error) {
+	return c.ingresses.list(ctx, namespace)
+}
+
+// Ingress returns a single ingress.
+func (c *Cache) Ingress(ctx context.Context, namespace, name string) (*networkingv1.Ingress, error) {
+	return c.ingresses.get(ctx, namespace, name)
+}
+
+// cacheObject is implemented by the typed API objects held in the cache.
+type cacheObject interface {
+	runtime.Object
+	metav1.Object
+}
+
+// listFunc lists one page of a resource and returns the continue token.
+type listFunc[T cacheObject] func(ctx context.Context, namespace string, opts metav1.ListOptions) ([]T, string, error)
+
+// resourceCache holds the objects of one resource type.
+//
+// The lock only guards the maps: API reads run without it, so a slow or
+// throttled request never blocks lookups that the cache can already answer,
+// and concurrent misses of the same key share one request through share.
+type resourceCache[T cacheObject] struct {
+	resource  schema.GroupResource
+	listFn    listFunc[T]
+	getFn     func(ctx context.Context, namespace, name string) (T, error)
+	chunkSize int64
+	backoff   wait.Backoff
+	flight    singleflight.Group
+
+	mu       sync.Mutex
+	objects  map[string]T              // namespace/name -> object
+	missing  map[string]bool           // namespace/name known not to exist
+	listed   map[string]bool           // namespaces listed in full; "" covers all
+	partial  map[string]bool           // namespaces whose list came back partial
+	filtered map[string]filteredResult // memoised selector-scoped lists
+}
+
+// filteredResult is a memoised selector-scoped list.
+type filteredResult struct {
+	keys    []string
+	partial bool
+}
+
+func newResourceCache[T cacheObject](
+	c *Cache,
+	resource schema.GroupResource,
+	listFn listFunc[T],
+	getFn func(ctx context.Context, namespace, name string) (T, error),
+) *resourceCache[T] {
+	return &resourceCache[T]{
+		resource:  resource,
+		listFn:    listFn,
+		getFn:     getFn,
+		chunkSize: c.chunkSize,
+		backoff:   c.backoff,
+		objects:   make(map[string]T),
+		missing:   make(map[string]bool),
+		listed:    make(map[string]bool),
+		partial:   make(map[string]bool),
+		filtered:  make(map[string]filteredResult),
+	}
+}
+
+// covers reports whether a namespace has been listed in full. Callers must
+// hold the lock.
+func (r *resourceCache[T]) covers(namespace string) bool {
+	return r.listed[metav1.NamespaceAll] || r.listed[namespace]
+}
+
+// store adds objects to the cache. Callers must hold the lock.
+func (r *resourceCache[T]) store(items []T) {
+	for _, item := range items {
+		r.objects[objectKey(item.GetNamespace(), item.GetName())] = item
+	}
+}
+
+// list returns every object in a namespace, listing it on first use. A list
+// that came back partial is not repeated; later callers get what was read
+// and their context is marked partial as well.
+func (r *resourceCache[T]) list(ctx context.Context, namespace string) ([]T, error) {
+	r.mu.Lock()
+	tried := r.covers(namespace) || r.partial[namespace]
+	r.mu.Unlock()
+
+	if !tried {
+		_, err := r.share(ctx, "list|"+namespace, func(ctx context.Context) (any, error) {
+			r.mu.Lock()
+			tried := r.covers(namespace) || r.partial[namespace]
+			r.mu.Unlock()
+			if tried {
+				return nil, nil
+			}
+
+			items, complete, err := r.fetch(ctx, namespace, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.store(items)
+			// A partial list must not answer later lookups with NotFound
+			if complete {
+				r.listed[namespace] = true
+			} else {
+				r.partial[namespace] = true
+			}
+			return nil, nil
+		})
+		if err != nil {
+			markPartial(ctx)
+			return nil, err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.covers(namespace) && r.partial[namespace] {
+		markPartial(ctx)
+	}
+	return r.collect(func(item T) bool {
+		return namespace == metav1.NamespaceAll || item.GetNamespace() == namespace
+	}), nil
+}
+
+// listSelected returns the objects in a namespace that match a label
+// selector, using a selector-scoped LIST unless the namespace is cached.
+func (r *resourceCache[T]) listSelected(ctx context.Context, namespace string, selector labels.Selector) ([]T, error) {
+	opts := metav1.ListOptions{LabelSelector: selector.String()}
+	return r.listFiltered(ctx, namespace, opts, func(item T) bool {
+		return selector.Matches(labels.Set(item.GetLabels()))
+	})
+}
+
+// listFiltered returns the objects in a namespace accepted by match. When the
+// namespace is not cached, opts scopes a server-side LIST whose result is
+// memoised; match must accept the same objects as opts' selectors.
+func (r *resourceCache[T]) listFiltered(ctx context.Context, namespace string, opts metav1.ListOptions, match func(T) bool) ([]T, error) {
+	inNamespace := func(item T) bool {
+		return (namespace == metav1.NamespaceAll || item.GetNamespace() == namespace) && match(item)
+	}
+	memoKey := namespace + "|" + opts.LabelSelector + "|" + opts.FieldSelector
+
+	// memoised answers the lookup from the cache if possible. Callers must
+	// hold the lock.
+	memoised := func() ([]T, bool) {
+		if r.covers(namespace) {
+			return r.collect(inNamespace), true
+		}
+		memo, ok := r.filtered[memoKey]
+		if !ok {
+			return nil, false
+		}
+		if memo.partial {
+			markPartial(ctx)
+		}
+		items := make([]T, 0, len(memo.keys))
+		for _, key := range memo.keys {
+			items = append(items, r.objects[key])
+		}
+		return items, true
+	}
+
+	r.mu.Lock()
+	items, ok := memoised()
+	r.mu.Unlock()
+	if ok {
+		return items, nil
+	}
+
+	_, err := r.share(ctx, "filter|"+memoKey, func(ctx context.Context) (any, error) {
+		r.mu.Lock()
+		_, ok := memoised()
+		r.mu.Unlock()
+		if ok {
+			return nil, nil
+		}
+
+		items, complete, err := r.fetch(ctx, namespace, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		keys := make([]string, 0, len(items))
+		for _, item := range items {
+			key := objectKey(item.GetNamespace(), item.GetName())
+			r.objects[key] = item
+			// Not every implementation honours field selectors
+			if inNamespace(item) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		r.filtered[memoKey] = filteredResult{keys: keys, partial: !complete}
+		return nil, nil
+	})
+	if err != nil {
+		markPartial(ctx)
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	items, _ = memoised()
+	return items, nil
+}
+
+// get returns a single object, answering from the cache when possible.
+func (r *resourceCache[T]) get(ctx context.Context, namespace, name string) (T, error) {
+	var zero T
+	key := objectKey(namespace, name)
+
+	// cached answers the lookup from the cache if possible. Callers must
+	// hold the lock.
+	cached := func() (T, bool, error) {
+		if item, ok := r.objects[key]; ok {
+			return item, true, nil
+		}
+		if r.missing[key] || r.covers(namespace) {
+			return zero, true, apierrors.NewNotFound(r.resource, name)
+		}
+		return zero, false, nil
+	}
+
+	r.mu.Lock()
+	item, ok, err := cached()
+	r.mu.Unlock()
+	if ok {
+		return item, err
+	}
+
+	result, err := r.share(ctx, "get|"+key, func(ctx context.Context) (any, error) {
+		r.mu.Lock()
+		item, ok, err := cached()
+		r.mu.Unlock()
+		if ok {
+			return item, err
+		}
+
+		err = retryRead(r.backoff, func() error {
+			var err error
+			item, err = r.getFn(ctx, namespace, name)
+			return err
+		})
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				r.missing[key] = true
+			}
+			return zero, err
+		}
+		r.objects[key] = item
+		return item, nil
+	})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			markPartial(ctx)
+		}
+		return zero, err
+	}
+
+	return result.(T), nil
+}
+
+// abandonedError is the error of a shared read whose caller gave up on it.
+type abandonedError struct {
+	err error
+}
+
+func (e *abandonedError) Error() string {
+	return e.err.Error()
+}
+
+// share runs read once for concurrent callers of the same key, with the
+// context of the caller that started it. When that caller's context ends
+// before the read is done, the callers that are still waiting read again
+// under their own context instead of failing with the first caller's
+// cancellation.
+func (r *resourceCache[T]) share(ctx context.Context, key string, read func(ctx context.Context) (any, error)) (any, error) {
+	for {
+		result, err, _ := r.flight.Do(key, func() (any, error) {
+			result, err := read(ctx)
+			if err != nil && ctx.Err() != nil {
+				return nil, &abandonedError{err: err}
+			}
+			return result, err
+		})
+
+		var abandoned *abandonedError
+		if !errors.As(err, &abandoned) {
+			return result, err
+		}
+		if ctx.Err() != nil {
+			return nil, abandoned.err
+		}
+	}
+}
+
+// fetch lists a resource in chunks of chunkSize, retrying transient errors.
+// When a later page fails, the pages read so far are returned with complete
+// set to false and the context's DataTracker is marked partial; an error is
+// only returned when nothing could be read or the context ended, since pages
+// cut short by the caller must not be kept as the namespace's partial list.
+func (r *resourceCache[T]) fetch(ctx context.Context, namespace string, opts metav1.ListOptions) (items []T, complete bool, err error) {
+	opts.Limit = r.chunkSize
+
+	for {
+		var (
+			page []T
+			next string
+		)
+		err := retryRead(r.backoff, func() error {
+			var err error
+			page, next, err = r.listFn(ctx, namespace, opts)
+			return err
+		})
+
+		switch {
+		case err == nil:
+		case apierrors.IsResourceExpired(err) && opts.Continue != "":
+			// The continue token expired between pages; fall back to a
+			// single unpaginated list like client-go's pager does
+			opts.Limit, opts.Continue, items = 0, "", nil
+			continue
+		case len(items) > 0 && ctx.Err() == nil:
+			markPartial(ctx)
+			return items, false, nil
+		default:
+			markPartial(ctx)
+			return nil, false, err
+		}
+
+		items = append(items, page...)
+		if next == "" {
+			return items, true, nil
+		}
+		opts.Continue = next
+	}
+}
+
+// retryRead runs read, retrying transient errors with backoff, and returns
+// its last error. retry.OnError is not used on its own because it reports a
+// read that failed with a context error as successful.
+func retryRead(backoff wait.Backoff, read func() error) error {
+	var err error
+	_ = retry.OnError(backoff, isRetryable, func() error {
+		err = read()
+		return err
+	})
+	return err
+}
+
+// isRetryable reports whether an API error is transient: throttling (429),
+// server errors (5xx), server-side timeouts and etcd request timeouts.
+func isRetryable(err error) bool {
+	if apierrors.IsTooManyRequests(err) || apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) || apierrors.IsInternalError(err) ||
+		apierrors.IsServiceUnavailable(err) || apierrors.IsUnexpectedServerError(err) {
+		return true
+	}
+
+	var status apierrors.APIStatus
+	if errors.As(err, &status) && status.Status().Code >= http.StatusInternalServerError {
+		return true
+	}
+
+	message := err.Error()
+	return strings.Contains(message, "etcdserver: request timed out") ||
+		strings.Contains(message, "etcdserver: leader changed")
+}
+
+// collect returns the cached objects accepted by match, sorted by key.
+// Callers must hold the lock.
+func (r *resourceCache[T]) collect(match func(T) bool) []T {
+	keys := make([]string, 0, len(r.objects))
+	for key, item := range r.objects {
+		if match(item) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	items := make([]T, 0, len(keys))
+	for _, key := range keys {
+		items = append(items, r.objects[key])
+	}
+	return items
+}
+
+// objectKey returns the cache key of an object.
+func objectKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+// pointers returns pointers to the elements of a list's items.
+func pointers[T any](items []T) []*T {
+	result := make([]*T, len(items))
+	for i := range items {
+		result[i] = &items[i]
+	}
+	return result
+}
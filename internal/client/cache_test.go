@@ -0,0 +1,475 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func newCacheTestClientset() *fake.Clientset {
+	return fake.NewClientset(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default", Labels: map[string]string{"app": "web"}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-2", Namespace: "default", Labels: map[string]string{"app": "web"}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "db-1", Namespace: "default", Labels: map[string]string{"app": "db"}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "other"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "web-1.1", Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web-1"},
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "web-2.1", Namespace: "default"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web-2"},
+		},
+	)
+}
+
+func TestCacheGetIsMemoised(t *testing.T) {
+	clientset := newCacheTestClientset()
+	cache := NewCache(clientset, 0)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		pod, err := cache.Pod(ctx, "default", "web-1")
+		if err != nil {
+			t.Fatalf("Pod() unexpected error: %v", err)
+		}
+		if pod.Name != "web-1" {
+			t.Errorf("Expected web-1, got %s", pod.Name)
+		}
+
+		if _, err := cache.Node(ctx, "missing"); !apierrors.IsNotFound(err) {
+			t.Errorf("Expected NotFound, got %v", err)
+		}
+	}
+
+	if actions := len(clientset.Actions()); actions != 2 {
+		t.Errorf("Expected 2 API calls, got %d", actions)
+	}
+}
+
+func TestCachePrefetch(t *testing.T) {
+	clientset := newCacheTestClientset()
+	cache := NewCache(clientset, 0)
+	ctx := context.Background()
+
+	if err := cache.Prefetch(ctx, "default", ResourcePods, ResourceEvents, ResourceNodes); err != nil {
+		t.Fatalf("Prefetch() unexpected error: %v", err)
+	}
+	prefetched := len(clientset.Actions())
+
+	pods, err := cache.Pods(ctx, "default")
+	if err != nil {
+		t.Fatalf("Pods() unexpected error: %v", err)
+	}
+	if len(pods) != 3 {
+		t.Errorf("Expected 3 pods in default, got %d", len(pods))
+	}
+
+	web, err := cache.PodsMatching(ctx, "default", labels.SelectorFromSet(labels.Set{"app": "web"}))
+	if err != nil || len(web) != 2 {
+		t.Errorf("Expected 2 web pods, got %d (%v)", len(web), err)
+	}
+
+	events, err := cache.EventsFor(ctx, "default", "Pod", "web-1")
+	if err != nil || len(events) != 1 || events[0].Name != "web-1.1" {
+		t.Errorf("Expected only event web-1.1, got %v (%v)", events, err)
+	}
+
+	if _, err := cache.Node(ctx, "node-1"); err != nil {
+		t.Errorf("Node() unexpected error: %v", err)
+	}
+	if _, err := cache.Pod(ctx, "default", "missing"); !apierrors.IsNotFound(err) {
+		t.Errorf("Expected NotFound for pod missing from a listed namespace, got %v", err)
+	}
+
+	if actions := len(clientset.Actions()); actions != prefetched {
+		t.Errorf("Expected lookups to be served from the cache, got %d extra API calls", actions-prefetched)
+	}
+
+	// Other namespaces are still read from the API
+	if _, err := cache.Pod(ctx, "other", "web-1"); err != nil {
+		t.Errorf("Pod() in other namespace unexpected error: %v", err)
+	}
+	if actions := len(clientset.Actions()); actions != prefetched+1 {
+		t.Errorf("Expected 1 API call for an uncached namespace, got %d", actions-prefetched)
+	}
+}
+
+func TestCacheFilteredListWithoutPrefetch(t *testing.T) {
+	clientset := newCacheTestClientset()
+	cache := NewCache(clientset, 0)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		// The fake clientset ignores field selectors; the cache filters anyway
+		events, err := cache.EventsFor(ctx, "default", "Pod", "web-2")
+		if err != nil || len(events) != 1 || events[0].Name != "web-2.1" {
+			t.Errorf("Expected only event web-2.1, got %v (%v)", events, err)
+		}
+	}
+
+	if actions := len(clientset.Actions()); actions != 1 {
+		t.Errorf("Expected 1 memoised API call, got %d", actions)
+	}
+}
+
+func TestCacheReset(t *testing.T) {
+	clientset := newCacheTestClientset()
+	cache := NewCache(clientset, 0)
+	ctx := context.Background()
+
+	if _, err := cache.Pod(ctx, "default", "web-1"); err != nil {
+		t.Fatal(err)
+	}
+	cache.Reset()
+	if _, err := cache.Pod(ctx, "default", "web-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if actions := len(clientset.Actions()); actions != 2 {
+		t.Errorf("Expected reset to force a new API call, got %d calls", actions)
+	}
+}
+
+func TestCacheObjects(t *testing.T) {
+	clientset := newCacheTestClientset()
+	if err := clientset.Tracker().Add(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "default"}}); err != nil {
+		t.Fatal(err)
+	}
+	cache := NewCache(clientset, 0)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		configMaps, err := cache.Objects(ctx, "configmap", "default")
+		if err != nil {
+			t.Fatalf("Objects() unexpected error: %v", err)
+		}
+		if len(configMaps) != 1 {
+			t.Errorf("Expected 1 config map, got %d", len(configMaps))
+		}
+	}
+	if actions := len(clientset.Actions()); actions != 1 {
+		t.Errorf("Expected the kind to be listed once, got %d calls", actions)
+	}
+
+	// Cluster-scoped kinds ignore the namespace; typed kinds share their cache
+	nodes, err := cache.Objects(ctx, "Node", "default")
+	if err != nil || len(nodes) != 1 {
+		t.Errorf("Expected 1 node, got %d (%v)", len(nodes), err)
+	}
+	pods, err := cache.Objects(ctx, "Pod", "default")
+	if err != nil || len(pods) != 3 {
+		t.Errorf("Expected 3 pods, got %d (%v)", len(pods), err)
+	}
+
+	if _, err := cache.Objects(ctx, "Secret", "default"); err == nil {
+		t.Error("Expected an error for an unsupported kind")
+	}
+}
+
+func TestKubernetesClientCacheIsShared(t *testing.T) {
+	k8sClient := &KubernetesClient{Clientset: fake.NewClientset()}
+
+	if k8sClient.Cache() != k8sClient.Cache() {
+		t.Error("Expected the same cache for every call")
+	}
+	if k8sClient.Requests.Count() != 0 {
+		t.Error("Expected a nil request counter to report zero")
+	}
+}
+
+// newFastRetryCache returns a cache whose retries do not sleep.
+func newFastRetryCache(clientset *fake.Clientset, chunkSize int64) *Cache {
+	cache := NewCache(clientset, chunkSize)
+	cache.backoff = wait.Backoff{Steps: 3, Duration: time.Millisecond}
+	cache.Reset()
+	return cache
+}
+
+// newPagingClientset serves the pods pod-0..pod-<count-1> in pages, honouring
+// Limit and Continue like an API server. failPage, when positive, is answered
+// with err instead.
+func newPagingClientset(count, failPage int, err error) *fake.Clientset {
+	clientset := fake.NewClientset()
+	for i := 0; i < count; i++ {
+		_ = clientset.Tracker().Add(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("pod-%d", i), Namespace: "default"}})
+	}
+
+	clientset.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		opts := action.(k8stesting.ListActionImpl).ListOptions
+		if opts.Limit == 0 {
+			return false, nil, nil
+		}
+
+		start, _ := strconv.Atoi(opts.Continue)
+		if failPage > 0 && start/int(opts.Limit) == failPage {
+			return true, nil, err
+		}
+
+		list := &corev1.PodList{}
+		for i := start; i < count && i < start+int(opts.Limit); i++ {
+			list.Items = append(list.Items, corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("pod-%d", i), Namespace: "default"}})
+		}
+		if next := start + int(opts.Limit); next < count {
+			list.Continue = strconv.Itoa(next)
+		}
+		return true, list, nil
+	})
+
+	return clientset
+}
+
+func TestCachePaginatesLists(t *testing.T) {
+	clientset := newPagingClientset(5, 0, nil)
+	cache := newFastRetryCache(clientset, 2)
+	ctx, tracker := TrackData(context.Background())
+
+	pods, err := cache.Pods(ctx, "default")
+	if err != nil {
+		t.Fatalf("Pods() unexpected error: %v", err)
+	}
+	if len(pods) != 5 {
+		t.Errorf("Expected 5 pods, got %d", len(pods))
+	}
+	if actions := len(clientset.Actions()); actions != 3 {
+		t.Errorf("Expected 3 paged LIST calls, got %d", actions)
+	}
+	if tracker.Partial() {
+		t.Error("Expected complete data")
+	}
+}
+
+func TestCacheRetriesTransientErrors(t *testing.T) {
+	clientset := newCacheTestClientset()
+	failures := 0
+	clientset.PrependReactor("get", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if failures < 2 {
+			failures++
+			if failures == 1 {
+				return true, nil, apierrors.NewTooManyRequests("slow down", 0)
+			}
+			return true, nil, apierrors.NewInternalError(errors.New("etcdserver: request timed out"))
+		}
+		return false, nil, nil
+	})
+
+	cache := newFastRetryCache(clientset, 0)
+	ctx, tracker := TrackData(context.Background())
+
+	if _, err := cache.Pod(ctx, "default", "web-1"); err != nil {
+		t.Fatalf("Pod() unexpected error after retries: %v", err)
+	}
+	if actions := len(clientset.Actions()); actions != 3 {
+		t.Errorf("Expected 3 GET attempts, got %d", actions)
+	}
+	if tracker.Partial() {
+		t.Error("Expected complete data after a successful retry")
+	}
+}
+
+func TestCachePartialList(t *testing.T) {
+	clientset := newPagingClientset(5, 1, apierrors.NewServiceUnavailable("overloaded"))
+	cache := newFastRetryCache(clientset, 2)
+	ctx, tracker := TrackData(context.Background())
+
+	pods, err := cache.Pods(ctx, "default")
+	if err != nil {
+		t.Fatalf("Pods() unexpected error: %v", err)
+	}
+	if len(pods) != 2 {
+		t.Errorf("Expected the 2 pods of the first page, got %d", len(pods))
+	}
+	if !tracker.Partial() {
+		t.Error("Expected partial data after a failed page")
+	}
+
+	// The partial list must not answer lookups of unread pods with NotFound
+	if _, err := cache.Pod(context.Background(), "default", "pod-4"); err != nil {
+		t.Errorf("Expected pod-4 to be fetched individually, got %v", err)
+	}
+
+	// Nor is it repeated, but later callers learn that it is partial
+	actions := len(clientset.Actions())
+	again, retracker := TrackData(context.Background())
+	if _, err := cache.Pods(again, "default"); err != nil {
+		t.Fatalf("Pods() unexpected error: %v", err)
+	}
+	if extra := len(clientset.Actions()) - actions; extra != 0 {
+		t.Errorf("Expected the partial list not to be repeated, got %d extra API calls", extra)
+	}
+	if !retracker.Partial() {
+		t.Error("Expected a later caller to be marked partial")
+	}
+}
+
+func TestCacheDoesNotHoldLockDuringRequests(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var slowGets atomic.Int32
+
+	// The fake clientset serialises its reactors, so read through a
+	// hand-written function instead
+	pods := newResourceCache(NewCache(fake.NewClientset(), 0), corev1.Resource("pods"), nil,
+		func(ctx context.Context, ns, name string) (*corev1.Pod, error) {
+			if name == "slow" && slowGets.Add(1) == 1 {
+				close(started)
+				<-release
+			}
+			return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns}}, nil
+		})
+	ctx := context.Background()
+
+	// Concurrent lookups of the slow pod share a single request
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := pods.get(ctx, "default", "slow"); err != nil {
+				t.Errorf("get() unexpected error: %v", err)
+			}
+		}()
+	}
+	<-started
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = pods.get(ctx, "default", "fast")
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected lookups of other pods not to wait for a slow request")
+	}
+
+	close(release)
+	wg.Wait()
+
+	if gets := slowGets.Load(); gets != 1 {
+		t.Errorf("Expected 1 GET of the slow pod, got %d", gets)
+	}
+}
+
+func TestCacheSharedReadOutlivesCancelledCaller(t *testing.T) {
+	started := make(chan struct{})
+	var gets atomic.Int32
+
+	// The first GET waits for its caller to give up, later ones succeed
+	pods := newResourceCache(NewCache(fake.NewClientset(), 0), corev1.Resource("pods"), nil,
+		func(ctx context.Context, ns, name string) (*corev1.Pod, error) {
+			if gets.Add(1) == 1 {
+				close(started)
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns}}, nil
+		})
+
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := pods.get(leaderCtx, "default", "web")
+		leaderErr <- err
+	}()
+	<-started
+
+	followerCtx, tracker := TrackData(context.Background())
+	followerErr := make(chan error, 1)
+	go func() {
+		_, err := pods.get(followerCtx, "default", "web")
+		followerErr <- err
+	}()
+
+	// Give the follower time to join the leader's request
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the cancelled caller to fail with its cancellation, got %v", err)
+	}
+	if err := <-followerErr; err != nil {
+		t.Errorf("Expected the waiting caller to read the pod itself, got %v", err)
+	}
+	if tracker.Partial() {
+		t.Error("Expected the waiting caller's data to be complete")
+	}
+}
+
+func TestCacheListFailsWithoutData(t *testing.T) {
+	clientset := newCacheTestClientset()
+	clientset.PrependReactor("list", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(corev1.Resource("nodes"), "", errors.New("denied"))
+	})
+
+	cache := newFastRetryCache(clientset, 0)
+	ctx, tracker := TrackData(context.Background())
+
+	if _, err := cache.Nodes(ctx); !apierrors.IsForbidden(err) {
+		t.Errorf("Expected Forbidden, got %v", err)
+	}
+	if actions := len(clientset.Actions()); actions != 1 {
+		t.Errorf("Expected a non-transient error not to be retried, got %d calls", actions)
+	}
+	if !tracker.Partial() {
+		t.Error("Expected partial data after a failed read")
+	}
+}
+
+func TestCacheExpiredContinueFallsBack(t *testing.T) {
+	clientset := newPagingClientset(5, 1, apierrors.NewResourceExpired("continue token expired"))
+	cache := newFastRetryCache(clientset, 2)
+	ctx, tracker := TrackData(context.Background())
+
+	pods, err := cache.Pods(ctx, "default")
+	if err != nil {
+		t.Fatalf("Pods() unexpected error: %v", err)
+	}
+	if len(pods) != 5 {
+		t.Errorf("Expected the unpaginated fallback to return 5 pods, got %d", len(pods))
+	}
+	if tracker.Partial() {
+		t.Error("Expected complete data after the fallback")
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"too many requests", apierrors.NewTooManyRequests("slow down", 1), true},
+		{"internal error", apierrors.NewInternalError(errors.New("boom")), true},
+		{"service unavailable", apierrors.NewServiceUnavailable("overloaded"), true},
+		{"server timeout", apierrors.NewServerTimeout(corev1.Resource("pods"), "list", 1), true},
+		{"gateway timeout", apierrors.NewTimeoutError("timeout", 1), true},
+		{"etcd timeout", errors.New("etcdserver: request timed out"), true},
+		{"not found", apierrors.NewNotFound(corev1.Resource("pods"), "web"), false},
+		{"forbidden", apierrors.NewForbidden(corev1.Resource("pods"), "web", errors.New("denied")), false},
+		{"plain error", errors.New("connection refused"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryable(tt.err); got != tt.want {
+				t.Errorf("isRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,52 +2,196 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/util/homedir"
 )
 
-// KubernetesClient wraps the Kubernetes clientset with additional metadata
+// KubernetesClient wraps the Kubernetes clientset with additional metadata.
+//
+// Clientset is programmed against kubernetes.Interface so that diagnostics can
+// be driven by a live clientset, k8s.io/client-go/kubernetes/fake, or any other
+// implementation (for example a cached or recorded client).
 type KubernetesClient struct {
-	Clientset *kubernetes.Clientset
-	Config    *rest.Config
+	Clientset kubernetes.Interface
+	Discovery discovery.ServerVersionInterface
+	Config    ConfigProvider
 	Context   string
+
+	// Offline is set when the client is backed by a static cluster snapshot
+	// rather than a live API server. Checks that need live data (log
+	// streaming, API server round trips) report SKIPPED in this mode.
+	Offline bool
+
+	// RecordedLogs serves container logs captured in a snapshot. It is only
+	// consulted for offline clients and may be nil.
+	RecordedLogs LogSource
+
+	// Requests counts the API requests made by a live client; it is nil for
+	// clients that do not talk to an API server over HTTP.
+	Requests *RequestCounter
+
+	// ChunkSize is the page size used when listing resources; zero lists
+	// everything in a single request.
+	ChunkSize int64
+
+	// ImpersonatedClientset builds the clientset returned by Impersonate. It
+	// is nil for live clients, which derive one from Config; tests and
+	// recorded clients set it to serve impersonated requests themselves.
+	ImpersonatedClientset func(identity rest.ImpersonationConfig) (kubernetes.Interface, error)
+
+	cache     *Cache
+	cacheOnce sync.Once
+
+	clusterInfoMu sync.Mutex
+	clusterInfo   map[string]string
+}
+
+// Cache returns the shared read cache in front of the clientset.
+func (k *KubernetesClient) Cache() *Cache {
+	k.cacheOnce.Do(func() {
+		k.cache = NewCache(k.Clientset, k.ChunkSize)
+	})
+	return k.cache
+}
+
+// LogSource provides previously recorded container logs.
+type LogSource interface {
+	// ContainerLogs returns the recorded logs of a container and whether any
+	// were recorded.
+	ContainerLogs(namespace, pod, container string) (string, bool)
+}
+
+// ErrOffline is returned for operations that require a live API server
+// when the client is backed by a cluster snapshot.
+var ErrOffline = errors.New("not available in offline snapshot mode")
+
+// ConfigProvider abstracts the REST configuration backing a client.
+// *rest.Config does not satisfy it directly; use RESTConfig to wrap one.
+type ConfigProvider interface {
+	// Host returns the API server address the client talks to.
+	Host() string
+
+	// RESTConfig returns the underlying rest.Config, or nil when the client
+	// is not backed by a real API server connection.
+	RESTConfig() *rest.Config
+}
+
+// restConfigProvider adapts a *rest.Config to the ConfigProvider interface.
+type restConfigProvider struct {
+	config *rest.Config
+}
+
+// RESTConfig wraps a *rest.Config as a ConfigProvider.
+func RESTConfig(config *rest.Config) ConfigProvider {
+	return &restConfigProvider{config: config}
+}
+
+// Host returns the API server address from the wrapped config.
+func (r *restConfigProvider) Host() string {
+	if r.config == nil {
+		return ""
+	}
+	return r.config.Host
+}
+
+// RESTConfig returns the wrapped config.
+func (r *restConfigProvider) RESTConfig() *rest.Config {
+	return r.config
+}
+
+// StaticConfig is a ConfigProvider for clients that are not backed by a
+// rest.Config, such as fake or recorded clientsets.
+type StaticConfig struct {
+	Server string
+}
+
+// Host returns the configured server address.
+func (s StaticConfig) Host() string {
+	return s.Server
+}
+
+// RESTConfig always returns nil for a static config.
+func (s StaticConfig) RESTConfig() *rest.Config {
+	return nil
+}
+
+// Options configures a client created by NewKubernetesClientWithOptions.
+type Options struct {
+	// Kubeconfig is the kubeconfig path; empty uses the default loading rules
+	Kubeconfig string
+
+	// Context is the kubeconfig context; empty selects the current context
+	// (or the in-cluster configuration when running inside a pod)
+	Context string
+
+	// QPS and Burst bound the client-side request rate; zero keeps the
+	// client-go defaults
+	QPS   float32
+	Burst int
+
+	// ChunkSize is the page size for LIST requests; zero disables pagination
+	ChunkSize int64
+
+	// Impersonate sends every request as another user, group or UID, like
+	// kubectl's --as, --as-group and --as-uid flags
+	Impersonate rest.ImpersonationConfig
 }
 
 // NewKubernetesClient creates a new Kubernetes client
 func NewKubernetesClient(kubeconfig string) (*KubernetesClient, error) {
-	var config *rest.Config
+	return NewKubernetesClientForContext(kubeconfig, "")
+}
 
-	var err error
+// NewKubernetesClientForContext creates a client for a named kubeconfig
+// context. An empty context name selects the current context (or the
+// in-cluster configuration when running inside a pod).
+func NewKubernetesClientForContext(kubeconfig, contextName string) (*KubernetesClient, error) {
+	return NewKubernetesClientWithOptions(Options{
+		Kubeconfig: kubeconfig,
+		Context:    contextName,
+		ChunkSize:  DefaultChunkSize,
+	})
+}
 
-	if kubeconfig == "" {
-		// Try in-cluster config first
-		config, err = rest.InClusterConfig()
-		if err != nil {
-			// Fall back to kubeconfig file using proper loading rules
-			// This handles KUBECONFIG env var and default paths correctly
-			loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-			configOverrides := &clientcmd.ConfigOverrides{}
-			clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
-			config, err = clientConfig.ClientConfig()
-			if err != nil {
-				return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
-			}
-		}
-	} else {
-		// Load config from specific kubeconfig file
-		config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
-		if err != nil {
-			return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
-		}
+// NewKubernetesClientWithOptions creates a client from explicit options.
+func NewKubernetesClientWithOptions(opts Options) (*KubernetesClient, error) {
+	kubeconfig, contextName := opts.Kubeconfig, opts.Context
+
+	config, err := loadRESTConfig(kubeconfig, contextName)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.QPS > 0 {
+		config.QPS = opts.QPS
+	}
+	if opts.Burst > 0 {
+		config.Burst = opts.Burst
 	}
 
+	if opts.Impersonate.UserName == "" && (len(opts.Impersonate.Groups) > 0 || opts.Impersonate.UID != "") {
+		return nil, fmt.Errorf("impersonating a group or UID requires a user name")
+	}
+	if opts.Impersonate.UserName != "" {
+		config.Impersonate = opts.Impersonate
+	}
+
+	// Count every request sent to the API server
+	requests := &RequestCounter{}
+	config.Wrap(requests.wrap)
+
 	// Create clientset
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
@@ -55,18 +199,163 @@ func NewKubernetesClient(kubeconfig string) (*KubernetesClient, error) {
 	}
 
 	// Get current context
-	context := getCurrentContextFromConfig(config, kubeconfig)
+	context := contextName
+	if context == "" {
+		context = getCurrentContextFromConfig(config, kubeconfig)
+	}
+
+	k8sClient := NewKubernetesClientFromClientset(clientset, RESTConfig(config), context)
+	k8sClient.Requests = requests
+	k8sClient.ChunkSize = opts.ChunkSize
+
+	return k8sClient, nil
+}
+
+// Impersonate returns a client that sends every request as the given
+// identity, sharing this client's request counter and chunk size. The caller
+// needs the "impersonate" permission for the identity.
+func (k *KubernetesClient) Impersonate(identity rest.ImpersonationConfig) (*KubernetesClient, error) {
+	if k.Offline {
+		return nil, ErrOffline
+	}
+
+	var (
+		clientset kubernetes.Interface
+		config    = k.Config
+		err       error
+	)
+	if k.ImpersonatedClientset != nil {
+		clientset, err = k.ImpersonatedClientset(identity)
+	} else {
+		base := k.Config.RESTConfig()
+		if base == nil {
+			return nil, fmt.Errorf("impersonation requires a live API server connection")
+		}
+
+		// The copy keeps the transport wrappers, so requests are still counted
+		restConfig := rest.CopyConfig(base)
+		restConfig.Impersonate = identity
+		config = RESTConfig(restConfig)
+		clientset, err = kubernetes.NewForConfig(restConfig)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create impersonated client for %q: %w", identity.UserName, err)
+	}
+
+	impersonated := NewKubernetesClientFromClientset(clientset, config, k.Context)
+	impersonated.Requests = k.Requests
+	impersonated.ChunkSize = k.ChunkSize
+
+	return impersonated, nil
+}
+
+// ServiceAccountIdentity returns the identity a pod running as the service
+// account authenticates with.
+func ServiceAccountIdentity(namespace, name string) rest.ImpersonationConfig {
+	return rest.ImpersonationConfig{
+		UserName: fmt.Sprintf("system:serviceaccount:%s:%s", namespace, name),
+		Groups: []string{
+			"system:serviceaccounts",
+			"system:serviceaccounts:" + namespace,
+			"system:authenticated",
+		},
+	}
+}
+
+// ListContexts returns the names of all contexts in the kubeconfig, sorted
+// alphabetically. An empty path uses the default loading rules ($KUBECONFIG
+// or ~/.kube/config).
+func ListContexts(kubeconfig string) ([]string, error) {
+	rawConfig, err := kubeconfigLoader(kubeconfig, "").RawConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+
+	contexts := make([]string, 0, len(rawConfig.Contexts))
+	for name := range rawConfig.Contexts {
+		contexts = append(contexts, name)
+	}
+	sort.Strings(contexts)
+
+	if len(contexts) == 0 {
+		return nil, fmt.Errorf("no contexts found in kubeconfig")
+	}
+
+	return contexts, nil
+}
+
+// NewKubernetesClientFromClientset creates a client from an existing clientset.
+// The discovery client is taken from the clientset; config may be nil, in which
+// case an empty StaticConfig is used.
+func NewKubernetesClientFromClientset(clientset kubernetes.Interface, config ConfigProvider, contextName string) *KubernetesClient {
+	if config == nil {
+		config = StaticConfig{}
+	}
 
 	return &KubernetesClient{
 		Clientset: clientset,
+		Discovery: clientset.Discovery(),
 		Config:    config,
-		Context:   context,
-	}, nil
+		Context:   contextName,
+		ChunkSize: DefaultChunkSize,
+	}
+}
+
+// loadRESTConfig builds a rest.Config from the given kubeconfig path, falling
+// back to in-cluster configuration and default loading rules when it is empty.
+// A non-empty context name always resolves through the kubeconfig.
+func loadRESTConfig(kubeconfig, contextName string) (*rest.Config, error) {
+	if contextName != "" {
+		config, err := kubeconfigLoader(kubeconfig, contextName).ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load kubeconfig context %q: %w", contextName, err)
+		}
+		return config, nil
+	}
+
+	if kubeconfig != "" {
+		// Load config from specific kubeconfig file
+		config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+		}
+		return config, nil
+	}
+
+	// Try in-cluster config first
+	config, err := rest.InClusterConfig()
+	if err == nil {
+		return config, nil
+	}
+
+	// Fall back to kubeconfig file using proper loading rules
+	// This handles KUBECONFIG env var and default paths correctly
+	config, err = kubeconfigLoader("", "").ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+
+	return config, nil
+}
+
+// kubeconfigLoader returns a client config for the given kubeconfig path
+// (default loading rules when empty) with an optional context override.
+func kubeconfigLoader(kubeconfig, contextName string) clientcmd.ClientConfig {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfig
+	configOverrides := &clientcmd.ConfigOverrides{CurrentContext: contextName}
+
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
 }
 
 // TestConnection tests the connection to the Kubernetes cluster
 func (k *KubernetesClient) TestConnection(ctx context.Context) error {
-	_, err := k.Clientset.Discovery().ServerVersion()
+	// A snapshot is always "reachable"; there is no API server to contact
+	if k.Offline {
+		return nil
+	}
+
+	_, err := k.Discovery.ServerVersion()
 	if err != nil {
 		// Provide more helpful error messages for common issues
 		errMsg := err.Error()
@@ -92,20 +381,47 @@ func (k *KubernetesClient) TestConnection(ctx context.Context) error {
 	return nil
 }
 
-// GetClusterInfo returns basic cluster information
+// GetClusterInfo returns basic cluster information. The server version is
+// read once per client; failed reads are retried on the next call.
 func (k *KubernetesClient) GetClusterInfo(ctx context.Context) (map[string]string, error) {
-	version, err := k.Clientset.Discovery().ServerVersion()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get server version: %w", err)
+	k.clusterInfoMu.Lock()
+	defer k.clusterInfoMu.Unlock()
+
+	if k.clusterInfo == nil {
+		info, err := k.readClusterInfo()
+		if err != nil {
+			return nil, err
+		}
+		k.clusterInfo = info
 	}
 
+	// Callers own the returned map
+	return maps.Clone(k.clusterInfo), nil
+}
+
+// readClusterInfo reads the cluster information from the server.
+func (k *KubernetesClient) readClusterInfo() (map[string]string, error) {
 	info := map[string]string{
-		"context":    k.Context,
-		"server":     k.Config.Host,
-		"version":    version.String(),
-		"gitVersion": version.GitVersion,
-		"platform":   version.Platform,
+		"context": k.Context,
+		"server":  k.Config.Host(),
 	}
+	if k.Offline {
+		info["source"] = "snapshot"
+	}
+
+	version, err := k.Discovery.ServerVersion()
+	if err != nil {
+		// Snapshots without a recorded server version are still usable
+		if k.Offline {
+			info["version"] = "unknown (offline snapshot)"
+			return info, nil
+		}
+		return nil, fmt.Errorf("failed to get server version: %w", err)
+	}
+
+	info["version"] = version.String()
+	info["gitVersion"] = version.GitVersion
+	info["platform"] = version.Platform
 
 	return info, nil
 }
@@ -2,8 +2,18 @@ package client
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
 )
 
 func TestNewKubernetesClient(t *testing.T) {
@@ -90,3 +100,320 @@ func TestGetCurrentContext(t *testing.T) {
 		t.Log("Empty context from kubeconfig")
 	}
 }
+
+func TestNewKubernetesClientFromClientset(t *testing.T) {
+	clientset := fake.NewClientset()
+	clientset.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{
+		GitVersion: "v1.30.0",
+		Platform:   "linux/amd64",
+	}
+
+	client := NewKubernetesClientFromClientset(clientset, StaticConfig{Server: "https://fake:6443"}, "fake-context")
+
+	if client.Clientset != clientset {
+		t.Error("Expected clientset to be set correctly")
+	}
+	if client.Discovery == nil {
+		t.Fatal("Expected discovery client to be set from clientset")
+	}
+
+	ctx := context.Background()
+	if err := client.TestConnection(ctx); err != nil {
+		t.Fatalf("TestConnection() unexpected error: %v", err)
+	}
+
+	info, err := client.GetClusterInfo(ctx)
+	if err != nil {
+		t.Fatalf("GetClusterInfo() unexpected error: %v", err)
+	}
+	if info["context"] != "fake-context" {
+		t.Errorf("Expected context 'fake-context', got %q", info["context"])
+	}
+	if info["server"] != "https://fake:6443" {
+		t.Errorf("Expected server 'https://fake:6443', got %q", info["server"])
+	}
+	if info["gitVersion"] != "v1.30.0" {
+		t.Errorf("Expected gitVersion 'v1.30.0', got %q", info["gitVersion"])
+	}
+}
+
+// countingDiscovery counts server version reads.
+type countingDiscovery struct {
+	reads int
+}
+
+func (d *countingDiscovery) ServerVersion() (*version.Info, error) {
+	d.reads++
+	return &version.Info{GitVersion: "v1.30.0"}, nil
+}
+
+func TestGetClusterInfoReadsVersionOnce(t *testing.T) {
+	client := NewKubernetesClientFromClientset(fake.NewClientset(), StaticConfig{Server: "https://fake:6443"}, "fake-context")
+	discovery := &countingDiscovery{}
+	client.Discovery = discovery
+
+	ctx := context.Background()
+	first, err := client.GetClusterInfo(ctx)
+	if err != nil {
+		t.Fatalf("GetClusterInfo() unexpected error: %v", err)
+	}
+	first["context"] = "changed"
+
+	second, err := client.GetClusterInfo(ctx)
+	if err != nil {
+		t.Fatalf("GetClusterInfo() unexpected error: %v", err)
+	}
+	if discovery.reads != 1 {
+		t.Errorf("Expected the server version to be read once, got %d reads", discovery.reads)
+	}
+	if second["context"] != "fake-context" {
+		t.Errorf("Expected callers not to share the returned map, got context %q", second["context"])
+	}
+
+	// Clients do not share cluster information
+	other := NewKubernetesClientFromClientset(fake.NewClientset(), StaticConfig{Server: "https://other:6443"}, "other-context")
+	other.Discovery = &countingDiscovery{}
+	info, err := other.GetClusterInfo(ctx)
+	if err != nil {
+		t.Fatalf("GetClusterInfo() unexpected error: %v", err)
+	}
+	if info["context"] != "other-context" {
+		t.Errorf("Expected context 'other-context', got %q", info["context"])
+	}
+}
+
+func TestNewKubernetesClientFromClientset_NilConfig(t *testing.T) {
+	client := NewKubernetesClientFromClientset(fake.NewClientset(), nil, "")
+
+	if client.Config == nil {
+		t.Fatal("Expected default config provider")
+	}
+	if client.Config.Host() != "" {
+		t.Errorf("Expected empty host, got %q", client.Config.Host())
+	}
+	if client.Config.RESTConfig() != nil {
+		t.Error("Expected nil rest.Config for static config")
+	}
+}
+
+func TestRESTConfig(t *testing.T) {
+	provider := RESTConfig(&rest.Config{Host: "https://example:6443"})
+
+	if provider.Host() != "https://example:6443" {
+		t.Errorf("Expected host 'https://example:6443', got %q", provider.Host())
+	}
+	if provider.RESTConfig() == nil {
+		t.Error("Expected wrapped rest.Config")
+	}
+
+	if RESTConfig(nil).Host() != "" {
+		t.Error("Expected empty host for nil rest.Config")
+	}
+}
+
+const multiContextKubeconfig = `apiVersion: v1
+kind: Config
+current-context: staging
+clusters:
+- name: eu
+  cluster:
+    server: https://eu.example.com:6443
+- name: us
+  cluster:
+    server: https://us.example.com:6443
+contexts:
+- name: prod-us
+  context:
+    cluster: us
+    user: admin
+- name: prod-eu
+  context:
+    cluster: eu
+    user: admin
+- name: staging
+  context:
+    cluster: eu
+    user: admin
+users:
+- name: admin
+  user:
+    token: secret
+`
+
+func writeKubeconfig(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(multiContextKubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestListContexts(t *testing.T) {
+	contexts, err := ListContexts(writeKubeconfig(t))
+	if err != nil {
+		t.Fatalf("ListContexts() unexpected error: %v", err)
+	}
+
+	expected := []string{"prod-eu", "prod-us", "staging"}
+	if len(contexts) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, contexts)
+	}
+	for i := range expected {
+		if contexts[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, contexts)
+		}
+	}
+}
+
+func TestNewKubernetesClientForContext(t *testing.T) {
+	kubeconfig := writeKubeconfig(t)
+
+	client, err := NewKubernetesClientForContext(kubeconfig, "prod-us")
+	if err != nil {
+		t.Fatalf("NewKubernetesClientForContext() unexpected error: %v", err)
+	}
+	if client.Context != "prod-us" {
+		t.Errorf("Expected context prod-us, got %q", client.Context)
+	}
+	if client.Config.Host() != "https://us.example.com:6443" {
+		t.Errorf("Expected us server, got %q", client.Config.Host())
+	}
+
+	current, err := NewKubernetesClientForContext(kubeconfig, "")
+	if err != nil {
+		t.Fatalf("NewKubernetesClientForContext() unexpected error: %v", err)
+	}
+	if current.Context != "staging" {
+		t.Errorf("Expected current context staging, got %q", current.Context)
+	}
+
+	if _, err := NewKubernetesClientForContext(kubeconfig, "missing"); err == nil {
+		t.Error("Expected error for unknown context")
+	}
+}
+
+func TestNewKubernetesClientWithOptions(t *testing.T) {
+	kubeconfig := writeKubeconfig(t)
+
+	client, err := NewKubernetesClientWithOptions(Options{
+		Kubeconfig: kubeconfig,
+		Context:    "prod-us",
+		QPS:        50,
+		Burst:      100,
+		ChunkSize:  250,
+	})
+	if err != nil {
+		t.Fatalf("NewKubernetesClientWithOptions() unexpected error: %v", err)
+	}
+
+	config := client.Config.RESTConfig()
+	if config.QPS != 50 || config.Burst != 100 {
+		t.Errorf("Expected QPS 50 and burst 100, got %v and %d", config.QPS, config.Burst)
+	}
+	if client.ChunkSize != 250 {
+		t.Errorf("Expected chunk size 250, got %d", client.ChunkSize)
+	}
+
+	defaults, err := NewKubernetesClientForContext(kubeconfig, "prod-us")
+	if err != nil {
+		t.Fatalf("NewKubernetesClientForContext() unexpected error: %v", err)
+	}
+	if qps := defaults.Config.RESTConfig().QPS; qps != 0 {
+		t.Errorf("Expected the client-go default QPS to be kept, got %v", qps)
+	}
+	if defaults.ChunkSize != DefaultChunkSize {
+		t.Errorf("Expected default chunk size %d, got %d", DefaultChunkSize, defaults.ChunkSize)
+	}
+}
+
+func TestRequestCounter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"major":"1","minor":"30","gitVersion":"v1.30.0"}`))
+	}))
+	defer server.Close()
+
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	config := strings.ReplaceAll(multiContextKubeconfig, "https://us.example.com:6443", server.URL)
+	if err := os.WriteFile(kubeconfig, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewKubernetesClientForContext(kubeconfig, "prod-us")
+	if err != nil {
+		t.Fatalf("NewKubernetesClientForContext() unexpected error: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := client.TestConnection(context.Background()); err != nil {
+			t.Fatalf("TestConnection() unexpected error: %v", err)
+		}
+	}
+
+	if count := client.Requests.Count(); count != 2 {
+		t.Errorf("Expected 2 counted requests, got %d", count)
+	}
+}
+
+func TestImpersonation(t *testing.T) {
+	var users, groups []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		users = append(users, r.Header.Get("Impersonate-User"))
+		groups = append(groups, strings.Join(r.Header.Values("Impersonate-Group"), ","))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"major":"1","minor":"30","gitVersion":"v1.30.0"}`))
+	}))
+	defer server.Close()
+
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	config := strings.ReplaceAll(multiContextKubeconfig, "https://us.example.com:6443", server.URL)
+	if err := os.WriteFile(kubeconfig, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewKubernetesClientWithOptions(Options{
+		Kubeconfig:  kubeconfig,
+		Context:     "prod-us",
+		Impersonate: rest.ImpersonationConfig{UserName: "jane", Groups: []string{"developers"}},
+	})
+	if err != nil {
+		t.Fatalf("NewKubernetesClientWithOptions() unexpected error: %v", err)
+	}
+	if err := client.TestConnection(context.Background()); err != nil {
+		t.Fatalf("TestConnection() unexpected error: %v", err)
+	}
+
+	saClient, err := client.Impersonate(ServiceAccountIdentity("shop", "web"))
+	if err != nil {
+		t.Fatalf("Impersonate() unexpected error: %v", err)
+	}
+	if err := saClient.TestConnection(context.Background()); err != nil {
+		t.Fatalf("TestConnection() unexpected error: %v", err)
+	}
+
+	if users[0] != "jane" || groups[0] != "developers" {
+		t.Errorf("Expected requests as jane/developers, got %q/%q", users[0], groups[0])
+	}
+	if users[1] != "system:serviceaccount:shop:web" ||
+		groups[1] != "system:serviceaccounts,system:serviceaccounts:shop,system:authenticated" {
+		t.Errorf("Expected requests as the service account, got %q/%q", users[1], groups[1])
+	}
+	if count := client.Requests.Count(); count != 2 {
+		t.Errorf("Expected the impersonated client to share the request counter, got %d", count)
+	}
+
+	if _, err := NewKubernetesClientWithOptions(Options{
+		Kubeconfig:  kubeconfig,
+		Context:     "prod-us",
+		Impersonate: rest.ImpersonationConfig{Groups: []string{"developers"}},
+	}); err == nil {
+		t.Error("Expected an error when impersonating a group without a user")
+	}
+
+	if _, err := NewKubernetesClientFromClientset(fake.NewClientset(), nil, "fake").Impersonate(ServiceAccountIdentity("shop", "web")); err == nil {
+		t.Error("Expected an error when impersonating without a REST config")
+	}
+}
@@ -0,0 +1,34 @@
+package client
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// DataTracker records whether the cache reads made with a context returned
+// complete data. Reads that fail after retries (other than NotFound) or that
+// return only some pages of a list mark the tracker partial.
+type DataTracker struct {
+	partial atomic.Bool
+}
+
+type dataTrackerKey struct{}
+
+// TrackData returns a context whose cache reads are recorded by the returned
+// tracker.
+func TrackData(ctx context.Context) (context.Context, *DataTracker) {
+	tracker := &DataTracker{}
+	return context.WithValue(ctx, dataTrackerKey{}, tracker), tracker
+}
+
+// Partial reports whether any tracked read returned incomplete data.
+func (t *DataTracker) Partial() bool {
+	return t != nil && t.partial.Load()
+}
+
+// markPartial marks the context's tracker, if any, as partial.
+func markPartial(ctx context.Context) {
+	if tracker, ok := ctx.Value(dataTrackerKey{}).(*DataTracker); ok {
+		tracker.partial.Store(true)
+	}
+}
@@ -0,0 +1,300 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Kind describes a built-in resource kind that Objects can list.
+type Kind struct {
+	// Kind is the object kind, e.g. Deployment
+	Kind string
+
+	// Version is the API group version the kind is listed from
+	Version schema.GroupVersion
+
+	// Resource is the plural resource name used in RBAC rules
+	Resource string
+
+	// Namespaced is false for cluster-scoped kinds such as nodes
+	Namespaced bool
+
+	list func(clientset kubernetes.Interface) listFunc[cacheObject]
+}
+
+// APIVersion returns the apiVersion of the kind's objects, e.g. apps/v1.
+func (k Kind) APIVersion() string {
+	return k.Version.String()
+}
+
+// kinds lists the kinds Objects supports. Secrets are deliberately absent, so
+// that generic lookups never read secret values.
+var kinds = []Kind{
+	{Kind: "Pod", Version: corev1.SchemeGroupVersion, Resource: "pods", Namespaced: true},
+	{Kind: "Service", Version: corev1.SchemeGroupVersion, Resource: "services", Namespaced: true},
+	{Kind: "Endpoints", Version: corev1.SchemeGroupVersion, Resource: "endpoints", Namespaced: true},
+	{Kind: "ServiceAccount", Version: corev1.SchemeGroupVersion, Resource: "serviceaccounts", Namespaced: true},
+	{Kind: "Node", Version: corev1.SchemeGroupVersion, Resource: "nodes"},
+	{Kind: "Ingress", Version: networkingv1.SchemeGroupVersion, Resource: "ingresses", Namespaced: true},
+	{Kind: "ConfigMap", Version: corev1.SchemeGroupVersion, Resource: "configmaps", Namespaced: true,
+		list: func(clientset kubernetes.Interface) listFunc[cacheObject] {
+			return genericList(func(ctx context.Context, ns string, opts metav1.ListOptions) ([]corev1.ConfigMap, string, error) {
+				list, err := clientset.CoreV1().ConfigMaps(ns).List(ctx, opts)
+				if err != nil {
+					return nil, "", err
+				}
+				return list.Items, list.Continue, nil
+			})
+		}},
+	{Kind: "PersistentVolumeClaim", Version: corev1.SchemeGroupVersion, Resource: "persistentvolumeclaims", Namespaced: true,
+		list: func(clientset kubernetes.Interface) listFunc[cacheObject] {
+			return genericList(func(ctx context.Context, ns string, opts metav1.ListOptions) ([]corev1.PersistentVolumeClaim, string, error) {
+				list, err := clientset.CoreV1().PersistentVolumeClaims(ns).List(ctx, opts)
+				if err != nil {
+					return nil, "", err
+				}
+				return list.Items, list.Continue, nil
+			})
+		}},
+	{Kind: "PersistentVolume", Version: corev1.SchemeGroupVersion, Resource: "persistentvolumes",
+		list: func(clientset kubernetes.Interface) listFunc[cacheObject] {
+			return genericList(func(ctx context.Context, _ string, opts metav1.ListOptions) ([]corev1.PersistentVolume, string, error) {
+				list, err := clientset.CoreV1().PersistentVolumes().List(ctx, opts)
+				if err != nil {
+					return nil, "", err
+				}
+				return list.Items, list.Continue, nil
+			})
+		}},
+	{Kind: "Namespace", Version: corev1.SchemeGroupVersion, Resource: "namespaces",
+		list: func(clientset kubernetes.Interface) listFunc[cacheObject] {
+			return genericList(func(ctx context.Context, _ string, opts metav1.ListOptions) ([]corev1.Namespace, string, error) {
+				list, err := clientset.CoreV1().Namespaces().List(ctx, opts)
+				if err != nil {
+					return nil, "", err
+				}
+				return list.Items, list.Continue, nil
+			})
+		}},
+	{Kind: "ResourceQuota", Version: corev1.SchemeGroupVersion, Resource: "resourcequotas", Namespaced: true,
+		list: func(clientset kubernetes.Interface) listFunc[cacheObject] {
+			return genericList(func(ctx context.Context, ns string, opts metav1.ListOptions) ([]corev1.ResourceQuota, string, error) {
+				list, err := clientset.CoreV1().ResourceQuotas(ns).List(ctx, opts)
+				if err != nil {
+					return nil, "", err
+				}
+				return list.Items, list.Continue, nil
+			})
+		}},
+	{Kind: "LimitRange", Version: corev1.SchemeGroupVersion, Resource: "limitranges", Namespaced: true,
+		list: func(clientset kubernetes.Interface) listFunc[cacheObject] {
+			return genericList(func(ctx context.Context, ns string, opts metav1.ListOptions) ([]corev1.LimitRange, string, error) {
+				list, err := clientset.CoreV1().LimitRanges(ns).List(ctx, opts)
+				if err != nil {
+					return nil, "", err
+				}
+				return list.Items, list.Continue, nil
+			})
+		}},
+	{Kind: "Deployment", Version: appsv1.SchemeGroupVersion, Resource: "deployments", Namespaced: true,
+		list: func(clientset kubernetes.Interface) listFunc[cacheObject] {
+			return genericList(func(ctx context.Context, ns string, opts metav1.ListOptions) ([]appsv1.Deployment, string, error) {
+				list, err := clientset.AppsV1().Deployments(ns).List(ctx, opts)
+				if err != nil {
+					return nil, "", err
+				}
+				return list.Items, list.Continue, nil
+			})
+		}},
+	{Kind: "StatefulSet", Version: appsv1.SchemeGroupVersion, Resource: "statefulsets", Namespaced: true,
+		list: func(clientset kubernetes.Interface) listFunc[cacheObject] {
+			return genericList(func(ctx context.Context, ns string, opts metav1.ListOptions) ([]appsv1.StatefulSet, string, error) {
+				list, err := clientset.AppsV1().StatefulSets(ns).List(ctx, opts)
+				if err != nil {
+					return nil, "", err
+				}
+				return list.Items, list.Continue, nil
+			})
+		}},
+	{Kind: "DaemonSet", Version: appsv1.SchemeGroupVersion, Resource: "daemonsets", Namespaced: true,
+		list: func(clientset kubernetes.Interface) listFunc[cacheObject] {
+			return genericList(func(ctx context.Context, ns string, opts metav1.ListOptions) ([]appsv1.DaemonSet, string, error) {
+				list, err := clientset.AppsV1().DaemonSets(ns).List(ctx, opts)
+				if err != nil {
+					return nil, "", err
+				}
+				return list.Items, list.Continue, nil
+			})
+		}},
+	{Kind: "ReplicaSet", Version: appsv1.SchemeGroupVersion, Resource: "replicasets", Namespaced: true,
+		list: func(clientset kubernetes.Interface) listFunc[cacheObject] {
+			return genericList(func(ctx context.Context, ns string, opts metav1.ListOptions) ([]appsv1.ReplicaSet, string, error) {
+				list, err := clientset.AppsV1().ReplicaSets(ns).List(ctx, opts)
+				if err != nil {
+					return nil, "", err
+				}
+				return list.Items, list.Continue, nil
+			})
+		}},
+	{Kind: "Job", Version: batchv1.SchemeGroupVersion, Resource: "jobs", Namespaced: true,
+		list: func(clientset kubernetes.Interface) listFunc[cacheObject] {
+			return genericList(func(ctx context.Context, ns string, opts metav1.ListOptions) ([]batchv1.Job, string, error) {
+				list, err := clientset.BatchV1().Jobs(ns).List(ctx, opts)
+				if err != nil {
+					return nil, "", err
+				}
+				return list.Items, list.Continue, nil
+			})
+		}},
+	{Kind: "CronJob", Version: batchv1.SchemeGroupVersion, Resource: "cronjobs", Namespaced: true,
+		list: func(clientset kubernetes.Interface) listFunc[cacheObject] {
+			return genericList(func(ctx context.Context, ns string, opts metav1.ListOptions) ([]batchv1.CronJob, string, error) {
+				list, err := clientset.BatchV1().CronJobs(ns).List(ctx, opts)
+				if err != nil {
+					return nil, "", err
+				}
+				return list.Items, list.Continue, nil
+			})
+		}},
+	{Kind: "PodDisruptionBudget", Version: policyv1.SchemeGroupVersion, Resource: "poddisruptionbudgets", Namespaced: true,
+		list: func(clientset kubernetes.Interface) listFunc[cacheObject] {
+			return genericList(func(ctx context.Context, ns string, opts metav1.ListOptions) ([]policyv1.PodDisruptionBudget, string, error) {
+				list, err := clientset.PolicyV1().PodDisruptionBudgets(ns).List(ctx, opts)
+				if err != nil {
+					return nil, "", err
+				}
+				return list.Items, list.Continue, nil
+			})
+		}},
+	{Kind: "HorizontalPodAutoscaler", Version: autoscalingv2.SchemeGroupVersion, Resource: "horizontalpodautoscalers", Namespaced: true,
+		list: func(clientset kubernetes.Interface) listFunc[cacheObject] {
+			return genericList(func(ctx context.Context, ns string, opts metav1.ListOptions) ([]autoscalingv2.HorizontalPodAutoscaler, string, error) {
+				list, err := clientset.AutoscalingV2().HorizontalPodAutoscalers(ns).List(ctx, opts)
+				if err != nil {
+					return nil, "", err
+				}
+				return list.Items, list.Continue, nil
+			})
+		}},
+	{Kind: "NetworkPolicy", Version: networkingv1.SchemeGroupVersion, Resource: "networkpolicies", Namespaced: true,
+		list: func(clientset kubernetes.Interface) listFunc[cacheObject] {
+			return genericList(func(ctx context.Context, ns string, opts metav1.ListOptions) ([]networkingv1.NetworkPolicy, string, error) {
+				list, err := clientset.NetworkingV1().NetworkPolicies(ns).List(ctx, opts)
+				if err != nil {
+					return nil, "", err
+				}
+				return list.Items, list.Continue, nil
+			})
+		}},
+}
+
+// LookupKind returns the supported kind with the given name, ignoring case.
+func LookupKind(name string) (Kind, bool) {
+	for _, kind := range kinds {
+		if strings.EqualFold(kind.Kind, name) {
+			return kind, true
+		}
+	}
+	return Kind{}, false
+}
+
+// KindNames returns the names of the supported kinds in alphabetical order.
+func KindNames() []string {
+	names := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		names = append(names, kind.Kind)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Objects lists the objects of a supported kind in a namespace
+// (metav1.NamespaceAll for every namespace); cluster-scoped kinds ignore the
+// namespace. Kinds the cache already holds share its lists, and other kinds
+// are listed once per namespace like them.
+func (c *Cache) Objects(ctx context.Context, kindName, namespace string) ([]runtime.Object, error) {
+	kind, ok := LookupKind(kindName)
+	if !ok {
+		return nil, fmt.Errorf("unsupported kind %q", kindName)
+	}
+	if !kind.Namespaced {
+		namespace = metav1.NamespaceAll
+	}
+
+	switch kind.Kind {
+	case "Pod":
+		return objects(c.pods.list(ctx, namespace))
+	case "Service":
+		return objects(c.services.list(ctx, namespace))
+	case "Endpoints":
+		return objects(c.endpoints.list(ctx, namespace))
+	case "ServiceAccount":
+		return objects(c.serviceAccounts.list(ctx, namespace))
+	case "Node":
+		return objects(c.nodes.list(ctx, namespace))
+	case "Ingress":
+		return objects(c.ingresses.list(ctx, namespace))
+	}
+
+	return objects(c.kindCache(kind).list(ctx, namespace))
+}
+
+// kindCache returns the cache of a kind without a typed cache of its own,
+// creating it on first use.
+func (c *Cache) kindCache(kind Kind) *resourceCache[cacheObject] {
+	c.kindsMu.Lock()
+	defer c.kindsMu.Unlock()
+
+	if cache, ok := c.kinds[kind.Kind]; ok {
+		return cache
+	}
+
+	cache := newResourceCache(c, kind.Version.WithResource(kind.Resource).GroupResource(), kind.list(c.clientset), nil)
+	c.kinds[kind.Kind] = cache
+	return cache
+}
+
+// genericList adapts a typed list function to the cache of a kind.
+func genericList[T any, PT interface {
+	*T
+	cacheObject
+}](list func(ctx context.Context, namespace string, opts metav1.ListOptions) ([]T, string, error)) listFunc[cacheObject] {
+	return func(ctx context.Context, namespace string, opts metav1.ListOptions) ([]cacheObject, string, error) {
+		items, next, err := list(ctx, namespace, opts)
+		if err != nil {
+			return nil, "", err
+		}
+
+		result := make([]cacheObject, len(items))
+		for i := range items {
+			result[i] = PT(&items[i])
+		}
+		return result, next, nil
+	}
+}
+
+// objects converts a typed list to runtime objects.
+func objects[T cacheObject](items []T, err error) ([]runtime.Object, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]runtime.Object, len(items))
+	for i, item := range items {
+		result[i] = item
+	}
+	return result, nil
+}
@@ -0,0 +1,37 @@
+package client
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// RequestCounter counts the HTTP requests a client sends to the API server.
+// A nil counter counts nothing and reports zero.
+type RequestCounter struct {
+	count atomic.Int64
+}
+
+// Count returns the number of requests made so far.
+func (r *RequestCounter) Count() int64 {
+	if r == nil {
+		return 0
+	}
+	return r.count.Load()
+}
+
+// wrap returns a round tripper that counts every request sent through rt.
+func (r *RequestCounter) wrap(rt http.RoundTripper) http.RoundTripper {
+	return &countingRoundTripper{counter: r, next: rt}
+}
+
+// countingRoundTripper increments a RequestCounter for every request.
+type countingRoundTripper struct {
+	counter *RequestCounter
+	next    http.RoundTripper
+}
+
+// RoundTrip counts the request and forwards it.
+func (c *countingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.counter.count.Add(1)
+	return c.next.RoundTrip(req)
+}
@@ -0,0 +1,321 @@
+// Package config loads kdebug's configuration files: the user's
+// ~/.config/kdebug/config.yaml and a per-repository .kdebug.yaml found in the
+// working directory or one of its parents.
+//
+// Settings in .kdebug.yaml take precedence over the user file, and flags given
+// on the command line take precedence over both. Check IDs are validated when
+// the configuration is applied to the check registry, since only the
+// diagnostic packages know which checks exist.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"kdebug/internal/output"
+)
+
+// FileName is the name of the per-repository configuration file.
+const FileName = ".kdebug.yaml"
+
+// Config is the schema of a configuration file. Every setting is optional.
+type Config struct {
+	// Output is the default --output format
+	Output string `yaml:"output,omitempty"`
+
+	// Namespace is the default --namespace
+	Namespace string `yaml:"namespace,omitempty"`
+
+	Checks     Checks     `yaml:"checks,omitempty"`
+	Thresholds Thresholds `yaml:"thresholds,omitempty"`
+
+	// LogPatterns are matched against container logs with --include-logs,
+	// before the built-in patterns
+	LogPatterns []LogPattern `yaml:"logPatterns,omitempty"`
+
+	// CustomChecks lists files and directories of custom check definitions;
+	// relative paths are relative to the configuration file
+	CustomChecks []string `yaml:"customChecks,omitempty"`
+
+	// PluginDirs lists directories searched for check plugins before the
+	// user's plugin directory and PATH; relative paths are relative to the
+	// configuration file
+	PluginDirs []string `yaml:"pluginDirs,omitempty"`
+
+	// Sources lists the files the configuration was loaded from, in order
+	// of increasing precedence
+	Sources []string `yaml:"-"`
+}
+
+// Checks changes which checks run by default and how serious their
+// findings are. Checks are named by ID, e.g. POD-LOGS.
+type Checks struct {
+	// Enable lists checks to run by default, such as opt-in checks
+	Enable []string `yaml:"enable,omitempty"`
+
+	// Disable lists checks not to run unless selected with --checks
+	Disable []string `yaml:"disable,omitempty"`
+
+	// Severity overrides the severity of a check's failures; its warnings
+	// are reported one level lower
+	Severity map[string]output.Severity `yaml:"severity,omitempty"`
+}
+
+// Thresholds tune individual checks, keyed by check ID.
+type Thresholds struct {
+	Connectivity ConnectivityThresholds `yaml:"CLUSTER-CONNECTIVITY,omitempty"`
+	TLS          TLSThresholds          `yaml:"INGRESS-TLS,omitempty"`
+}
+
+// ConnectivityThresholds tune the API server connectivity check.
+type ConnectivityThresholds struct {
+	// SlowResponse is the response time above which the check warns
+	// (default 5s)
+	SlowResponse time.Duration `yaml:"slowResponse,omitempty"`
+}
+
+// TLSThresholds tune what the ingress TLS check expects of TLS secrets.
+type TLSThresholds struct {
+	// SecretTypes are the accepted secret types (default kubernetes.io/tls)
+	SecretTypes []string `yaml:"secretTypes,omitempty"`
+
+	// RequiredKeys are the data keys a secret must have (default tls.crt
+	// and tls.key)
+	RequiredKeys []string `yaml:"requiredKeys,omitempty"`
+
+	// RequireHosts warns about TLS blocks without hosts (default true)
+	RequireHosts *bool `yaml:"requireHosts,omitempty"`
+}
+
+// LogPattern is a regular expression that marks a container's logs as
+// failing, with the message and suggestion reported for it.
+type LogPattern struct {
+	Pattern    string `yaml:"pattern"`
+	Message    string `yaml:"message"`
+	Suggestion string `yaml:"suggestion,omitempty"`
+}
+
+// Regexp returns the compiled pattern of a validated configuration.
+func (p LogPattern) Regexp() *regexp.Regexp {
+	return regexp.MustCompile(p.Pattern)
+}
+
+// UserPath returns the path of the user's configuration file, under
+// $XDG_CONFIG_HOME when set. It returns "" when there is no home directory.
+func UserPath() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "kdebug", "config.yaml")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "kdebug", "config.yaml")
+}
+
+// FindRepoFile returns the .kdebug.yaml in dir or its nearest parent that
+// has one, or "" when there is none.
+func FindRepoFile(dir string) string {
+	for {
+		path := filepath.Join(dir, FileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Load loads and merges the user's configuration file and the .kdebug.yaml
+// nearest to the working directory. Missing files are not an error.
+func Load() (*Config, error) {
+	var paths []string
+	if path := UserPath(); path != "" {
+		paths = append(paths, path)
+	}
+	if dir, err := os.Getwd(); err == nil {
+		if path := FindRepoFile(dir); path != "" && !slices.Contains(paths, path) {
+			paths = append(paths, path)
+		}
+	}
+
+	config := &Config{}
+	for _, path := range paths {
+		file, err := LoadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		config.Merge(file)
+	}
+
+	return config, nil
+}
+
+// LoadFile loads and validates a single configuration file. Unknown fields
+// are an error, so that misspelt settings are not silently ignored.
+func LoadFile(path string) (*Config, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration file: %w", err)
+	}
+	defer file.Close()
+
+	config := &Config{}
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+
+	for _, paths := range [][]string{config.CustomChecks, config.PluginDirs} {
+		for i, relative := range paths {
+			if !filepath.IsAbs(relative) {
+				paths[i] = filepath.Join(filepath.Dir(path), relative)
+			}
+		}
+	}
+
+	config.Sources = []string{path}
+	return config, nil
+}
+
+// Validate checks the settings that can be checked without the check
+// registry.
+func (c *Config) Validate() error {
+	if c.Output != "" {
+		if !output.KnownFormat(c.Output) {
+			return fmt.Errorf("output: unknown format %q", c.Output)
+		}
+		if err := output.ValidateFormat(c.Output); err != nil {
+			return fmt.Errorf("output: %w", err)
+		}
+	}
+	if c.Namespace != "" {
+		if errs := validation.IsDNS1123Label(c.Namespace); len(errs) > 0 {
+			return fmt.Errorf("namespace: %q is not a valid namespace: %s", c.Namespace, strings.Join(errs, "; "))
+		}
+	}
+
+	for _, id := range c.Checks.Enable {
+		if slices.ContainsFunc(c.Checks.Disable, func(disabled string) bool { return strings.EqualFold(id, disabled) }) {
+			return fmt.Errorf("checks: %s is both enabled and disabled", id)
+		}
+	}
+	for id, severity := range c.Checks.Severity {
+		if severity.Rank() < 0 {
+			return fmt.Errorf("checks.severity.%s: unknown severity %q (want info, low, medium, high or critical)", id, severity)
+		}
+	}
+
+	if c.Thresholds.Connectivity.SlowResponse < 0 {
+		return fmt.Errorf("thresholds.CLUSTER-CONNECTIVITY.slowResponse must not be negative")
+	}
+	for _, key := range c.Thresholds.TLS.RequiredKeys {
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("thresholds.INGRESS-TLS.requiredKeys must not contain empty keys")
+		}
+	}
+
+	for i, pattern := range c.LogPatterns {
+		if pattern.Pattern == "" {
+			return fmt.Errorf("logPatterns[%d]: pattern is required", i)
+		}
+		if _, err := regexp.Compile(pattern.Pattern); err != nil {
+			return fmt.Errorf("logPatterns[%d]: %w", i, err)
+		}
+		if pattern.Message == "" {
+			return fmt.Errorf("logPatterns[%d]: message is required", i)
+		}
+	}
+
+	for i, path := range c.CustomChecks {
+		if strings.TrimSpace(path) == "" {
+			return fmt.Errorf("customChecks[%d]: path is required", i)
+		}
+	}
+	for i, dir := range c.PluginDirs {
+		if strings.TrimSpace(dir) == "" {
+			return fmt.Errorf("pluginDirs[%d]: path is required", i)
+		}
+	}
+
+	return nil
+}
+
+// Merge applies the settings of a configuration that takes precedence. Its
+// enabled and disabled checks replace the opposite setting of the same
+// checks, its log patterns are tried first, its custom checks are loaded
+// after the others and its plugin directories are searched first.
+func (c *Config) Merge(override *Config) {
+	if override.Output != "" {
+		c.Output = override.Output
+	}
+	if override.Namespace != "" {
+		c.Namespace = override.Namespace
+	}
+
+	for _, id := range override.Checks.Enable {
+		c.Checks.Disable = without(c.Checks.Disable, id)
+		c.Checks.Enable = append(without(c.Checks.Enable, id), id)
+	}
+	for _, id := range override.Checks.Disable {
+		c.Checks.Enable = without(c.Checks.Enable, id)
+		c.Checks.Disable = append(without(c.Checks.Disable, id), id)
+	}
+	for id, severity := range override.Checks.Severity {
+		if c.Checks.Severity == nil {
+			c.Checks.Severity = make(map[string]output.Severity)
+		}
+		for existing := range c.Checks.Severity {
+			if strings.EqualFold(existing, id) {
+				delete(c.Checks.Severity, existing)
+			}
+		}
+		c.Checks.Severity[id] = severity
+	}
+
+	if override.Thresholds.Connectivity.SlowResponse != 0 {
+		c.Thresholds.Connectivity.SlowResponse = override.Thresholds.Connectivity.SlowResponse
+	}
+	if override.Thresholds.TLS.SecretTypes != nil {
+		c.Thresholds.TLS.SecretTypes = override.Thresholds.TLS.SecretTypes
+	}
+	if override.Thresholds.TLS.RequiredKeys != nil {
+		c.Thresholds.TLS.RequiredKeys = override.Thresholds.TLS.RequiredKeys
+	}
+	if override.Thresholds.TLS.RequireHosts != nil {
+		c.Thresholds.TLS.RequireHosts = override.Thresholds.TLS.RequireHosts
+	}
+
+	c.LogPatterns = append(slices.Clone(override.LogPatterns), c.LogPatterns...)
+	c.CustomChecks = append(c.CustomChecks, override.CustomChecks...)
+	c.PluginDirs = append(slices.Clone(override.PluginDirs), c.PluginDirs...)
+	c.Sources = append(c.Sources, override.Sources...)
+}
+
+// without returns ids without id, compared case-insensitively.
+func without(ids []string, id string) []string {
+	return slices.DeleteFunc(slices.Clone(ids), func(existing string) bool {
+		return strings.EqualFold(existing, id)
+	})
+}
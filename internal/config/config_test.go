@@ -0,0 +1,198 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"kdebug/internal/output"
+)
+
+func writeFile(t *testing.T, path, content string) string {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFile(t *testing.T) {
+	path := writeFile(t, filepath.Join(t.TempDir(), FileName), `
+output: json
+namespace: production
+checks:
+  enable: [POD-LOGS]
+  disable: [POD-NETWORK]
+  severity:
+    SERVICE-ENDPOINTS: critical
+thresholds:
+  CLUSTER-CONNECTIVITY:
+    slowResponse: 2s
+  INGRESS-TLS:
+    secretTypes: [kubernetes.io/tls, Opaque]
+    requireHosts: false
+logPatterns:
+  - pattern: (?i)deadlock detected
+    message: Database deadlock detected
+    suggestion: Review transaction ordering
+customChecks: [checks/, /etc/kdebug/checks.yaml]
+pluginDirs: [plugins]
+`)
+
+	config, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() unexpected error: %v", err)
+	}
+
+	if config.Output != "json" || config.Namespace != "production" {
+		t.Errorf("Unexpected defaults: %+v", config)
+	}
+	if config.Checks.Severity["SERVICE-ENDPOINTS"] != output.SeverityCritical {
+		t.Errorf("Expected a severity override, got %v", config.Checks.Severity)
+	}
+	if config.Thresholds.Connectivity.SlowResponse != 2*time.Second {
+		t.Errorf("Expected a 2s slow response threshold, got %s", config.Thresholds.Connectivity.SlowResponse)
+	}
+	if tls := config.Thresholds.TLS; len(tls.SecretTypes) != 2 || tls.RequireHosts == nil || *tls.RequireHosts {
+		t.Errorf("Unexpected TLS thresholds: %+v", tls)
+	}
+	if len(config.LogPatterns) != 1 || !config.LogPatterns[0].Regexp().MatchString("ERROR: Deadlock detected") {
+		t.Errorf("Unexpected log patterns: %+v", config.LogPatterns)
+	}
+	if want := []string{filepath.Join(filepath.Dir(path), "checks"), "/etc/kdebug/checks.yaml"}; !slices.Equal(config.CustomChecks, want) {
+		t.Errorf("Expected custom check paths relative to the file, got %v", config.CustomChecks)
+	}
+	if want := []string{filepath.Join(filepath.Dir(path), "plugins")}; !slices.Equal(config.PluginDirs, want) {
+		t.Errorf("Expected plugin directories relative to the file, got %v", config.PluginDirs)
+	}
+	if len(config.Sources) != 1 || config.Sources[0] != path {
+		t.Errorf("Expected the file to be recorded as the source, got %v", config.Sources)
+	}
+}
+
+func TestLoadFileEmpty(t *testing.T) {
+	config, err := LoadFile(writeFile(t, filepath.Join(t.TempDir(), FileName), "# nothing yet\n"))
+	if err != nil {
+		t.Fatalf("LoadFile() unexpected error: %v", err)
+	}
+	if config.Output != "" || len(config.Sources) != 1 {
+		t.Errorf("Expected an empty configuration, got %+v", config)
+	}
+}
+
+func TestLoadFileInvalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown field":        "outptu: json\n",
+		"unknown format":       "output: jsn\n",
+		"invalid template":     "output: template={{.target\n",
+		"invalid namespace":    "namespace: Production\n",
+		"enabled and disabled": "checks:\n  enable: [POD-LOGS]\n  disable: [pod-logs]\n",
+		"unknown severity":     "checks:\n  severity:\n    POD-LOGS: urgent\n",
+		"negative threshold":   "thresholds:\n  CLUSTER-CONNECTIVITY:\n    slowResponse: -1s\n",
+		"invalid duration":     "thresholds:\n  CLUSTER-CONNECTIVITY:\n    slowResponse: soon\n",
+		"empty TLS key":        "thresholds:\n  INGRESS-TLS:\n    requiredKeys: ['']\n",
+		"invalid pattern":      "logPatterns:\n  - pattern: '(unclosed'\n    message: m\n",
+		"pattern without text": "logPatterns:\n  - pattern: deadlock\n",
+		"empty custom checks":  "customChecks: ['']\n",
+		"empty plugin dir":     "pluginDirs: ['']\n",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := writeFile(t, filepath.Join(t.TempDir(), FileName), content)
+			_, err := LoadFile(path)
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			if !strings.Contains(err.Error(), path) {
+				t.Errorf("Expected the error to name the file, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	writeFile(t, filepath.Join(home, "kdebug", "config.yaml"), `
+output: yaml
+namespace: staging
+checks:
+  disable: [POD-LOGS, POD-NETWORK]
+  severity:
+    pod-logs: low
+logPatterns:
+  - pattern: user
+    message: From the user file
+`)
+
+	repo := t.TempDir()
+	writeFile(t, filepath.Join(repo, FileName), `
+namespace: production
+checks:
+  enable: [POD-LOGS]
+  severity:
+    POD-LOGS: high
+logPatterns:
+  - pattern: repo
+    message: From the repository
+`)
+	dir := filepath.Join(repo, "deploy", "charts")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+
+	config, err := Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+
+	if config.Output != "yaml" || config.Namespace != "production" {
+		t.Errorf("Expected the repository file to override the user file, got output %q and namespace %q", config.Output, config.Namespace)
+	}
+	if len(config.Checks.Enable) != 1 || len(config.Checks.Disable) != 1 || config.Checks.Disable[0] != "POD-NETWORK" {
+		t.Errorf("Expected the repository to re-enable POD-LOGS, got %+v", config.Checks)
+	}
+	if len(config.Checks.Severity) != 1 || config.Checks.Severity["POD-LOGS"] != output.SeverityHigh {
+		t.Errorf("Expected the repository severity to win, got %v", config.Checks.Severity)
+	}
+	if len(config.LogPatterns) != 2 || config.LogPatterns[0].Pattern != "repo" {
+		t.Errorf("Expected the repository patterns to be tried first, got %+v", config.LogPatterns)
+	}
+	if len(config.Sources) != 2 {
+		t.Errorf("Expected both files as sources, got %v", config.Sources)
+	}
+}
+
+func TestLoadWithoutFiles(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+
+	config, err := Load()
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if len(config.Sources) != 0 {
+		t.Errorf("Expected no sources, got %v", config.Sources)
+	}
+}
+
+func TestUserPath(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("HOME", "/home/dev")
+	if got := UserPath(); got != filepath.Join("/home/dev", ".config", "kdebug", "config.yaml") {
+		t.Errorf("UserPath() = %s", got)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", "/etc/xdg")
+	if got := UserPath(); got != filepath.Join("/etc/xdg", "kdebug", "config.yaml") {
+		t.Errorf("UserPath() = %s", got)
+	}
+}
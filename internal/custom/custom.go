@@ -0,0 +1,646 @@
+// Package custom loads declarative custom checks: YAML definitions with a
+// target kind, selectors and CEL expressions evaluated against each target
+// object and its related objects. Custom checks are registered with the
+// check registry like built-in ones, so --checks, --skip-checks, the
+// configuration file and 'kdebug checks list' apply to them.
+//
+// Checks that target pods, services or ingresses run with the command of the
+// same name, against every object it diagnoses. Checks that target any other
+// kind run with the cluster command, against every object of that kind.
+package custom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/ext"
+	"gopkg.in/yaml.v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"kdebug/internal/checks"
+	"kdebug/internal/client"
+	"kdebug/internal/output"
+)
+
+// costLimit bounds the work of a single expression evaluation, so that a
+// runaway comprehension over large lists cannot stall diagnostics.
+const costLimit = 10_000_000
+
+// File is the schema of a custom check file.
+type File struct {
+	Checks []Definition `yaml:"checks"`
+}
+
+// Definition is a custom check as written in a file.
+type Definition struct {
+	// ID is the stable, upper-case identifier, e.g. ORG-DEPLOYMENT-PDB
+	ID string `yaml:"id"`
+
+	// Name is the display name of results (default the ID)
+	Name string `yaml:"name,omitempty"`
+
+	Description string `yaml:"description,omitempty"`
+
+	// Category defaults to configuration
+	Category checks.Category `yaml:"category,omitempty"`
+
+	// Severity of a failed result (default medium)
+	Severity output.Severity `yaml:"severity,omitempty"`
+
+	// Enabled runs the check by default (default true)
+	Enabled *bool `yaml:"enabled,omitempty"`
+
+	Target Target `yaml:"target"`
+
+	// Related lists other objects the expressions can read as
+	// related.<name>, by name
+	Related map[string]Related `yaml:"related,omitempty"`
+
+	// When is an optional CEL condition; objects for which it is false are
+	// not reported
+	When string `yaml:"when,omitempty"`
+
+	// Validations are CEL conditions every target object must satisfy
+	Validations []Validation `yaml:"validations"`
+
+	// FailureStatus is the status of objects that fail a validation,
+	// FAILED (default) or WARNING
+	FailureStatus output.CheckStatus `yaml:"failureStatus,omitempty"`
+
+	Suggestion string `yaml:"suggestion,omitempty"`
+}
+
+// Target selects the objects a check evaluates.
+type Target struct {
+	Kind string `yaml:"kind"`
+
+	// Namespaces limits the check to namespaces matching these path.Match
+	// patterns (default every namespace)
+	Namespaces []string `yaml:"namespaces,omitempty"`
+
+	// LabelSelector limits the check to matching objects, e.g. tier=web
+	LabelSelector string `yaml:"labelSelector,omitempty"`
+}
+
+// Related selects objects the expressions can read besides the target.
+type Related struct {
+	Kind string `yaml:"kind"`
+
+	// AllNamespaces reads related objects from every namespace instead of
+	// the target's
+	AllNamespaces bool `yaml:"allNamespaces,omitempty"`
+
+	LabelSelector string `yaml:"labelSelector,omitempty"`
+}
+
+// Validation is a condition target objects must satisfy.
+type Validation struct {
+	// Expression is a CEL expression that must evaluate to true
+	Expression string `yaml:"expression"`
+
+	// Message is reported when the expression is false
+	Message string `yaml:"message,omitempty"`
+
+	// MessageExpression is a CEL expression returning the message; it
+	// takes precedence over Message
+	MessageExpression string `yaml:"messageExpression,omitempty"`
+}
+
+// Check is a loaded and compiled custom check.
+type Check struct {
+	checks.Check
+
+	// Source is the file the check was loaded from
+	Source string
+
+	definition  Definition
+	kind        client.Kind
+	selector    labels.Selector
+	related     map[string]relatedObjects
+	when        cel.Program
+	validations []validation
+}
+
+type relatedObjects struct {
+	Related
+	selector labels.Selector
+}
+
+type validation struct {
+	Validation
+	condition cel.Program
+	message   cel.Program
+}
+
+// Lister lists objects by kind; *client.Cache implements it.
+type Lister interface {
+	Objects(ctx context.Context, kind, namespace string) ([]runtime.Object, error)
+}
+
+// Load loads the custom checks in the given files and directories; the
+// *.yaml and *.yml files of a directory are loaded in name order.
+func Load(paths []string) ([]*Check, error) {
+	env, err := newEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	var loaded []*Check
+	seen := make(map[string]string)
+	for _, path := range paths {
+		files, err := definitionFiles(path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			fileChecks, err := loadFile(env, file)
+			if err != nil {
+				return nil, err
+			}
+			for _, check := range fileChecks {
+				if previous, ok := seen[check.ID]; ok {
+					return nil, fmt.Errorf("invalid custom check file %s: check %s is already defined in %s", file, check.ID, previous)
+				}
+				seen[check.ID] = file
+				loaded = append(loaded, check)
+			}
+		}
+	}
+
+	return loaded, nil
+}
+
+// definitionFiles returns the file itself, or the YAML files of a directory.
+func definitionFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read custom checks: %w", err)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read custom checks: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if !entry.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			files = append(files, filepath.Join(path, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// loadFile loads and compiles the checks of a file. Unknown fields are an
+// error, so that misspelt settings are not silently ignored.
+func loadFile(env *cel.Env, path string) ([]*Check, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read custom checks: %w", err)
+	}
+	defer file.Close()
+
+	var doc File
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&doc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid custom check file %s: %w", path, err)
+	}
+
+	loaded := make([]*Check, 0, len(doc.Checks))
+	for i, definition := range doc.Checks {
+		check, err := compile(env, definition)
+		if err != nil {
+			return nil, fmt.Errorf("invalid custom check file %s: checks[%d]: %w", path, i, err)
+		}
+		check.Source = path
+		loaded = append(loaded, check)
+	}
+
+	return loaded, nil
+}
+
+// newEnv returns the CEL environment of custom check expressions: the
+// target as object and the related objects as related.<name>.
+func newEnv() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable("object", cel.DynType),
+		cel.Variable("related", cel.MapType(cel.StringType, cel.ListType(cel.DynType))),
+		cel.OptionalTypes(),
+		ext.Strings(),
+		ext.Lists(),
+		ext.Sets(),
+	)
+}
+
+// compile validates a definition and compiles its expressions.
+func compile(env *cel.Env, definition Definition) (*Check, error) {
+	if !checks.ValidID(definition.ID) {
+		return nil, fmt.Errorf("id %q must be upper-case letters, digits and dashes, e.g. ORG-DEPLOYMENT-PDB", definition.ID)
+	}
+
+	kind, ok := client.LookupKind(definition.Target.Kind)
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported target kind %q (want one of %s)", definition.ID, definition.Target.Kind, strings.Join(client.KindNames(), ", "))
+	}
+
+	if definition.Category == "" {
+		definition.Category = checks.CategoryConfiguration
+	}
+	if !definition.Category.Known() {
+		return nil, fmt.Errorf("%s: unknown category %q", definition.ID, definition.Category)
+	}
+	if definition.Severity == "" {
+		definition.Severity = output.SeverityMedium
+	}
+	if definition.Severity.Rank() < 0 {
+		return nil, fmt.Errorf("%s: unknown severity %q (want info, low, medium, high or critical)", definition.ID, definition.Severity)
+	}
+	switch definition.FailureStatus {
+	case "":
+		definition.FailureStatus = output.StatusFailed
+	case output.StatusFailed, output.StatusWarning:
+	default:
+		return nil, fmt.Errorf("%s: failureStatus must be %s or %s, got %q", definition.ID, output.StatusFailed, output.StatusWarning, definition.FailureStatus)
+	}
+	for _, pattern := range definition.Target.Namespaces {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%s: invalid namespace pattern %q", definition.ID, pattern)
+		}
+	}
+	if len(definition.Validations) == 0 {
+		return nil, fmt.Errorf("%s: at least one validation is required", definition.ID)
+	}
+
+	check := &Check{
+		definition: definition,
+		kind:       kind,
+		related:    make(map[string]relatedObjects, len(definition.Related)),
+	}
+	name := definition.Name
+	if name == "" {
+		name = definition.ID
+	}
+
+	var err error
+	if check.selector, err = labels.Parse(definition.Target.LabelSelector); err != nil {
+		return nil, fmt.Errorf("%s: invalid labelSelector: %w", definition.ID, err)
+	}
+
+	permissions := []checks.Permission{permission(kind)}
+	inputs := []string{kind.Resource}
+	for _, name := range sortedKeys(definition.Related) {
+		related := definition.Related[name]
+		relatedKind, ok := client.LookupKind(related.Kind)
+		if !ok {
+			return nil, fmt.Errorf("%s: related.%s: unsupported kind %q (want one of %s)", definition.ID, name, related.Kind, strings.Join(client.KindNames(), ", "))
+		}
+		related.Kind = relatedKind.Kind
+
+		selector, err := labels.Parse(related.LabelSelector)
+		if err != nil {
+			return nil, fmt.Errorf("%s: related.%s: invalid labelSelector: %w", definition.ID, name, err)
+		}
+		check.related[name] = relatedObjects{Related: related, selector: selector}
+
+		if !containsPermission(permissions, permission(relatedKind)) {
+			permissions = append(permissions, permission(relatedKind))
+			inputs = append(inputs, relatedKind.Resource)
+		}
+	}
+
+	if definition.When != "" {
+		if check.when, err = compileExpression(env, definition.When, types.BoolType); err != nil {
+			return nil, fmt.Errorf("%s: when: %w", definition.ID, err)
+		}
+	}
+	for i, v := range definition.Validations {
+		if v.Expression == "" {
+			return nil, fmt.Errorf("%s: validations[%d]: expression is required", definition.ID, i)
+		}
+
+		compiled := validation{Validation: v}
+		if compiled.condition, err = compileExpression(env, v.Expression, types.BoolType); err != nil {
+			return nil, fmt.Errorf("%s: validations[%d]: %w", definition.ID, i, err)
+		}
+		if v.MessageExpression != "" {
+			if compiled.message, err = compileExpression(env, v.MessageExpression, types.StringType); err != nil {
+				return nil, fmt.Errorf("%s: validations[%d].messageExpression: %w", definition.ID, i, err)
+			}
+		}
+		check.validations = append(check.validations, compiled)
+	}
+
+	description := definition.Description
+	if description == "" {
+		description = name
+	}
+	check.Check = checks.Check{
+		ID:             definition.ID,
+		Name:           name,
+		Command:        CommandFor(kind.Kind),
+		Alias:          strings.ToLower(definition.ID),
+		Category:       definition.Category,
+		Severity:       definition.Severity,
+		Description:    description,
+		DefaultEnabled: definition.Enabled == nil || *definition.Enabled,
+		Inputs:         inputs,
+		Permissions:    permissions,
+	}
+
+	return check, nil
+}
+
+// compileExpression compiles a CEL expression that must return the given
+// type, or dyn.
+func compileExpression(env *cel.Env, expression string, want *types.Type) (cel.Program, error) {
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if got := ast.OutputType(); !got.IsExactType(want) && !got.IsExactType(types.DynType) {
+		return nil, fmt.Errorf("expression must return %s, not %s", want, got)
+	}
+
+	return env.Program(ast, cel.CostLimit(costLimit), cel.InterruptCheckFrequency(100))
+}
+
+// CommandFor returns the command that runs checks on a target kind.
+func CommandFor(kind string) string {
+	switch kind {
+	case "Pod":
+		return "pod"
+	case "Service":
+		return "service"
+	case "Ingress":
+		return "ingress"
+	default:
+		return "cluster"
+	}
+}
+
+// Register adds the checks to a registry. Checks whose ID is taken are an
+// error, and the checks registered before it stay registered.
+func Register(registry *checks.Registry, custom []*Check) error {
+	for _, check := range custom {
+		if err := registry.Add(check.Check); err != nil {
+			return fmt.Errorf("invalid custom check in %s: %w", check.Source, err)
+		}
+	}
+	return nil
+}
+
+// ForCommand returns the checks run by a command.
+func ForCommand(custom []*Check, command string) []*Check {
+	var selected []*Check
+	for _, check := range custom {
+		if check.Command == command {
+			selected = append(selected, check)
+		}
+	}
+	return selected
+}
+
+// Runnables binds the custom checks of a command to the input of a
+// diagnostic package. object returns the object an input is about, or nil
+// for the cluster command, whose checks list their target kind.
+func Runnables[T any](custom []*Check, command string, lister func(T) Lister, object func(T) runtime.Object) []checks.Runnable[T] {
+	var runnables []checks.Runnable[T]
+	for _, check := range ForCommand(custom, command) {
+		runnables = append(runnables, checks.Runnable[T]{
+			Check: check.Check,
+			Run: func(ctx context.Context, input T) []output.CheckResult {
+				if obj := object(input); obj != nil {
+					return check.Evaluate(ctx, lister(input), obj)
+				}
+				return check.EvaluateAll(ctx, lister(input))
+			},
+		})
+	}
+	return runnables
+}
+
+// EvaluateAll evaluates the check against every object of its target kind.
+func (c *Check) EvaluateAll(ctx context.Context, lister Lister) []output.CheckResult {
+	objects, err := lister.Objects(ctx, c.kind.Kind, metav1.NamespaceAll)
+	if err != nil {
+		return []output.CheckResult{{
+			Name:    c.Name,
+			Status:  output.StatusSkipped,
+			Message: fmt.Sprintf("Failed to list %s", c.kind.Resource),
+			Error:   err.Error(),
+		}}
+	}
+
+	var results []output.CheckResult
+	for _, obj := range objects {
+		results = append(results, c.Evaluate(ctx, lister, obj)...)
+	}
+	if len(results) == 0 {
+		results = append(results, output.CheckResult{
+			Name:    c.Name,
+			Status:  output.StatusSkipped,
+			Message: fmt.Sprintf("No %s matched the target", c.kind.Resource),
+		})
+	}
+
+	return results
+}
+
+// Evaluate evaluates the check against one object. Objects outside the
+// target or for which the when condition is false produce no result.
+func (c *Check) Evaluate(ctx context.Context, lister Lister, obj runtime.Object) []output.CheckResult {
+	meta, err := metaOf(obj)
+	if err != nil || !c.targets(meta) {
+		return nil
+	}
+
+	result := output.CheckResult{
+		Name:     c.Name,
+		Resource: &output.ResourceRef{Kind: c.kind.Kind, Namespace: meta.GetNamespace(), Name: meta.GetName(), UID: string(meta.GetUID())},
+	}
+	skipped := func(message string, err error) []output.CheckResult {
+		result.Status = output.StatusSkipped
+		result.Message = message
+		result.Error = err.Error()
+		return []output.CheckResult{result}
+	}
+
+	object, err := toMap(obj, c.kind.Kind)
+	if err != nil {
+		return skipped("Failed to convert the object for evaluation", err)
+	}
+	related, err := c.relatedObjects(ctx, lister, meta.GetNamespace())
+	if err != nil {
+		return skipped("Failed to list related objects", err)
+	}
+	vars := map[string]any{"object": object, "related": related}
+
+	if c.when != nil {
+		applies, err := evalBool(ctx, c.when, vars)
+		if err != nil {
+			return skipped("Failed to evaluate the when condition", err)
+		}
+		if !applies {
+			return nil
+		}
+	}
+
+	for _, v := range c.validations {
+		ok, err := evalBool(ctx, v.condition, vars)
+		if err != nil {
+			result.Details = map[string]string{"expression": v.Expression}
+			return skipped("Failed to evaluate a validation", err)
+		}
+		if ok {
+			continue
+		}
+
+		result.Status = c.definition.FailureStatus
+		result.Message = c.failureMessage(ctx, v, vars)
+		result.Suggestion = c.definition.Suggestion
+		result.Details = map[string]string{"expression": v.Expression, "source": c.Source}
+		return []output.CheckResult{result}
+	}
+
+	result.Status = output.StatusPassed
+	result.Message = "All validations passed"
+	return []output.CheckResult{result}
+}
+
+// targets reports whether an object is in the check's target.
+func (c *Check) targets(meta metav1.Object) bool {
+	if !c.selector.Matches(labels.Set(meta.GetLabels())) {
+		return false
+	}
+	if len(c.definition.Target.Namespaces) == 0 || !c.kind.Namespaced {
+		return true
+	}
+	for _, pattern := range c.definition.Target.Namespaces {
+		if ok, _ := path.Match(pattern, meta.GetNamespace()); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// relatedObjects lists the related objects of a target in a namespace.
+func (c *Check) relatedObjects(ctx context.Context, lister Lister, namespace string) (map[string]any, error) {
+	related := make(map[string]any, len(c.related))
+	for name, spec := range c.related {
+		listNamespace := namespace
+		if spec.AllNamespaces {
+			listNamespace = metav1.NamespaceAll
+		}
+
+		objects, err := lister.Objects(ctx, spec.Kind, listNamespace)
+		if err != nil {
+			return nil, fmt.Errorf("related.%s: %w", name, err)
+		}
+
+		items := make([]any, 0, len(objects))
+		for _, obj := range objects {
+			meta, err := metaOf(obj)
+			if err != nil || !spec.selector.Matches(labels.Set(meta.GetLabels())) {
+				continue
+			}
+			item, err := toMap(obj, spec.Kind)
+			if err != nil {
+				return nil, fmt.Errorf("related.%s: %w", name, err)
+			}
+			items = append(items, item)
+		}
+		related[name] = items
+	}
+	return related, nil
+}
+
+// failureMessage returns the message of a failed validation.
+func (c *Check) failureMessage(ctx context.Context, v validation, vars map[string]any) string {
+	if v.message != nil {
+		value, _, err := v.message.ContextEval(ctx, vars)
+		if err == nil {
+			if message, ok := value.Value().(string); ok && message != "" {
+				return message
+			}
+		}
+	}
+	if v.Message != "" {
+		return v.Message
+	}
+	return "Failed validation: " + v.Expression
+}
+
+// toMap converts a typed object to the map the expressions see, with the
+// apiVersion and kind typed objects leave empty.
+func toMap(obj runtime.Object, kindName string) (map[string]any, error) {
+	object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	if kind, ok := client.LookupKind(kindName); ok {
+		object["apiVersion"] = kind.APIVersion()
+		object["kind"] = kind.Kind
+	}
+	return object, nil
+}
+
+// evalBool evaluates a boolean expression.
+func evalBool(ctx context.Context, program cel.Program, vars map[string]any) (bool, error) {
+	value, _, err := program.ContextEval(ctx, vars)
+	if err != nil {
+		return false, err
+	}
+	result, ok := value.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("expression returned %s, not bool", value.Type())
+	}
+	return result, nil
+}
+
+func metaOf(obj runtime.Object) (metav1.Object, error) {
+	meta, ok := obj.(metav1.Object)
+	if !ok {
+		return nil, fmt.Errorf("%T has no object metadata", obj)
+	}
+	return meta, nil
+}
+
+func permission(kind client.Kind) checks.Permission {
+	return checks.Permission{Verbs: []string{"list"}, Resource: kind.Resource, Group: kind.Version.Group}
+}
+
+func containsPermission(permissions []checks.Permission, want checks.Permission) bool {
+	for _, p := range permissions {
+		if p.String() == want.String() {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
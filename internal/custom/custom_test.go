@@ -0,0 +1,231 @@
+package custom
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"kdebug/internal/checks"
+	"kdebug/internal/client"
+	"kdebug/internal/output"
+)
+
+const pdbChecks = `
+checks:
+  - id: ORG-DEPLOYMENT-PDB
+    name: PodDisruptionBudget Coverage
+    description: Production Deployments are covered by a PodDisruptionBudget
+    category: availability
+    severity: high
+    target:
+      kind: Deployment
+      namespaces: ["prod-*"]
+    related:
+      pdbs:
+        kind: PodDisruptionBudget
+    when: object.spec.replicas > 1
+    validations:
+      - expression: >-
+          related.pdbs.exists(p, has(p.spec.selector.matchLabels) &&
+            p.spec.selector.matchLabels.all(k, object.spec.template.metadata.labels[?k] == optional.of(p.spec.selector.matchLabels[k])))
+        messageExpression: "'Deployment ' + object.metadata.name + ' has no PodDisruptionBudget'"
+    suggestion: Create a PodDisruptionBudget selecting the Deployment's pods
+  - id: ORG-SERVICE-TEAM
+    target:
+      kind: service
+    failureStatus: WARNING
+    validations:
+      - expression: "'team' in object.metadata.?labels.orValue({})"
+        message: Service has no team label
+`
+
+func writeChecks(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func deployment(namespace, name string, replicas int32, labels map[string]string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: labels}},
+		},
+	}
+}
+
+func pdb(namespace, name string, selector map[string]string) *policyv1.PodDisruptionBudget {
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: selector}},
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	writeChecks(t, dir, "org.yaml", pdbChecks)
+	writeChecks(t, dir, "README.md", "not a check file")
+
+	loaded, err := Load([]string{dir})
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if len(loaded) != 2 {
+		t.Fatalf("Expected 2 checks, got %d", len(loaded))
+	}
+
+	pdbCheck := loaded[0]
+	if pdbCheck.Command != "cluster" || pdbCheck.Alias != "org-deployment-pdb" || pdbCheck.Severity != output.SeverityHigh ||
+		pdbCheck.Category != checks.CategoryAvailability || !pdbCheck.DefaultEnabled {
+		t.Errorf("Unexpected registered check: %+v", pdbCheck.Check)
+	}
+	if len(pdbCheck.Permissions) != 2 || pdbCheck.Permissions[1].String() != "list poddisruptionbudgets.policy" {
+		t.Errorf("Expected list permissions for the target and related kinds, got %v", pdbCheck.Permissions)
+	}
+
+	serviceCheck := loaded[1]
+	if serviceCheck.Command != "service" || serviceCheck.Name != "ORG-SERVICE-TEAM" || serviceCheck.Category != checks.CategoryConfiguration ||
+		serviceCheck.Severity != output.SeverityMedium {
+		t.Errorf("Expected defaults for the service check, got %+v", serviceCheck.Check)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	valid := "    target: {kind: Deployment}\n    validations:\n      - expression: 'true'\n"
+	tests := map[string]string{
+		"unknown field":      "checks:\n  - id: ORG-A\n    targte: {kind: Pod}\n",
+		"lower-case id":      "checks:\n  - id: org-a\n" + valid,
+		"unsupported kind":   "checks:\n  - id: ORG-A\n    target: {kind: Secret}\n    validations:\n      - expression: 'true'\n",
+		"unknown severity":   "checks:\n  - id: ORG-A\n    severity: urgent\n" + valid,
+		"unknown category":   "checks:\n  - id: ORG-A\n    category: style\n" + valid,
+		"failure status":     "checks:\n  - id: ORG-A\n    failureStatus: SKIPPED\n" + valid,
+		"no validations":     "checks:\n  - id: ORG-A\n    target: {kind: Deployment}\n",
+		"syntax error":       "checks:\n  - id: ORG-A\n    target: {kind: Pod}\n    validations:\n      - expression: 'object.spec.'\n",
+		"not a condition":    "checks:\n  - id: ORG-A\n    target: {kind: Pod}\n    validations:\n      - expression: '1 + 2'\n",
+		"message not string": "checks:\n  - id: ORG-A\n    target: {kind: Pod}\n    validations:\n      - expression: 'true'\n        messageExpression: '42'\n",
+		"invalid selector":   "checks:\n  - id: ORG-A\n    target: {kind: Pod, labelSelector: 'a in (b'}\n    validations:\n      - expression: 'true'\n",
+		"related kind":       "checks:\n  - id: ORG-A\n    related:\n      x: {kind: Widget}\n" + valid,
+		"duplicate id":       "checks:\n  - id: ORG-A\n" + valid + "  - id: ORG-A\n" + valid,
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := writeChecks(t, t.TempDir(), "checks.yaml", content)
+			_, err := Load([]string{path})
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			if !strings.Contains(err.Error(), path) {
+				t.Errorf("Expected the error to name the file, got %v", err)
+			}
+		})
+	}
+}
+
+func TestEvaluateAll(t *testing.T) {
+	loaded, err := Load([]string{writeChecks(t, t.TempDir(), "org.yaml", pdbChecks)})
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+
+	clientset := fake.NewClientset(
+		deployment("prod-eu", "web", 3, map[string]string{"app": "web"}),
+		deployment("prod-eu", "api", 2, map[string]string{"app": "api"}),
+		deployment("prod-eu", "cron", 1, map[string]string{"app": "cron"}),
+		deployment("staging", "web", 3, map[string]string{"app": "web"}),
+		pdb("prod-eu", "web", map[string]string{"app": "web"}),
+		pdb("prod-us", "api", map[string]string{"app": "api"}),
+	)
+	cache := client.NewCache(clientset, 0)
+
+	results := loaded[0].EvaluateAll(context.Background(), cache)
+	if len(results) != 2 {
+		t.Fatalf("Expected results for the replicated production Deployments only, got %+v", results)
+	}
+
+	byName := make(map[string]output.CheckResult)
+	for _, result := range results {
+		byName[result.Resource.Name] = result
+	}
+	if web := byName["web"]; web.Status != output.StatusPassed || web.Resource.Kind != "Deployment" || web.Resource.Namespace != "prod-eu" {
+		t.Errorf("Expected web to pass, got %+v", web)
+	}
+	api := byName["api"]
+	if api.Status != output.StatusFailed || api.Message != "Deployment api has no PodDisruptionBudget" ||
+		api.Suggestion != "Create a PodDisruptionBudget selecting the Deployment's pods" {
+		t.Errorf("Expected api to fail with the message expression, got %+v", api)
+	}
+}
+
+func TestRunnables(t *testing.T) {
+	loaded, err := Load([]string{writeChecks(t, t.TempDir(), "org.yaml", pdbChecks)})
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+
+	cache := client.NewCache(fake.NewClientset(), 0)
+	runnables := Runnables(loaded, "service", func(*corev1.Service) Lister { return cache },
+		func(service *corev1.Service) runtime.Object { return service })
+	if len(runnables) != 1 || runnables[0].ID != "ORG-SERVICE-TEAM" {
+		t.Fatalf("Expected the service check only, got %+v", runnables)
+	}
+
+	registry := &checks.Registry{}
+	if err := Register(registry, loaded); err != nil {
+		t.Fatalf("Register() unexpected error: %v", err)
+	}
+	if err := Register(registry, loaded[:1]); err == nil {
+		t.Error("Expected registering a check twice to fail")
+	}
+	selection, err := registry.Select("service", nil, nil)
+	if err != nil {
+		t.Fatalf("Select() unexpected error: %v", err)
+	}
+
+	unlabelled := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}}
+	results := checks.Run(context.Background(), runnables, selection, unlabelled)
+	if len(results) != 1 || results[0].Status != output.StatusWarning || results[0].ID != "ORG-SERVICE-TEAM" ||
+		results[0].Severity != output.SeverityLow || results[0].Message != "Service has no team label" {
+		t.Errorf("Expected a stamped warning, got %+v", results)
+	}
+
+	labelled := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Labels: map[string]string{"team": "shop"}}}
+	results = checks.Run(context.Background(), runnables, selection, labelled)
+	if len(results) != 1 || results[0].Status != output.StatusPassed {
+		t.Errorf("Expected the labelled service to pass, got %+v", results)
+	}
+}
+
+func TestEvaluateError(t *testing.T) {
+	content := `
+checks:
+  - id: ORG-REPLICAS
+    target: {kind: Deployment}
+    validations:
+      - expression: object.spec.paused == false
+`
+	loaded, err := Load([]string{writeChecks(t, t.TempDir(), "org.yaml", content)})
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+
+	cache := client.NewCache(fake.NewClientset(), 0)
+	results := loaded[0].Evaluate(context.Background(), cache, deployment("default", "web", 1, nil))
+	if len(results) != 1 || results[0].Status != output.StatusSkipped || results[0].Error == "" ||
+		results[0].Details["expression"] != "object.spec.paused == false" {
+		t.Errorf("Expected a missing field to skip the result with the error, got %+v", results)
+	}
+}
@@ -0,0 +1,314 @@
+// Package diff compares two saved kdebug reports, for example taken before
+// and after a rollout, and reports the findings that appeared, were resolved
+// or changed between them.
+//
+// Results are matched by the context they were diagnosed in, their check ID,
+// the resource they are about and their name, since a check may report
+// several results for the same resource. Resources with generated names, such
+// as the pods of a Deployment, are matched by their controller, so that a
+// rollout alone does not turn every finding into a new one. Results sharing a
+// key, such as the replicas of a Deployment, are paired with a result of the
+// same status first, so that a rollout reordering them is not a change.
+package diff
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+
+	"kdebug/internal/output"
+)
+
+// Result is a check result with the report it was taken from.
+type Result struct {
+	output.CheckResult `yaml:",inline"`
+
+	// Context is the kubeconfig context of a multi-context report
+	Context string `json:"context,omitempty" yaml:"context,omitempty"`
+
+	// Target is the target of the report the result belongs to
+	Target string `json:"target" yaml:"target"`
+}
+
+// key identifies a result across reports.
+func (r Result) key() string {
+	id := r.ID
+	if id == "" {
+		id = r.Name
+	}
+
+	resource := r.Target
+	switch ref := r.Resource; {
+	case ref != nil && ref.Controller != nil:
+		// Generated names change with every rollout; the controller does not
+		resource = ref.Kind + "/" + ref.Namespace + "/" + ref.Controller.Kind + "/" + ref.Controller.Name
+	case ref != nil:
+		resource = ref.Kind + "/" + ref.Namespace + "/" + ref.Name
+	}
+
+	return r.Context + "|" + id + "|" + resource + "|" + r.Name
+}
+
+// document accepts every JSON and YAML document kdebug prints: a single
+// report, a report set or a multi-context report.
+type document struct {
+	output.DiagnosticReport
+
+	Reports  []*output.DiagnosticReport       `json:"reports"`
+	Contexts map[string]*output.ContextResult `json:"contexts"`
+}
+
+// Load reads the check results of a report saved with -o json or -o yaml.
+func Load(path string) ([]Result, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close() // read-only
+	}()
+
+	var doc document
+	if err := utilyaml.NewYAMLOrJSONDecoder(file, 4096).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse report %s: %w", path, err)
+	}
+
+	var results []Result
+	add := func(contextName string, report *output.DiagnosticReport) {
+		if report == nil {
+			return
+		}
+		for _, check := range report.Checks {
+			results = append(results, Result{CheckResult: check, Context: contextName, Target: report.Target})
+		}
+	}
+
+	switch {
+	case len(doc.Contexts) > 0:
+		names := make([]string, 0, len(doc.Contexts))
+		for name := range doc.Contexts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			for _, report := range doc.Contexts[name].Reports {
+				add(name, report)
+			}
+		}
+	case doc.Reports != nil:
+		for _, report := range doc.Reports {
+			add("", report)
+		}
+	case doc.Checks != nil:
+		add("", &doc.DiagnosticReport)
+	default:
+		return nil, fmt.Errorf("%s is not a kdebug JSON or YAML report", path)
+	}
+
+	return results, nil
+}
+
+// DetailChange is a Details value that differs between the reports. An
+// empty side means the key was not set.
+type DetailChange struct {
+	Key    string `json:"key" yaml:"key"`
+	Before string `json:"before" yaml:"before"`
+	After  string `json:"after" yaml:"after"`
+}
+
+// Change is a result that differs between the reports.
+type Change struct {
+	Result `yaml:",inline"`
+
+	// BeforeStatus is the status in the earlier report, empty when the
+	// result was not reported
+	BeforeStatus output.CheckStatus `json:"before_status,omitempty" yaml:"before_status,omitempty"`
+
+	// AfterStatus is the status in the later report, empty when the result
+	// is no longer reported
+	AfterStatus output.CheckStatus `json:"after_status,omitempty" yaml:"after_status,omitempty"`
+
+	DetailChanges []DetailChange `json:"detail_changes,omitempty" yaml:"detail_changes,omitempty"`
+}
+
+// Summary counts the changes by kind.
+type Summary struct {
+	NewFailures   int `json:"new_failures" yaml:"new_failures"`
+	Resolved      int `json:"resolved" yaml:"resolved"`
+	StatusChanges int `json:"status_changes" yaml:"status_changes"`
+	DetailChanges int `json:"detail_changes" yaml:"detail_changes"`
+	Unchanged     int `json:"unchanged" yaml:"unchanged"`
+}
+
+// Report is the difference between two reports.
+type Report struct {
+	SchemaVersion string `json:"schema_version" yaml:"schema_version"`
+	Before        string `json:"before" yaml:"before"`
+	After         string `json:"after" yaml:"after"`
+
+	// NewFailures are results that failed in the later report but not in
+	// the earlier one, including results that were not reported before
+	NewFailures []Change `json:"new_failures" yaml:"new_failures"`
+
+	// Resolved are failures and warnings that passed in the later report or
+	// are no longer reported
+	Resolved []Change `json:"resolved" yaml:"resolved"`
+
+	// StatusChanges are the remaining status changes, such as new warnings
+	// or failures that became warnings
+	StatusChanges []Change `json:"status_changes" yaml:"status_changes"`
+
+	// DetailChanges are results with the same status but different details
+	DetailChanges []Change `json:"detail_changes" yaml:"detail_changes"`
+
+	Summary Summary `json:"summary" yaml:"summary"`
+}
+
+// Regressed reports whether the later report has new failures.
+func (r *Report) Regressed() bool {
+	return len(r.NewFailures) > 0
+}
+
+// Compare matches the results of two reports and classifies the differences.
+// Changes are listed in the order of the later report, followed by results
+// that are no longer reported.
+func Compare(before, after []Result) *Report {
+	report := &Report{
+		SchemaVersion: output.SchemaVersion,
+		NewFailures:   []Change{},
+		Resolved:      []Change{},
+		StatusChanges: []Change{},
+		DetailChanges: []Change{},
+	}
+
+	matches := pair(before, after)
+	seen := make([]bool, len(before))
+	for i, current := range after {
+		if matches[i] < 0 {
+			report.add(Change{Result: current, AfterStatus: current.Status})
+			continue
+		}
+
+		previous := before[matches[i]]
+		seen[matches[i]] = true
+		report.add(Change{
+			Result:        current,
+			BeforeStatus:  previous.Status,
+			AfterStatus:   current.Status,
+			DetailChanges: compareDetails(previous.Details, current.Details),
+		})
+	}
+
+	for i, previous := range before {
+		if !seen[i] {
+			report.add(Change{Result: previous, BeforeStatus: previous.Status})
+		}
+	}
+
+	return report
+}
+
+// add classifies a matched, new or vanished result.
+func (r *Report) add(change Change) {
+	switch {
+	case change.BeforeStatus == change.AfterStatus:
+		if len(change.DetailChanges) > 0 {
+			r.DetailChanges = append(r.DetailChanges, change)
+			r.Summary.DetailChanges++
+		} else {
+			r.Summary.Unchanged++
+		}
+	case change.AfterStatus == output.StatusFailed:
+		r.NewFailures = append(r.NewFailures, change)
+		r.Summary.NewFailures++
+	case finding(change.BeforeStatus) && (change.AfterStatus == output.StatusPassed || change.AfterStatus == ""):
+		r.Resolved = append(r.Resolved, change)
+		r.Summary.Resolved++
+	case change.BeforeStatus == "" && !finding(change.AfterStatus),
+		change.AfterStatus == "" && !finding(change.BeforeStatus):
+		// Passed or skipped checks that were added or removed, e.g. by
+		// selecting other checks, are not changes to the cluster
+		r.Summary.Unchanged++
+	default:
+		r.StatusChanges = append(r.StatusChanges, change)
+		r.Summary.StatusChanges++
+	}
+}
+
+func finding(status output.CheckStatus) bool {
+	return status == output.StatusFailed || status == output.StatusWarning
+}
+
+// pair returns the index of the earlier result each later result is matched
+// with, or -1 for results that were not reported before. Results sharing a
+// key are paired with an earlier result of the same status first and in order
+// after that, so that replicas which only swapped places between the reports
+// are not mistaken for a resolved and a new failure.
+func pair(before, after []Result) []int {
+	unmatched := make(map[string][]int, len(before))
+	for i, result := range before {
+		key := result.key()
+		unmatched[key] = append(unmatched[key], i)
+	}
+
+	keys := make([]string, len(after))
+	matches := make([]int, len(after))
+	for i, result := range after {
+		keys[i] = result.key()
+		matches[i] = -1
+	}
+
+	take := func(i int, fits func(Result) bool) {
+		candidates := unmatched[keys[i]]
+		for j, candidate := range candidates {
+			if fits(before[candidate]) {
+				matches[i] = candidate
+				unmatched[keys[i]] = append(candidates[:j:j], candidates[j+1:]...)
+				return
+			}
+		}
+	}
+
+	for i, current := range after {
+		take(i, func(previous Result) bool {
+			return previous.Status == current.Status
+		})
+	}
+	for i := range after {
+		if matches[i] < 0 {
+			take(i, func(Result) bool { return true })
+		}
+	}
+
+	return matches
+}
+
+// compareDetails returns the changed, added and removed details in key order.
+func compareDetails(before, after map[string]string) []DetailChange {
+	keys := make(map[string]bool, len(before)+len(after))
+	for key := range before {
+		keys[key] = true
+	}
+	for key := range after {
+		keys[key] = true
+	}
+
+	var changes []DetailChange
+	for key := range keys {
+		previous, hadBefore := before[key]
+		current, hasAfter := after[key]
+		if hadBefore == hasAfter && previous == current {
+			continue
+		}
+		changes = append(changes, DetailChange{Key: key, Before: previous, After: current})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+
+	return changes
+}
@@ -0,0 +1,242 @@
+package diff
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"kdebug/internal/output"
+)
+
+func podResult(id string, status output.CheckStatus, pod string, details map[string]string) Result {
+	return Result{
+		Target: "pod/" + pod,
+		CheckResult: output.CheckResult{
+			ID:       id,
+			Name:     id,
+			Status:   status,
+			Details:  details,
+			Resource: &output.ResourceRef{Kind: "Pod", Namespace: "default", Name: pod},
+		},
+	}
+}
+
+func TestCompare(t *testing.T) {
+	before := []Result{
+		podResult("POD-IMAGE-PULL", output.StatusPassed, "web", nil),
+		podResult("POD-IMAGE-PULL", output.StatusFailed, "api", nil),
+		podResult("POD-LOGS", output.StatusWarning, "api", nil),
+		podResult("POD-RESTARTS", output.StatusFailed, "web", map[string]string{"restarts": "3"}),
+		podResult("POD-NETWORK", output.StatusPassed, "web", nil),
+		podResult("POD-EXISTENCE", output.StatusFailed, "old", nil),
+	}
+	after := []Result{
+		podResult("POD-IMAGE-PULL", output.StatusFailed, "web", nil),
+		podResult("POD-IMAGE-PULL", output.StatusPassed, "api", nil),
+		podResult("POD-LOGS", output.StatusFailed, "api", nil),
+		podResult("POD-RESTARTS", output.StatusFailed, "web", map[string]string{"restarts": "5", "reason": "OOMKilled"}),
+		podResult("POD-NETWORK", output.StatusWarning, "web", nil),
+		podResult("POD-EXISTENCE", output.StatusFailed, "new", nil),
+		podResult("POD-EXISTENCE", output.StatusPassed, "other", nil),
+	}
+
+	report := Compare(before, after)
+
+	if got := report.Summary; got != (Summary{NewFailures: 3, Resolved: 2, StatusChanges: 1, DetailChanges: 1, Unchanged: 1}) {
+		t.Errorf("Unexpected summary %+v", got)
+	}
+	if !report.Regressed() {
+		t.Error("Expected new failures to be a regression")
+	}
+
+	newFailures := []string{"web", "api", "new"}
+	for i, change := range report.NewFailures {
+		if change.Resource.Name != newFailures[i] {
+			t.Errorf("Expected new failure %d on %s, got %s", i, newFailures[i], change.Resource.Name)
+		}
+	}
+	if report.NewFailures[2].BeforeStatus != "" {
+		t.Errorf("Expected no earlier status for a new result, got %s", report.NewFailures[2].BeforeStatus)
+	}
+
+	if resolved := report.Resolved[1]; resolved.Resource.Name != "old" || resolved.AfterStatus != "" {
+		t.Errorf("Expected the vanished failure on old to be resolved, got %+v", resolved)
+	}
+
+	if change := report.StatusChanges[0]; change.ID != "POD-NETWORK" || change.AfterStatus != output.StatusWarning {
+		t.Errorf("Expected the new warning as status change, got %+v", change)
+	}
+
+	details := report.DetailChanges[0].DetailChanges
+	want := []DetailChange{{Key: "reason", After: "OOMKilled"}, {Key: "restarts", Before: "3", After: "5"}}
+	if len(details) != len(want) {
+		t.Fatalf("Expected %d detail changes, got %+v", len(want), details)
+	}
+	for i := range want {
+		if details[i] != want[i] {
+			t.Errorf("Expected detail change %+v, got %+v", want[i], details[i])
+		}
+	}
+}
+
+func TestCompareMatchesByContext(t *testing.T) {
+	eu := podResult("POD-IMAGE-PULL", output.StatusFailed, "web", nil)
+	eu.Context = "prod-eu"
+	us := podResult("POD-IMAGE-PULL", output.StatusPassed, "web", nil)
+	us.Context = "prod-us"
+
+	usFailing := us
+	usFailing.Status = output.StatusFailed
+
+	report := Compare([]Result{eu, us}, []Result{eu, usFailing})
+	if len(report.NewFailures) != 1 || report.NewFailures[0].Context != "prod-us" {
+		t.Errorf("Expected a new failure in prod-us only, got %+v", report.NewFailures)
+	}
+}
+
+func TestCompareWithoutChanges(t *testing.T) {
+	results := []Result{podResult("POD-IMAGE-PULL", output.StatusFailed, "web", nil)}
+
+	report := Compare(results, results)
+	if report.Regressed() {
+		t.Error("Expected an existing failure not to be a regression")
+	}
+	if report.Summary.Unchanged != 1 {
+		t.Errorf("Expected 1 unchanged result, got %d", report.Summary.Unchanged)
+	}
+}
+
+func TestCompareMatchesRolledOutPodsByController(t *testing.T) {
+	deploymentPod := func(status output.CheckStatus, pod string) Result {
+		result := podResult("POD-IMAGE-PULL", status, pod, nil)
+		result.Resource.Controller = &output.ControllerRef{Kind: "Deployment", Name: "web"}
+		return result
+	}
+
+	before := []Result{
+		deploymentPod(output.StatusFailed, "web-7d4b8c6f9-abcde"),
+		deploymentPod(output.StatusPassed, "web-7d4b8c6f9-fghij"),
+	}
+	after := []Result{
+		deploymentPod(output.StatusFailed, "web-5f6c7d8e9-klmno"),
+		deploymentPod(output.StatusPassed, "web-5f6c7d8e9-pqrst"),
+	}
+
+	report := Compare(before, after)
+	if report.Regressed() || len(report.Resolved) != 0 {
+		t.Errorf("Expected renamed pods not to change the findings, got %+v", report)
+	}
+	if report.Summary.Unchanged != 2 {
+		t.Errorf("Expected 2 unchanged results, got %+v", report.Summary)
+	}
+}
+
+func TestComparePairsReplicasByStatus(t *testing.T) {
+	deploymentPod := func(status output.CheckStatus, pod string) Result {
+		result := podResult("POD-IMAGE-PULL", status, pod, nil)
+		result.Resource.Controller = &output.ControllerRef{Kind: "Deployment", Name: "web"}
+		return result
+	}
+
+	// The rollout lists the failing replica last
+	before := []Result{
+		deploymentPod(output.StatusFailed, "web-7d4b8c6f9-abcde"),
+		deploymentPod(output.StatusPassed, "web-7d4b8c6f9-fghij"),
+		deploymentPod(output.StatusPassed, "web-7d4b8c6f9-uvwxy"),
+	}
+	after := []Result{
+		deploymentPod(output.StatusPassed, "web-5f6c7d8e9-klmno"),
+		deploymentPod(output.StatusPassed, "web-5f6c7d8e9-pqrst"),
+		deploymentPod(output.StatusFailed, "web-5f6c7d8e9-zabcd"),
+	}
+
+	report := Compare(before, after)
+	if report.Regressed() || len(report.Resolved) != 0 {
+		t.Errorf("Expected reordered replicas not to change the findings, got %+v", report)
+	}
+	if report.Summary.Unchanged != 3 {
+		t.Errorf("Expected 3 unchanged results, got %+v", report.Summary)
+	}
+
+	// A replica that started failing is still a new failure
+	after[0] = deploymentPod(output.StatusFailed, "web-5f6c7d8e9-klmno")
+	report = Compare(before, after)
+	if len(report.NewFailures) != 1 || report.NewFailures[0].BeforeStatus != output.StatusPassed {
+		t.Errorf("Expected one replica to fail anew, got %+v", report.NewFailures)
+	}
+	if report.Summary.Unchanged != 2 {
+		t.Errorf("Expected 2 unchanged results, got %+v", report.Summary)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		want     int
+		contexts []string
+	}{
+		{
+			name:    "JSON report",
+			content: `{"target": "pod/web", "checks": [{"id": "POD-EXISTENCE", "name": "Pod Existence", "status": "PASSED"}]}`,
+			want:    1,
+		},
+		{
+			name: "YAML report set",
+			content: `schema_version: v1
+reports:
+  - target: pod/web
+    checks:
+      - id: POD-EXISTENCE
+        status: PASSED
+  - target: pod/api
+    checks:
+      - id: POD-EXISTENCE
+        status: FAILED
+`,
+			want: 2,
+		},
+		{
+			name:     "JSON multi-context report",
+			content:  `{"contexts": {"prod-us": {"reports": [{"target": "cluster", "checks": [{"id": "CLUSTER-NODES", "status": "PASSED"}]}]}, "prod-eu": {"error": "unreachable"}}}`,
+			want:     1,
+			contexts: []string{"prod-us"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "report")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			results, err := Load(path)
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			if len(results) != tt.want {
+				t.Fatalf("Expected %d results, got %d", tt.want, len(results))
+			}
+			if results[0].Target == "" {
+				t.Error("Expected results to carry the report target")
+			}
+			for i, name := range tt.contexts {
+				if results[i].Context != name {
+					t.Errorf("Expected context %s, got %s", name, results[i].Context)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadRejectsOtherDocuments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "other.json")
+	if err := os.WriteFile(path, []byte(`{"apiVersion": "v1", "kind": "Pod"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(path); err == nil {
+		t.Error("Expected an error for a document that is not a kdebug report")
+	}
+}
@@ -0,0 +1,130 @@
+// Package exitcode defines the process exit codes of kdebug and the --fail-on
+// policy that maps diagnostic findings to them.
+package exitcode
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"kdebug/internal/output"
+)
+
+// Exit codes returned by every kdebug command.
+const (
+	// OK means the command ran and found nothing at or above the --fail-on level
+	OK = 0
+
+	// Error is an unexpected runtime error
+	Error = 1
+
+	// Warnings means the highest finding was a warning and --fail-on=warning
+	Warnings = 2
+
+	// Failures means at least one check failed and --fail-on is failed or warning
+	Failures = 3
+
+	// Usage is an invalid flag, argument or configuration
+	Usage = 4
+
+	// Connectivity means the Kubernetes API server could not be reached
+	Connectivity = 5
+)
+
+// ExitError is an error carrying the exit code the process should end with.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// UsageError marks an error as an invalid flag, argument or configuration.
+func UsageError(err error) error {
+	return wrap(Usage, err)
+}
+
+// ConnectivityError marks an error as a failure to reach the API server.
+func ConnectivityError(err error) error {
+	return wrap(Connectivity, err)
+}
+
+func wrap(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return err
+	}
+
+	return &ExitError{Code: code, Err: err}
+}
+
+// Code returns the exit code for an error returned by a command: OK for nil,
+// the carried code for an ExitError and Error otherwise.
+func Code(err error) int {
+	if err == nil {
+		return OK
+	}
+
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+
+	return Error
+}
+
+// FailOn is the lowest finding level that makes a command exit non-zero.
+type FailOn string
+
+const (
+	FailOnNever   FailOn = "never"
+	FailOnFailed  FailOn = "failed"
+	FailOnWarning FailOn = "warning"
+)
+
+// ParseFailOn validates a --fail-on value.
+func ParseFailOn(value string) (FailOn, error) {
+	switch policy := FailOn(strings.ToLower(value)); policy {
+	case FailOnNever, FailOnFailed, FailOnWarning:
+		return policy, nil
+	default:
+		return "", UsageError(fmt.Errorf("invalid --fail-on value %q: must be one of never, failed, warning", value))
+	}
+}
+
+// Check returns an ExitError when the findings in summary reach the policy's
+// level, and nil otherwise.
+func (f FailOn) Check(summary output.Summary) error {
+	switch {
+	case f == FailOnNever:
+		return nil
+	case summary.Failed > 0:
+		return &ExitError{Code: Failures, Err: fmt.Errorf("%d checks failed (--fail-on=%s)", summary.Failed, f)}
+	case f == FailOnWarning && summary.Warnings > 0:
+		return &ExitError{Code: Warnings, Err: fmt.Errorf("%d checks reported warnings (--fail-on=%s)", summary.Warnings, f)}
+	default:
+		return nil
+	}
+}
+
+// CheckReports applies the policy to the combined findings of several reports.
+func (f FailOn) CheckReports(reports []*output.DiagnosticReport) error {
+	var summary output.Summary
+	for _, report := range reports {
+		if report != nil {
+			summary.Add(report.Summary)
+		}
+	}
+
+	return f.Check(summary)
+}
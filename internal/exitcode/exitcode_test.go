@@ -0,0 +1,91 @@
+package exitcode
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"kdebug/internal/output"
+)
+
+func TestCode(t *testing.T) {
+	base := errors.New("boom")
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, OK},
+		{"plain error", base, Error},
+		{"usage error", UsageError(base), Usage},
+		{"connectivity error", ConnectivityError(base), Connectivity},
+		{"wrapped exit error", fmt.Errorf("context: %w", ConnectivityError(base)), Connectivity},
+		{"first code wins", UsageError(ConnectivityError(base)), Connectivity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Code(tt.err); got != tt.want {
+				t.Errorf("Code() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	if UsageError(nil) != nil {
+		t.Error("Expected wrapping nil to return nil")
+	}
+}
+
+func TestParseFailOn(t *testing.T) {
+	for _, value := range []string{"never", "failed", "WARNING"} {
+		if _, err := ParseFailOn(value); err != nil {
+			t.Errorf("ParseFailOn(%q) unexpected error: %v", value, err)
+		}
+	}
+
+	_, err := ParseFailOn("critical")
+	if Code(err) != Usage {
+		t.Errorf("Expected a usage error for an invalid value, got %v", err)
+	}
+}
+
+func TestFailOnCheck(t *testing.T) {
+	clean := output.Summary{Total: 2, Passed: 2}
+	warnings := output.Summary{Total: 2, Passed: 1, Warnings: 1}
+	failures := output.Summary{Total: 3, Passed: 1, Warnings: 1, Failed: 1}
+
+	tests := []struct {
+		policy  FailOn
+		summary output.Summary
+		want    int
+	}{
+		{FailOnNever, failures, OK},
+		{FailOnFailed, clean, OK},
+		{FailOnFailed, warnings, OK},
+		{FailOnFailed, failures, Failures},
+		{FailOnWarning, clean, OK},
+		{FailOnWarning, warnings, Warnings},
+		{FailOnWarning, failures, Failures},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%+v", tt.policy, tt.summary), func(t *testing.T) {
+			if got := Code(tt.policy.Check(tt.summary)); got != tt.want {
+				t.Errorf("Check() exit code = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFailOnCheckReports(t *testing.T) {
+	reports := []*output.DiagnosticReport{
+		{Summary: output.Summary{Total: 1, Passed: 1}},
+		nil,
+		{Summary: output.Summary{Total: 1, Warnings: 1}},
+	}
+
+	if got := Code(FailOnWarning.CheckReports(reports)); got != Warnings {
+		t.Errorf("Expected findings of all reports to be combined, got exit code %d", got)
+	}
+}
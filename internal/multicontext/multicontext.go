@@ -0,0 +1,86 @@
+// Package multicontext runs a diagnostic command against several kubeconfig
+// contexts concurrently and combines the results into a single report.
+package multicontext
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"kdebug/internal/client"
+	"kdebug/internal/output"
+)
+
+// ClientFactory creates a client for a named kubeconfig context.
+type ClientFactory func(contextName string) (*client.KubernetesClient, error)
+
+// DiagnoseFunc runs a command's diagnostics against a single cluster,
+// printing messages and streaming results through outputMgr.
+type DiagnoseFunc func(ctx context.Context, k8sClient *client.KubernetesClient, outputMgr *output.OutputManager) ([]*output.DiagnosticReport, error)
+
+// Run diagnoses every context concurrently. A context that cannot be reached
+// or diagnosed is recorded with its error instead of failing the whole run.
+// Each context is diagnosed with an output manager tagged with its name.
+func Run(ctx context.Context, target string, contexts []string, outputMgr *output.OutputManager, newClient ClientFactory, diagnose DiagnoseFunc) *output.MultiContextReport {
+	report := &output.MultiContextReport{
+		SchemaVersion: output.SchemaVersion,
+		Target:        target,
+		Timestamp:     time.Now().Format(time.RFC3339),
+		Contexts:      make(map[string]*output.ContextResult, len(contexts)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+
+	for _, contextName := range contexts {
+		wg.Add(1)
+		go func(contextName string) {
+			defer wg.Done()
+
+			reports, err := runContext(ctx, contextName, outputMgr.ForContext(contextName), newClient, diagnose)
+			result := output.NewContextResult(reports, err)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Contexts[contextName] = result
+		}(contextName)
+	}
+
+	wg.Wait()
+
+	for _, result := range report.Contexts {
+		report.Summary.Add(result.Summary)
+	}
+
+	return report
+}
+
+// runContext connects to a single context and runs the diagnostics.
+func runContext(ctx context.Context, contextName string, outputMgr *output.OutputManager, newClient ClientFactory, diagnose DiagnoseFunc) ([]*output.DiagnosticReport, error) {
+	k8sClient, err := newClient(contextName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	if err := k8sClient.TestConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	reports, err := diagnose(ctx, k8sClient, outputMgr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tag every report with the context it was produced for
+	for _, report := range reports {
+		if report.ClusterInfo == nil {
+			report.ClusterInfo = make(map[string]string)
+		}
+		report.ClusterInfo["context"] = contextName
+	}
+
+	return reports, nil
+}
@@ -0,0 +1,137 @@
+package multicontext
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"kdebug/internal/client"
+	"kdebug/internal/output"
+)
+
+func newFakeClient(contextName string, objects ...corev1.Node) *client.KubernetesClient {
+	clientset := fake.NewClientset()
+	for i := range objects {
+		_ = clientset.Tracker().Add(&objects[i])
+	}
+	clientset.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{GitVersion: "v1.30.0"}
+
+	return client.NewKubernetesClientFromClientset(clientset, nil, contextName)
+}
+
+// countNodes reports one passed check per node and fails on clusters without nodes.
+func countNodes(ctx context.Context, k8sClient *client.KubernetesClient, _ *output.OutputManager) ([]*output.DiagnosticReport, error) {
+	nodes, err := k8sClient.Clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &output.DiagnosticReport{Target: "cluster"}
+	for _, node := range nodes.Items {
+		report.Checks = append(report.Checks, output.CheckResult{Name: node.Name, Status: output.StatusPassed})
+		report.Summary.Total++
+		report.Summary.Passed++
+	}
+	if len(nodes.Items) == 0 {
+		report.Checks = append(report.Checks, output.CheckResult{Name: "Nodes", Status: output.StatusFailed})
+		report.Summary.Total++
+		report.Summary.Failed++
+	}
+
+	return []*output.DiagnosticReport{report}, nil
+}
+
+func TestRun(t *testing.T) {
+	clusters := map[string]*client.KubernetesClient{
+		"prod-eu": newFakeClient("prod-eu",
+			corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "eu-1"}},
+			corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "eu-2"}}),
+		"prod-us": newFakeClient("prod-us"),
+	}
+
+	newClient := func(contextName string) (*client.KubernetesClient, error) {
+		if k8sClient, ok := clusters[contextName]; ok {
+			return k8sClient, nil
+		}
+		return nil, errors.New("context not found")
+	}
+
+	report := Run(context.Background(), "cluster", []string{"prod-eu", "prod-us", "staging"}, output.NewOutputManager("json", false), newClient, countNodes)
+
+	if len(report.Contexts) != 3 {
+		t.Fatalf("Expected 3 contexts, got %d", len(report.Contexts))
+	}
+
+	eu := report.Contexts["prod-eu"]
+	if eu.Error != "" || eu.Summary.Passed != 2 {
+		t.Errorf("Expected 2 passed checks for prod-eu, got %+v", eu)
+	}
+	if eu.Reports[0].ClusterInfo["context"] != "prod-eu" {
+		t.Errorf("Expected report to be tagged with its context, got %v", eu.Reports[0].ClusterInfo)
+	}
+
+	if us := report.Contexts["prod-us"]; us.Summary.Failed != 1 {
+		t.Errorf("Expected 1 failed check for prod-us, got %+v", us.Summary)
+	}
+
+	if staging := report.Contexts["staging"]; staging.Error == "" || len(staging.Reports) != 0 {
+		t.Errorf("Expected staging to carry an error, got %+v", staging)
+	}
+
+	if report.Summary.Total != 3 || report.Summary.Passed != 2 || report.Summary.Failed != 1 {
+		t.Errorf("Unexpected combined summary: %+v", report.Summary)
+	}
+	if report.Errors() != 1 {
+		t.Errorf("Expected 1 context error, got %d", report.Errors())
+	}
+
+	names := report.ContextNames()
+	if len(names) != 3 || names[0] != "prod-eu" || names[2] != "staging" {
+		t.Errorf("Expected sorted context names, got %v", names)
+	}
+}
+
+func TestRunDiagnoseError(t *testing.T) {
+	newClient := func(contextName string) (*client.KubernetesClient, error) {
+		return newFakeClient(contextName), nil
+	}
+	diagnose := func(ctx context.Context, k8sClient *client.KubernetesClient, _ *output.OutputManager) ([]*output.DiagnosticReport, error) {
+		return nil, errors.New("pod not found")
+	}
+
+	report := Run(context.Background(), "pod/web", []string{"a"}, output.NewOutputManager("json", false), newClient, diagnose)
+
+	if report.Contexts["a"].Error != "pod not found" {
+		t.Errorf("Expected diagnose error to be recorded, got %q", report.Contexts["a"].Error)
+	}
+}
+
+func TestRunTagsOutputWithContext(t *testing.T) {
+	newClient := func(contextName string) (*client.KubernetesClient, error) {
+		return newFakeClient(contextName), nil
+	}
+
+	var (
+		mu   sync.Mutex
+		seen = make(map[string]string)
+	)
+	diagnose := func(ctx context.Context, k8sClient *client.KubernetesClient, outputMgr *output.OutputManager) ([]*output.DiagnosticReport, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		seen[k8sClient.Context] = outputMgr.Context
+		return nil, nil
+	}
+
+	Run(context.Background(), "cluster", []string{"a", "b"}, output.NewOutputManager("ndjson", false), newClient, diagnose)
+
+	if seen["a"] != "a" || seen["b"] != "b" {
+		t.Errorf("Expected each context diagnosed with an output manager tagged with its name, got %v", seen)
+	}
+}
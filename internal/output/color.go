@@ -0,0 +1,96 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// ANSI color codes for terminal output
+const (
+	ColorReset  = "\033[0m"
+	ColorRed    = "\033[31m"
+	ColorGreen  = "\033[32m"
+	ColorYellow = "\033[33m"
+	ColorBlue   = "\033[34m"
+	ColorPurple = "\033[35m"
+	ColorCyan   = "\033[36m"
+	ColorWhite  = "\033[37m"
+	ColorBold   = "\033[1m"
+	ColorDim    = "\033[2m"
+)
+
+// ColorMode controls when output is colored.
+type ColorMode string
+
+const (
+	// ColorModeAuto colors terminals unless NO_COLOR is set or TERM is dumb
+	ColorModeAuto ColorMode = "auto"
+
+	// ColorModeAlways colors output even when it is piped or NO_COLOR is set
+	ColorModeAlways ColorMode = "always"
+
+	// ColorModeNever never colors output
+	ColorModeNever ColorMode = "never"
+)
+
+// ParseColorMode parses a --color value.
+func ParseColorMode(value string) (ColorMode, error) {
+	switch mode := ColorMode(value); mode {
+	case ColorModeAuto, ColorModeAlways, ColorModeNever:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid --color value %q: must be auto, always or never", value)
+	}
+}
+
+// Enabled reports whether output written to w is colored.
+func (m ColorMode) Enabled(w io.Writer) bool {
+	switch m {
+	case ColorModeAlways:
+		return true
+	case ColorModeNever:
+		return false
+	}
+
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	file, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(file.Fd()))
+}
+
+// Terminal formatting helpers
+func (o *OutputManager) colorize(text, color string) string {
+	if !o.Color {
+		return text
+	}
+	return color + text + ColorReset
+}
+
+func (o *OutputManager) bold(text string) string {
+	return o.colorize(text, ColorBold)
+}
+
+func (o *OutputManager) dim(text string) string {
+	return o.colorize(text, ColorDim)
+}
+
+// Icon returns an emoji followed by a space to start a line with, or
+// nothing in ASCII mode.
+func (o *OutputManager) Icon(emoji string) string {
+	if o.ASCII {
+		return ""
+	}
+	return emoji + " "
+}
+
+// rule returns the character separators are drawn with.
+func (o *OutputManager) rule() string {
+	if o.ASCII {
+		return "-"
+	}
+	return "─"
+}
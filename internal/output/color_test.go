@@ -0,0 +1,91 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseColorMode(t *testing.T) {
+	for _, value := range []string{"auto", "always", "never"} {
+		if mode, err := ParseColorMode(value); err != nil || string(mode) != value {
+			t.Errorf("ParseColorMode(%q) = %q, %v", value, mode, err)
+		}
+	}
+
+	if _, err := ParseColorMode("sometimes"); err == nil {
+		t.Error("Expected an error for an unknown mode")
+	}
+}
+
+func TestColorModeEnabled(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("TERM", "xterm")
+
+	var buf bytes.Buffer
+	if ColorModeAuto.Enabled(&buf) {
+		t.Error("Expected no colors for a writer that is not a terminal")
+	}
+	if !ColorModeAlways.Enabled(&buf) {
+		t.Error("Expected --color=always to color any writer")
+	}
+	if ColorModeNever.Enabled(&buf) {
+		t.Error("Expected --color=never to disable colors")
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if !ColorModeAlways.Enabled(&buf) {
+		t.Error("Expected --color=always to override NO_COLOR")
+	}
+}
+
+func TestDiagnosticReport_TableColor(t *testing.T) {
+	om := NewOutputManager("table", false)
+
+	om.Color = true
+	colored := render(t, om, func() error { return om.PrintReport(createTestReport()) })
+	if !strings.Contains(colored, ColorRed+"FAILED"+ColorReset) {
+		t.Errorf("Expected colored statuses, got:\n%s", colored)
+	}
+
+	om.Color = false
+	plain := render(t, om, func() error { return om.PrintReport(createTestReport()) })
+	if strings.Contains(plain, "\033[") {
+		t.Errorf("Expected no ANSI codes without colors, got:\n%s", plain)
+	}
+}
+
+func TestPrintReports_ASCII(t *testing.T) {
+	om := NewOutputManager("table", false)
+	reports := []*DiagnosticReport{createTestReport(), createTestReport()}
+
+	om.ASCII = false
+	if out := render(t, om, func() error { return om.PrintReports(reports) }); !strings.Contains(out, "────") {
+		t.Errorf("Expected a box-drawing separator, got:\n%s", out)
+	}
+
+	om.ASCII = true
+	out := render(t, om, func() error { return om.PrintReports(reports) })
+	if !strings.Contains(out, strings.Repeat("-", 80)) {
+		t.Errorf("Expected an ASCII separator, got:\n%s", out)
+	}
+	for _, r := range out {
+		if r > 127 {
+			t.Fatalf("Expected only ASCII in ASCII mode, found %q in:\n%s", r, out)
+		}
+	}
+}
+
+func TestIcon(t *testing.T) {
+	om := NewOutputManager("table", false)
+
+	om.ASCII = false
+	if got := om.Icon("📊"); got != "📊 " {
+		t.Errorf("Icon() = %q, want the emoji and a space", got)
+	}
+
+	om.ASCII = true
+	if got := om.Icon("📊"); got != "" {
+		t.Errorf("Icon() = %q in ASCII mode, want nothing", got)
+	}
+}
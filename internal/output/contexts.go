@@ -0,0 +1,205 @@
+package output
+
+import (
+	"fmt"
+	"sort"
+	"text/tabwriter"
+)
+
+// MultiContextReport combines the results of running a command against
+// several kubeconfig contexts.
+type MultiContextReport struct {
+	SchemaVersion string                    `json:"schema_version" yaml:"schema_version"`
+	Target        string                    `json:"target" yaml:"target"`
+	Timestamp     string                    `json:"timestamp" yaml:"timestamp"`
+	Contexts      map[string]*ContextResult `json:"contexts" yaml:"contexts"`
+	Summary       Summary                   `json:"summary" yaml:"summary"`
+}
+
+// ContextResult holds the reports produced for a single context, or the
+// error that prevented diagnosing it.
+type ContextResult struct {
+	Reports []*DiagnosticReport `json:"reports,omitempty" yaml:"reports,omitempty"`
+	Summary Summary             `json:"summary" yaml:"summary"`
+	Error   string              `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// NewContextResult builds a context result and totals its reports.
+func NewContextResult(reports []*DiagnosticReport, err error) *ContextResult {
+	result := &ContextResult{Reports: reports}
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	for _, report := range reports {
+		result.Summary.Add(report.Summary)
+	}
+
+	return result
+}
+
+// ContextNames returns the context names in alphabetical order.
+func (m *MultiContextReport) ContextNames() []string {
+	names := make([]string, 0, len(m.Contexts))
+	for name := range m.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Errors returns the number of contexts that could not be diagnosed.
+func (m *MultiContextReport) Errors() int {
+	errors := 0
+	for _, result := range m.Contexts {
+		if result.Error != "" {
+			errors++
+		}
+	}
+
+	return errors
+}
+
+// Add accumulates another summary into this one.
+func (s *Summary) Add(other Summary) {
+	s.Total += other.Total
+	s.Passed += other.Passed
+	s.Failed += other.Failed
+	s.Warnings += other.Warnings
+	s.Skipped += other.Skipped
+	s.Suppressed += other.Suppressed
+}
+
+// PrintMultiContextReport prints a combined report in the specified format.
+// The table format prints each context's reports followed by a cross-cluster
+// summary table.
+func (o *OutputManager) PrintMultiContextReport(report *MultiContextReport) error {
+	switch o.Format {
+	case FormatJSON:
+		return o.printJSON(report)
+	case FormatYAML:
+		return o.printYAML(report)
+	case FormatSARIF:
+		runs := make(map[string][]*DiagnosticReport, len(report.Contexts))
+		for name, result := range report.Contexts {
+			runs[name] = result.Reports
+		}
+		return o.printSARIF(runs)
+	case FormatJUnit:
+		var suites []junitTestSuite
+		for _, name := range report.ContextNames() {
+			result := report.Contexts[name]
+			if result.Error != "" {
+				suites = append(suites, junitContextError(name, result.Error))
+				continue
+			}
+			suites = append(suites, o.junitSuites(name, result.Reports)...)
+		}
+		return o.printJUnit(suites)
+	case FormatHTML:
+		return o.printHTML(report.sections())
+	case FormatMarkdown:
+		return o.printMarkdown(report.sections())
+	case FormatNDJSON:
+		return o.printNDJSONContexts(report)
+	case FormatPrometheus:
+		runs := make([]prometheusRun, 0, len(report.Contexts))
+		for _, name := range report.ContextNames() {
+			result := report.Contexts[name]
+			runs = append(runs, prometheusRun{Context: name, Reports: result.Reports, Error: result.Error})
+		}
+		return o.printPrometheus(runs)
+	case FormatTemplate, FormatJSONPath:
+		return o.printCustom(report)
+	default:
+		return o.printMultiContextTable(report)
+	}
+}
+
+// sections returns the reports of every context in context order, with a
+// section for each context that could not be diagnosed.
+func (r *MultiContextReport) sections() []reportSection {
+	var sections []reportSection
+	for _, name := range r.ContextNames() {
+		result := r.Contexts[name]
+		if result.Error != "" {
+			sections = append(sections, reportSection{Context: name, Error: result.Error})
+			continue
+		}
+		for _, report := range result.Reports {
+			sections = append(sections, reportSection{Context: name, Report: report})
+		}
+	}
+	return sections
+}
+
+// printMultiContextTable prints every context section and the summary table.
+func (o *OutputManager) printMultiContextTable(report *MultiContextReport) error {
+	for _, name := range report.ContextNames() {
+		result := report.Contexts[name]
+
+		fmt.Fprintf(o.Out, "%s\n", o.bold(fmt.Sprintf("=== Context: %s ===", name)))
+		fmt.Fprintln(o.Out)
+
+		if result.Error != "" {
+			fmt.Fprintf(o.Out, "%s %s\n\n", o.colorize("ERROR:", ColorRed), result.Error)
+			continue
+		}
+
+		for _, contextReport := range result.Reports {
+			if err := o.printTable(contextReport); err != nil {
+				return err
+			}
+			fmt.Fprintln(o.Out)
+		}
+	}
+
+	fmt.Fprintf(o.Out, "%s\n", o.bold("Cross-Cluster Summary:"))
+	fmt.Fprintln(o.Out)
+
+	w := tabwriter.NewWriter(o.Out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CONTEXT\tREPORTS\tPASSED\tFAILED\tWARNINGS\tSKIPPED\tSTATUS")
+	for _, name := range report.ContextNames() {
+		result := report.Contexts[name]
+		if result.Error != "" {
+			fmt.Fprintf(w, "%s\t-\t-\t-\t-\t-\t%s\n", name, o.colorize("ERROR", ColorRed))
+			continue
+		}
+
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%s\n", name, len(result.Reports),
+			result.Summary.Passed, result.Summary.Failed, result.Summary.Warnings, result.Summary.Skipped,
+			o.formatStatusClean(contextStatus(result)))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(o.Out)
+	summary := report.Summary
+	fmt.Fprintf(o.Out, "%d contexts: %d passed, %d failed, %d warnings, %d skipped",
+		len(report.Contexts), summary.Passed, summary.Failed, summary.Warnings, summary.Skipped)
+	if summary.Suppressed > 0 {
+		fmt.Fprintf(o.Out, ", %s", o.colorize(fmt.Sprintf("%d suppressed", summary.Suppressed), ColorPurple))
+	}
+	if errors := report.Errors(); errors > 0 {
+		fmt.Fprintf(o.Out, ", %s", o.colorize(fmt.Sprintf("%d unreachable", errors), ColorRed))
+	}
+	fmt.Fprintln(o.Out)
+
+	return nil
+}
+
+// contextStatus returns the worst check status of a context.
+func contextStatus(result *ContextResult) CheckStatus {
+	switch {
+	case result.Summary.Failed > 0:
+		return StatusFailed
+	case result.Summary.Warnings > 0:
+		return StatusWarning
+	case result.Summary.Passed == 0 && result.Summary.Skipped > 0:
+		return StatusSkipped
+	default:
+		return StatusPassed
+	}
+}
@@ -0,0 +1,103 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func createTestMultiContextReport() *MultiContextReport {
+	report := &MultiContextReport{
+		Target:    "cluster",
+		Timestamp: "2025-01-01T00:00:00Z",
+		Contexts: map[string]*ContextResult{
+			"prod-eu": NewContextResult([]*DiagnosticReport{createTestReport()}, nil),
+			"prod-us": NewContextResult(nil, errors.New("cluster unreachable")),
+		},
+	}
+	for _, result := range report.Contexts {
+		report.Summary.Add(result.Summary)
+	}
+
+	return report
+}
+
+// render runs fn, which prints with om, and returns what it wrote.
+func render(t *testing.T, om *OutputManager, fn func() error) string {
+	t.Helper()
+
+	var out bytes.Buffer
+	om.Out = &out
+
+	if err := fn(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return out.String()
+}
+
+func TestNewContextResult(t *testing.T) {
+	result := NewContextResult([]*DiagnosticReport{createTestReport(), createTestReport()}, nil)
+
+	expected := createTestReport().Summary
+	if result.Summary.Total != expected.Total*2 || result.Summary.Failed != expected.Failed*2 {
+		t.Errorf("Expected summaries to be added, got %+v", result.Summary)
+	}
+	if result.Error != "" {
+		t.Errorf("Expected no error, got %q", result.Error)
+	}
+}
+
+func TestMultiContextReport_JSON(t *testing.T) {
+	report := createTestMultiContextReport()
+	om := NewOutputManager("json", false)
+
+	output := render(t, om, func() error { return om.PrintMultiContextReport(report) })
+
+	var parsed MultiContextReport
+	if err := json.Unmarshal([]byte(output), &parsed); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v", err)
+	}
+
+	if len(parsed.Contexts) != 2 {
+		t.Fatalf("Expected 2 contexts keyed by name, got %d", len(parsed.Contexts))
+	}
+	if parsed.Contexts["prod-us"].Error != "cluster unreachable" {
+		t.Errorf("Expected prod-us error, got %+v", parsed.Contexts["prod-us"])
+	}
+	if len(parsed.Contexts["prod-eu"].Reports) != 1 {
+		t.Errorf("Expected 1 report for prod-eu, got %d", len(parsed.Contexts["prod-eu"].Reports))
+	}
+	if parsed.Summary.Total != report.Summary.Total {
+		t.Errorf("Expected combined total %d, got %d", report.Summary.Total, parsed.Summary.Total)
+	}
+}
+
+func TestMultiContextReport_Table(t *testing.T) {
+	report := createTestMultiContextReport()
+	om := NewOutputManager("table", false)
+
+	output := render(t, om, func() error { return om.PrintMultiContextReport(report) })
+
+	expectedElements := []string{
+		"=== Context: prod-eu ===",
+		"=== Context: prod-us ===",
+		"KDEBUG KUBERNETES DIAGNOSTIC REPORT",
+		"Cross-Cluster Summary:",
+		"CONTEXT",
+		"cluster unreachable",
+		"1 unreachable",
+	}
+
+	for _, element := range expectedElements {
+		if !strings.Contains(output, element) {
+			t.Errorf("Table output missing expected element: %s\nOutput: %s", element, output)
+		}
+	}
+
+	if strings.Index(output, "prod-eu") > strings.Index(output, "prod-us") {
+		t.Error("Expected contexts to be printed in alphabetical order")
+	}
+}
@@ -0,0 +1,142 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// Custom formats take their template or expression after an equals sign,
+// e.g. -o template='{{.target}}' or -o jsonpath='{.summary.failed}'. They
+// are evaluated over the document -o json prints, with its field names.
+const (
+	FormatTemplate OutputFormat = "template"
+	FormatJSONPath OutputFormat = "jsonpath"
+)
+
+// customPrinter is a compiled template or JSONPath expression.
+type customPrinter interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+// templateFuncs are available to -o template in addition to the text/template
+// builtins.
+var templateFuncs = template.FuncMap{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"csv":   csvRecord,
+}
+
+// ValidateFormat checks the template or JSONPath expression of a custom
+// output format. Other formats need no validation, since unknown ones fall
+// back to table.
+func ValidateFormat(format string) error {
+	_, _, err := parseCustomFormat(format)
+	return err
+}
+
+// parseCustomFormat compiles a template=, template-file= or jsonpath= format.
+// It returns an empty format for every other value.
+func parseCustomFormat(format string) (OutputFormat, customPrinter, error) {
+	name, spec, ok := strings.Cut(format, "=")
+	name = strings.ToLower(name)
+	if name != "template" && name != "template-file" && name != "jsonpath" {
+		return "", nil, nil
+	}
+	if !ok {
+		return "", nil, fmt.Errorf("-o %s requires a value, e.g. -o %s=...", name, name)
+	}
+
+	switch name {
+	case "template-file":
+		data, err := os.ReadFile(spec)
+		if err != nil {
+			return FormatTemplate, nil, fmt.Errorf("failed to read template file: %w", err)
+		}
+		printer, err := compileTemplate(string(data))
+		return FormatTemplate, printer, err
+	case "jsonpath":
+		printer, err := compileJSONPath(spec)
+		return FormatJSONPath, printer, err
+	default:
+		printer, err := compileTemplate(spec)
+		return FormatTemplate, printer, err
+	}
+}
+
+func compileTemplate(text string) (customPrinter, error) {
+	if text == "" {
+		return nil, fmt.Errorf("template is empty")
+	}
+
+	tmpl, err := template.New("output").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// compileJSONPath compiles an expression like kubectl does, accepting it with
+// or without the surrounding braces.
+func compileJSONPath(expression string) (customPrinter, error) {
+	if expression == "" {
+		return nil, fmt.Errorf("JSONPath expression is empty")
+	}
+	if !strings.HasPrefix(expression, "{") {
+		expression = "{" + expression + "}"
+	}
+
+	path := jsonpath.New("output").AllowMissingKeys(true)
+	if err := path.Parse(expression); err != nil {
+		return nil, fmt.Errorf("invalid JSONPath expression: %w", err)
+	}
+	return path, nil
+}
+
+// printCustom evaluates the custom format over the JSON form of a document.
+// Nothing is printed when evaluation fails part way through.
+func (o *OutputManager) printCustom(document interface{}) error {
+	data, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+
+	var fields interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	if err := o.custom.Execute(&out, fields); err != nil {
+		return fmt.Errorf("failed to evaluate %s output: %w", o.Format, err)
+	}
+
+	_, err = o.Out.Write(out.Bytes())
+	return err
+}
+
+// csvRecord renders values as a CSV record ending in a newline, quoting them
+// as needed.
+func csvRecord(values ...interface{}) (string, error) {
+	record := make([]string, len(values))
+	for i, value := range values {
+		if value != nil {
+			record[i] = fmt.Sprint(value)
+		}
+	}
+
+	var out bytes.Buffer
+	w := csv.NewWriter(&out)
+	if err := w.Write(record); err != nil {
+		return "", err
+	}
+	w.Flush()
+	return out.String(), w.Error()
+}
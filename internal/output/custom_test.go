@@ -0,0 +1,132 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewOutputManager_CustomFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		want   OutputFormat
+	}{
+		{"template={{.target}}", FormatTemplate},
+		{"Template={{.target}}", FormatTemplate},
+		{"jsonpath={.target}", FormatJSONPath},
+		{"jsonpath=.target", FormatJSONPath},
+		{"template={{.target", FormatTable},
+		{"template", FormatTable},
+		{"jsonpath", FormatTable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			if got := NewOutputManager(tt.format, false).Format; got != tt.want {
+				t.Errorf("NewOutputManager(%q).Format = %s, want %s", tt.format, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateFormat(t *testing.T) {
+	tests := []struct {
+		format  string
+		wantErr bool
+	}{
+		{"table", false},
+		{"unknown", false},
+		{"template={{.target}}", false},
+		{"jsonpath={.checks[*].id}", false},
+		{"template={{.target", true},
+		{"template=", true},
+		{"template", true},
+		{"jsonpath={.checks[", true},
+		{"template-file=" + filepath.Join(os.TempDir(), "kdebug-missing.tmpl"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			if err := ValidateFormat(tt.format); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateFormat(%q) error = %v, wantErr %v", tt.format, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDiagnosticReport_Template(t *testing.T) {
+	om := NewOutputManager(`template={{.target}}: {{.summary.failed}} failed{{range .checks}}{{with .details.image}} ({{.}}){{end}}{{end}}`, false)
+
+	out := render(t, om, func() error {
+		return om.PrintReport(createTestPodReport("web"))
+	})
+
+	if want := "pod/web: 1 failed (nginx:missing)"; out != want {
+		t.Errorf("Expected %q, got %q", want, out)
+	}
+}
+
+func TestPrintReports_TemplateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "findings.tmpl")
+	tmpl := `{{range .reports}}{{$target := .target}}{{range .checks}}{{if ne .status "PASSED"}}{{csv $target .id (lower .status) .message}}{{end}}{{end}}{{end}}`
+	if err := os.WriteFile(path, []byte(tmpl), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	om := NewOutputManager("template-file="+path, false)
+	out := render(t, om, func() error {
+		return om.PrintReports([]*DiagnosticReport{createTestPodReport("web"), createTestPodReport("api, v2")})
+	})
+
+	want := `pod/web,POD-IMAGE-PULL,failed,Image not found
+pod/web,POD-LOGS,warning,
+pod/web,POD-NETWORK,skipped,
+"pod/api, v2",POD-IMAGE-PULL,failed,Image not found
+"pod/api, v2",POD-LOGS,warning,
+"pod/api, v2",POD-NETWORK,skipped,
+`
+	if out != want {
+		t.Errorf("Unexpected CSV:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestDiagnosticReport_JSONPath(t *testing.T) {
+	om := NewOutputManager(`jsonpath={range .checks[?(@.status=="FAILED")]}{.id}={.details.image}{"\n"}{end}`, false)
+
+	out := render(t, om, func() error {
+		return om.PrintReport(createTestPodReport("web"))
+	})
+
+	if want := "POD-IMAGE-PULL=nginx:missing\n"; out != want {
+		t.Errorf("Expected %q, got %q", want, out)
+	}
+}
+
+func TestMultiContextReport_JSONPath(t *testing.T) {
+	om := NewOutputManager("jsonpath=.contexts.prod-us.error", false)
+
+	out := render(t, om, func() error {
+		return om.PrintMultiContextReport(createTestMultiContextReport())
+	})
+
+	if out != "cluster unreachable" {
+		t.Errorf("Expected the context error, got %q", out)
+	}
+}
+
+func TestPrintCustomFailsWithoutPartialOutput(t *testing.T) {
+	om := NewOutputManager(`template={{.target}}{{index .checks 99}}`, false)
+
+	var err error
+	out := render(t, om, func() error {
+		err = om.PrintReport(createTestPodReport("web"))
+		return nil
+	})
+
+	if err == nil {
+		t.Fatal("Expected an error for an index out of range")
+	}
+	if out != "" {
+		t.Errorf("Expected no output, got %q", out)
+	}
+}
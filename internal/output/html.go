@@ -0,0 +1,150 @@
+package output
+
+import (
+	_ "embed"
+	"html/template"
+	"sort"
+	"strings"
+	"time"
+)
+
+// htmlTemplate renders a self-contained report: styles and scripts are
+// inlined so the file can be opened offline and passed on as is.
+//
+//go:embed html.tmpl
+var htmlTemplate string
+
+var htmlPage = template.Must(template.New("report").Funcs(template.FuncMap{
+	"lower": func(status CheckStatus) string {
+		return strings.ToLower(string(status))
+	},
+	"duration": formatDuration,
+}).Parse(htmlTemplate))
+
+// htmlStatusOrder is the order checks are grouped in, most urgent first.
+var htmlStatusOrder = []CheckStatus{StatusFailed, StatusWarning, StatusSuppressed, StatusSkipped, StatusPassed}
+
+type htmlDocument struct {
+	Title     string
+	Generated string
+	Version   string
+	Summary   Summary
+	Reports   []htmlReport
+}
+
+type htmlReport struct {
+	Context     string
+	Target      string
+	Timestamp   string
+	Error       string
+	ClusterInfo []htmlPair
+	Summary     Summary
+	Groups      []htmlStatusGroup
+}
+
+type htmlStatusGroup struct {
+	Status    CheckStatus
+	Count     int
+	Resources []htmlResourceGroup
+}
+
+type htmlResourceGroup struct {
+	Resource string
+	Checks   []htmlCheck
+}
+
+type htmlCheck struct {
+	CheckResult
+	Details []htmlPair
+}
+
+type htmlPair struct {
+	Key   string
+	Value string
+}
+
+// printHTML prints sections as one HTML document.
+func (o *OutputManager) printHTML(sections []reportSection) error {
+	doc := htmlDocument{
+		Title:     "kdebug diagnostic report",
+		Generated: time.Now().Format(time.RFC3339),
+		Version:   o.Version,
+	}
+
+	for _, section := range sections {
+		if section.Error != "" {
+			doc.Reports = append(doc.Reports, htmlReport{Context: section.Context, Target: section.Context, Error: section.Error})
+			continue
+		}
+		if section.Report == nil {
+			continue
+		}
+
+		report := newHTMLReport(section.Context, section.Report)
+		doc.Summary.Add(report.Summary)
+		doc.Reports = append(doc.Reports, report)
+	}
+
+	if len(doc.Reports) == 1 && doc.Reports[0].Target != "" {
+		doc.Title += ": " + doc.Reports[0].Target
+	}
+
+	return htmlPage.Execute(o.Out, doc)
+}
+
+// newHTMLReport groups the checks of a report by status, then by the
+// resource they are about, in the order they were run.
+func newHTMLReport(contextName string, report *DiagnosticReport) htmlReport {
+	result := htmlReport{
+		Context:     contextName,
+		Target:      report.Target,
+		Timestamp:   report.Timestamp,
+		ClusterInfo: sortedPairs(report.ClusterInfo),
+		Summary:     report.Summary,
+	}
+
+	for _, status := range htmlStatusOrder {
+		group := htmlStatusGroup{Status: status}
+		index := make(map[string]int)
+
+		for _, check := range report.Checks {
+			if check.Status != status {
+				continue
+			}
+
+			resource := report.Target
+			if check.Resource != nil {
+				resource = check.Resource.String()
+			} else if report.Resource != nil {
+				resource = report.Resource.String()
+			}
+
+			i, ok := index[resource]
+			if !ok {
+				i = len(group.Resources)
+				index[resource] = i
+				group.Resources = append(group.Resources, htmlResourceGroup{Resource: resource})
+			}
+
+			group.Resources[i].Checks = append(group.Resources[i].Checks, htmlCheck{CheckResult: check, Details: sortedPairs(check.Details)})
+			group.Count++
+		}
+
+		if group.Count > 0 {
+			result.Groups = append(result.Groups, group)
+		}
+	}
+
+	return result
+}
+
+func sortedPairs(values map[string]string) []htmlPair {
+	pairs := make([]htmlPair, 0, len(values))
+	for key, value := range values {
+		pairs = append(pairs, htmlPair{Key: key, Value: value})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].Key < pairs[j].Key
+	})
+	return pairs
+}
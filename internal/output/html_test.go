@@ -0,0 +1,97 @@
+package output
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDiagnosticReport_HTML(t *testing.T) {
+	om := NewOutputManager("html", false)
+
+	report := createTestPodReport("web")
+	report.ClusterInfo = map[string]string{"version": "v1.30.0"}
+	report.Checks[1].Message = "Image <nginx:missing> not found"
+
+	out := render(t, om, func() error {
+		return om.PrintReport(report)
+	})
+
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"<th>version</th><td>v1.30.0</td>",
+		"Image &lt;nginx:missing&gt; not found",
+		"<td>image</td><td><code>nginx:missing</code></td>",
+		"Check the image name",
+		`id="filter"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected HTML output to contain %q", want)
+		}
+	}
+
+	// Groups are ordered by urgency, and only failures and warnings are
+	// expanded
+	failed := strings.Index(out, `<details class="status-group" data-status="failed" open>`)
+	warning := strings.Index(out, `<details class="status-group" data-status="warning" open>`)
+	passed := strings.Index(out, `<details class="status-group" data-status="passed">`)
+	if failed < 0 || warning < failed || passed < warning {
+		t.Errorf("Expected failed, warning and passed groups in order, got offsets %d, %d, %d", failed, warning, passed)
+	}
+
+	// The report must open offline
+	for _, external := range []string{"<link", "src=", "@import", "url("} {
+		if strings.Contains(out, external) {
+			t.Errorf("Expected no external assets, found %q", external)
+		}
+	}
+}
+
+func TestNewHTMLReportGroupsByResource(t *testing.T) {
+	report := &DiagnosticReport{
+		Target: "cluster",
+		Checks: []CheckResult{
+			{Name: "Node Conditions", Status: StatusFailed, Resource: &ResourceRef{Kind: "Node", Name: "node-1"}},
+			{Name: "Control Plane", Status: StatusFailed},
+			{Name: "Node Conditions", Status: StatusFailed, Resource: &ResourceRef{Kind: "Node", Name: "node-2"}},
+			{Name: "Node Pressure", Status: StatusFailed, Resource: &ResourceRef{Kind: "Node", Name: "node-1"}},
+		},
+	}
+
+	groups := newHTMLReport("", report).Groups
+	if len(groups) != 1 || groups[0].Count != 4 {
+		t.Fatalf("Expected one failed group with 4 checks, got %+v", groups)
+	}
+
+	var resources []string
+	for _, group := range groups[0].Resources {
+		resources = append(resources, group.Resource)
+	}
+	if got := strings.Join(resources, ","); got != "node/node-1,cluster,node/node-2" {
+		t.Errorf("Expected resources in the order they were first reported, got %s", got)
+	}
+	if len(groups[0].Resources[0].Checks) != 2 {
+		t.Errorf("Expected both node-1 checks in one group, got %d", len(groups[0].Resources[0].Checks))
+	}
+}
+
+func TestMultiContextReport_HTML(t *testing.T) {
+	om := NewOutputManager("html", false)
+
+	report := createTestMultiContextReport()
+	report.Contexts["prod-us"] = NewContextResult(nil, errors.New("cluster <unreachable>"))
+
+	out := render(t, om, func() error {
+		return om.PrintMultiContextReport(report)
+	})
+
+	if strings.Count(out, "<!DOCTYPE html>") != 1 {
+		t.Error("Expected a single document")
+	}
+	if !strings.Contains(out, "<h2>prod-eu: test-cluster</h2>") {
+		t.Error("Expected report sections prefixed with their context")
+	}
+	if !strings.Contains(out, "Diagnosis failed: cluster &lt;unreachable&gt;") {
+		t.Error("Expected the unreachable context with its error")
+	}
+}
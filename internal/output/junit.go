@@ -0,0 +1,247 @@
+package output
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// JUnit XML in the form rendered by Jenkins, GitLab and most CI dashboards:
+// one testsuite per report and one testcase per check result.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	Errors     int              `xml:"errors,attr"`
+	Skipped    int              `xml:"skipped,attr"`
+	Time       string           `xml:"time,attr"`
+	Timestamp  string           `xml:"timestamp,attr,omitempty"`
+	Properties *junitProperties `xml:"properties,omitempty"`
+	TestCases  []junitTestCase  `xml:"testcase"`
+
+	seconds float64
+}
+
+type junitProperties struct {
+	Properties []junitProperty `xml:"property"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	SystemOut *junitOutput  `xml:"system-out,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",cdata"`
+}
+
+type junitOutput struct {
+	Body string `xml:",cdata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// printJUnit prints suites as one JUnit XML document.
+func (o *OutputManager) printJUnit(suites []junitTestSuite) error {
+	doc := junitTestSuites{Name: "kdebug", Suites: suites}
+
+	var seconds float64
+	for _, suite := range suites {
+		doc.Tests += suite.Tests
+		doc.Failures += suite.Failures
+		doc.Errors += suite.Errors
+		doc.Skipped += suite.Skipped
+		seconds += suite.seconds
+	}
+	doc.Time = formatSeconds(seconds)
+
+	if _, err := fmt.Fprint(o.Out, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(o.Out)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintln(o.Out)
+	return err
+}
+
+// junitSuites converts reports into test suites. In multi-context runs the
+// suite names are prefixed with the context.
+func (o *OutputManager) junitSuites(contextName string, reports []*DiagnosticReport) []junitTestSuite {
+	suites := make([]junitTestSuite, 0, len(reports))
+	for _, report := range reports {
+		if report != nil {
+			suites = append(suites, o.junitSuite(contextName, report))
+		}
+	}
+	return suites
+}
+
+// junitSuite converts a report into a test suite.
+func (o *OutputManager) junitSuite(contextName string, report *DiagnosticReport) junitTestSuite {
+	suite := junitTestSuite{
+		Name:      report.Target,
+		Timestamp: report.Timestamp,
+		TestCases: []junitTestCase{},
+	}
+	var properties []junitProperty
+	if contextName != "" {
+		suite.Name = contextName + "/" + report.Target
+		properties = append(properties, junitProperty{Name: "context", Value: contextName})
+	}
+
+	infoKeys := make([]string, 0, len(report.ClusterInfo))
+	for key := range report.ClusterInfo {
+		infoKeys = append(infoKeys, key)
+	}
+	sort.Strings(infoKeys)
+	for _, key := range infoKeys {
+		properties = append(properties, junitProperty{Name: "cluster." + key, Value: report.ClusterInfo[key]})
+	}
+	if len(properties) > 0 {
+		suite.Properties = &junitProperties{Properties: properties}
+	}
+
+	// A check that produced several results ran once, so its duration is
+	// counted once
+	timed := make(map[string]bool)
+	for _, check := range report.Checks {
+		testCase := o.junitTestCase(report, check)
+		suite.TestCases = append(suite.TestCases, testCase)
+		suite.Tests++
+
+		switch {
+		case testCase.Failure != nil:
+			suite.Failures++
+		case testCase.Skipped != nil:
+			suite.Skipped++
+		}
+
+		if !timed[check.ID] {
+			timed[check.ID] = true
+			suite.seconds += check.DurationSeconds
+		}
+	}
+	suite.Time = formatSeconds(suite.seconds)
+
+	return suite
+}
+
+// junitTestCase converts a check result. Failed checks are failures, and
+// skipped checks and findings suppressed by a baseline are skipped; warnings
+// are failures only when WarningsAsFailures is set, and are otherwise
+// reported in system-out like passed checks.
+func (o *OutputManager) junitTestCase(report *DiagnosticReport, check CheckResult) junitTestCase {
+	testCase := junitTestCase{
+		Name:      displayName(report, check),
+		ClassName: check.ID,
+		Time:      formatSeconds(check.DurationSeconds),
+	}
+	if testCase.ClassName == "" {
+		testCase.ClassName = report.Target
+	}
+
+	body := junitBody(check)
+	switch {
+	case check.Status == StatusFailed, check.Status == StatusWarning && o.WarningsAsFailures:
+		testCase.Failure = &junitProblem{Message: check.Message, Type: string(check.Status), Body: body}
+	case check.Status == StatusSkipped:
+		testCase.Skipped = &junitSkipped{Message: check.Message}
+		testCase.SystemOut = &junitOutput{Body: body}
+	case check.Status == StatusSuppressed:
+		message := "Suppressed"
+		if check.Suppression != nil && check.Suppression.Justification != "" {
+			message += ": " + check.Suppression.Justification
+		}
+		testCase.Skipped = &junitSkipped{Message: message}
+		testCase.SystemOut = &junitOutput{Body: body}
+	default:
+		testCase.SystemOut = &junitOutput{Body: body}
+	}
+
+	return testCase
+}
+
+// junitBody renders the message, suggestion and details of a check result.
+func junitBody(check CheckResult) string {
+	var body strings.Builder
+
+	body.WriteString(string(check.Status))
+	if check.Message != "" {
+		body.WriteString(": " + check.Message)
+	}
+	body.WriteString("\n")
+	if check.Suggestion != "" {
+		fmt.Fprintf(&body, "Suggestion: %s\n", check.Suggestion)
+	}
+	if check.Error != "" {
+		fmt.Fprintf(&body, "Error: %s\n", check.Error)
+	}
+
+	if len(check.Details) > 0 {
+		keys := make([]string, 0, len(check.Details))
+		for key := range check.Details {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		body.WriteString("Details:\n")
+		for _, key := range keys {
+			fmt.Fprintf(&body, "  %s: %s\n", key, check.Details[key])
+		}
+	}
+
+	return body.String()
+}
+
+// junitContextError returns the suite reported for a context that could not
+// be diagnosed, with the error as a test error.
+func junitContextError(contextName, message string) junitTestSuite {
+	return junitTestSuite{
+		Name:       contextName,
+		Tests:      1,
+		Errors:     1,
+		Time:       formatSeconds(0),
+		Properties: &junitProperties{Properties: []junitProperty{{Name: "context", Value: contextName}}},
+		TestCases: []junitTestCase{{
+			Name:      "Context Diagnosis",
+			ClassName: "kdebug",
+			Time:      formatSeconds(0),
+			Error:     &junitProblem{Message: message, Type: "ContextError", Body: message},
+		}},
+	}
+}
+
+func formatSeconds(seconds float64) string {
+	return fmt.Sprintf("%.3f", seconds)
+}
@@ -0,0 +1,145 @@
+package output
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func decodeJUnit(t *testing.T, out string) junitTestSuites {
+	t.Helper()
+
+	if !strings.HasPrefix(out, "<?xml") {
+		t.Errorf("Expected an XML declaration, got %q", out)
+	}
+
+	var doc junitTestSuites
+	if err := xml.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("Failed to decode JUnit output: %v\n%s", err, out)
+	}
+
+	return doc
+}
+
+func TestDiagnosticReport_JUnit(t *testing.T) {
+	om := NewOutputManager("junit", false)
+
+	report := createTestPodReport("web")
+	report.ClusterInfo = map[string]string{"version": "v1.30.0"}
+
+	doc := decodeJUnit(t, render(t, om, func() error {
+		return om.PrintReport(report)
+	}))
+
+	if len(doc.Suites) != 1 {
+		t.Fatalf("Expected 1 testsuite, got %d", len(doc.Suites))
+	}
+	suite := doc.Suites[0]
+	if suite.Name != "pod/web" || suite.Tests != 4 || suite.Failures != 1 || suite.Skipped != 1 {
+		t.Errorf("Unexpected suite %s: tests=%d failures=%d skipped=%d", suite.Name, suite.Tests, suite.Failures, suite.Skipped)
+	}
+	if doc.Tests != 4 || doc.Failures != 1 || doc.Skipped != 1 {
+		t.Errorf("Expected totals to match the suite, got tests=%d failures=%d skipped=%d", doc.Tests, doc.Failures, doc.Skipped)
+	}
+	if suite.Properties == nil || suite.Properties.Properties[0] != (junitProperty{Name: "cluster.version", Value: "v1.30.0"}) {
+		t.Errorf("Expected cluster info as properties, got %+v", suite.Properties)
+	}
+
+	cases := make(map[string]junitTestCase)
+	for _, testCase := range suite.TestCases {
+		cases[testCase.ClassName] = testCase
+	}
+
+	failed := cases["POD-IMAGE-PULL"]
+	if failed.Failure == nil {
+		t.Fatal("Expected the failed check to be a failure")
+	}
+	for _, want := range []string{"Image not found", "Suggestion: Check the image name", "image: nginx:missing"} {
+		if !strings.Contains(failed.Failure.Body, want) {
+			t.Errorf("Expected failure body to contain %q, got %q", want, failed.Failure.Body)
+		}
+	}
+
+	if warning := cases["POD-LOGS"]; warning.Failure != nil || warning.SystemOut == nil {
+		t.Errorf("Expected the warning in system-out, got %+v", warning)
+	}
+	if skipped := cases["POD-NETWORK"]; skipped.Skipped == nil {
+		t.Error("Expected the skipped check to be skipped")
+	}
+	if passed := cases["POD-EXISTENCE"]; passed.Failure != nil || passed.Skipped != nil {
+		t.Errorf("Expected the passed check to succeed, got %+v", passed)
+	}
+}
+
+func TestDiagnosticReport_JUnitWarningsAsFailures(t *testing.T) {
+	om := NewOutputManager("junit", false)
+	om.WarningsAsFailures = true
+
+	doc := decodeJUnit(t, render(t, om, func() error {
+		return om.PrintReport(createTestPodReport("web"))
+	}))
+
+	if doc.Failures != 2 {
+		t.Errorf("Expected the warning to be counted as a failure, got %d failures", doc.Failures)
+	}
+}
+
+func TestDiagnosticReport_JUnitSuppressed(t *testing.T) {
+	report := createTestPodReport("web")
+	report.Checks[1].Suppression = &Suppression{Status: StatusFailed, Justification: "Mirror outage"}
+	report.Checks[1].Status = StatusSuppressed
+
+	om := NewOutputManager("junit", false)
+	om.WarningsAsFailures = true
+	doc := decodeJUnit(t, render(t, om, func() error {
+		return om.PrintReport(report)
+	}))
+
+	if doc.Failures != 1 || doc.Skipped != 2 {
+		t.Errorf("Expected the suppressed failure to be skipped, got failures=%d skipped=%d", doc.Failures, doc.Skipped)
+	}
+	suppressed := doc.Suites[0].TestCases[1]
+	if suppressed.Skipped == nil || suppressed.Skipped.Message != "Suppressed: Mirror outage" {
+		t.Errorf("Expected the justification as the skip message, got %+v", suppressed.Skipped)
+	}
+}
+
+func TestPrintReports_JUnit(t *testing.T) {
+	om := NewOutputManager("junit", false)
+
+	out := render(t, om, func() error {
+		return om.PrintReports([]*DiagnosticReport{createTestPodReport("web"), createTestPodReport("api")})
+	})
+
+	if strings.Count(out, "<?xml") != 1 {
+		t.Errorf("Expected a single document, got:\n%s", out)
+	}
+
+	doc := decodeJUnit(t, out)
+	if len(doc.Suites) != 2 || doc.Tests != 8 {
+		t.Errorf("Expected 2 suites with 8 tests, got %d suites with %d tests", len(doc.Suites), doc.Tests)
+	}
+}
+
+func TestMultiContextReport_JUnit(t *testing.T) {
+	om := NewOutputManager("junit", false)
+
+	doc := decodeJUnit(t, render(t, om, func() error {
+		return om.PrintMultiContextReport(createTestMultiContextReport())
+	}))
+
+	if len(doc.Suites) != 2 {
+		t.Fatalf("Expected a suite per context, got %d", len(doc.Suites))
+	}
+	if doc.Suites[0].Name != "prod-eu/test-cluster" {
+		t.Errorf("Expected suite names prefixed with the context, got %s", doc.Suites[0].Name)
+	}
+
+	unreachable := doc.Suites[1]
+	if unreachable.Errors != 1 || unreachable.TestCases[0].Error == nil {
+		t.Errorf("Expected the unreachable context as an error, got %+v", unreachable)
+	}
+	if doc.Errors != 1 {
+		t.Errorf("Expected 1 error in total, got %d", doc.Errors)
+	}
+}
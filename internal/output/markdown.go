@@ -0,0 +1,209 @@
+package output
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// printMarkdown prints sections as GitHub-flavored markdown for pull
+// requests, issues and chat: a badge line with the totals, a table of the
+// failed and warning checks with their suggestions, and the details of each
+// finding in collapsible sections.
+func (o *OutputManager) printMarkdown(sections []reportSection) error {
+	var md strings.Builder
+
+	if len(sections) == 1 && sections[0].Report != nil {
+		report := sections[0].Report
+		fmt.Fprintf(&md, "## kdebug: %s\n\n", markdownText(report.Target))
+		writeMarkdownReport(&md, report)
+	} else {
+		var summary Summary
+		for _, section := range sections {
+			if section.Report != nil {
+				summary.Add(section.Report.Summary)
+			}
+		}
+
+		fmt.Fprintf(&md, "## kdebug: %d reports\n\n", len(sections))
+		fmt.Fprintf(&md, "%s\n", markdownBadges(summary))
+
+		for _, section := range sections {
+			title := ""
+			if section.Context != "" {
+				title = section.Context + ": "
+			}
+
+			if section.Error != "" {
+				fmt.Fprintf(&md, "\n### %s\n\n", markdownText(strings.TrimSuffix(title, ": ")))
+				fmt.Fprintf(&md, "> ❌ **Diagnosis failed:** %s\n", markdownText(section.Error))
+				continue
+			}
+			if section.Report == nil {
+				continue
+			}
+
+			fmt.Fprintf(&md, "\n### %s\n\n", markdownText(title+section.Report.Target))
+			writeMarkdownReport(&md, section.Report)
+		}
+	}
+
+	_, err := fmt.Fprint(o.Out, md.String())
+	return err
+}
+
+// writeMarkdownReport writes the badge line, findings table and details of a
+// report.
+func writeMarkdownReport(md *strings.Builder, report *DiagnosticReport) {
+	fmt.Fprintf(md, "%s\n", markdownBadges(report.Summary))
+
+	var findings, suppressed []CheckResult
+	for _, check := range report.Checks {
+		switch check.Status {
+		case StatusFailed, StatusWarning:
+			findings = append(findings, check)
+		case StatusSuppressed:
+			suppressed = append(suppressed, check)
+		}
+	}
+	defer writeMarkdownSuppressed(md, report, suppressed)
+
+	if len(findings) == 0 {
+		md.WriteString("\nNo failed checks or warnings.\n")
+		return
+	}
+
+	md.WriteString("\n| Status | Check | Resource | Message | Suggestion |\n")
+	md.WriteString("|--------|-------|----------|---------|------------|\n")
+	for _, check := range findings {
+		fmt.Fprintf(md, "| %s | %s | %s | %s | %s |\n",
+			markdownStatus(check.Status),
+			markdownCheck(check),
+			markdownCell(markdownResource(report, check)),
+			markdownCell(check.Message),
+			markdownCell(check.Suggestion))
+	}
+
+	for _, check := range findings {
+		if len(check.Details) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(md, "\n<details>\n<summary>%s %s: %s</summary>\n\n",
+			markdownStatus(check.Status),
+			html.EscapeString(check.Name),
+			html.EscapeString(markdownResource(report, check)))
+		md.WriteString("| Detail | Value |\n|--------|-------|\n")
+		for _, pair := range sortedPairs(check.Details) {
+			fmt.Fprintf(md, "| %s | %s |\n", markdownCell(pair.Key), markdownCode(pair.Value))
+		}
+		md.WriteString("\n</details>\n")
+	}
+}
+
+// writeMarkdownSuppressed writes the findings suppressed by a baseline in a
+// collapsible table with their justifications.
+func writeMarkdownSuppressed(md *strings.Builder, report *DiagnosticReport, suppressed []CheckResult) {
+	if len(suppressed) == 0 {
+		return
+	}
+
+	fmt.Fprintf(md, "\n<details>\n<summary>🔕 %d suppressed</summary>\n\n", len(suppressed))
+	md.WriteString("| Check | Resource | Message | Justification |\n")
+	md.WriteString("|-------|----------|---------|---------------|\n")
+	for _, check := range suppressed {
+		justification := ""
+		if check.Suppression != nil {
+			justification = check.Suppression.Justification
+		}
+		fmt.Fprintf(md, "| %s | %s | %s | %s |\n",
+			markdownCheck(check),
+			markdownCell(markdownResource(report, check)),
+			markdownCell(check.Message),
+			markdownCell(justification))
+	}
+	md.WriteString("\n</details>\n")
+}
+
+// markdownBadges renders the summary as a line of emoji badges.
+func markdownBadges(summary Summary) string {
+	badges := fmt.Sprintf("🔴 **%d failed** · 🟡 **%d warnings** · ⚪ %d skipped · 🟢 %d passed",
+		summary.Failed, summary.Warnings, summary.Skipped, summary.Passed)
+	if summary.Suppressed > 0 {
+		badges += fmt.Sprintf(" · 🔕 %d suppressed", summary.Suppressed)
+	}
+	return fmt.Sprintf("%s · %d checks", badges, summary.Total)
+}
+
+func markdownStatus(status CheckStatus) string {
+	switch status {
+	case StatusFailed:
+		return "🔴 FAILED"
+	case StatusWarning:
+		return "🟡 WARNING"
+	case StatusSkipped:
+		return "⚪ SKIPPED"
+	case StatusSuppressed:
+		return "🔕 SUPPRESSED"
+	default:
+		return "🟢 PASSED"
+	}
+}
+
+func markdownCheck(check CheckResult) string {
+	name := markdownCell(check.Name)
+	if check.ID != "" {
+		name += " `" + check.ID + "`"
+	}
+	return name
+}
+
+// markdownResource returns the resource a check is about.
+func markdownResource(report *DiagnosticReport, check CheckResult) string {
+	switch {
+	case check.Resource != nil:
+		return check.Resource.String()
+	case report.Resource != nil:
+		return report.Resource.String()
+	default:
+		return report.Target
+	}
+}
+
+// markdownCell escapes text for a table cell, which must stay on one line.
+func markdownCell(text string) string {
+	text = markdownText(text)
+	text = strings.ReplaceAll(text, "|", "\\|")
+	text = strings.ReplaceAll(text, "\r\n", "<br>")
+	return strings.ReplaceAll(text, "\n", "<br>")
+}
+
+// markdownCode renders a value as inline code in a table cell.
+func markdownCode(text string) string {
+	if text == "" {
+		return ""
+	}
+	text = strings.ReplaceAll(text, "|", "\\|")
+	text = strings.ReplaceAll(text, "\n", " ")
+	if strings.Contains(text, "`") {
+		return "`` " + text + " ``"
+	}
+	return "`" + text + "`"
+}
+
+// markdownText escapes the characters that would start HTML or links in
+// free text such as messages from the API server.
+func markdownText(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"<", "&lt;",
+	">", "&gt;",
+	"[", `\[`,
+	"]", `\]`,
+	"*", `\*`,
+	"_", `\_`,
+	"`", "\\`",
+)
@@ -0,0 +1,87 @@
+package output
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiagnosticReport_Markdown(t *testing.T) {
+	om := NewOutputManager("markdown", false)
+
+	report := createTestPodReport("web")
+	report.Checks[1].Message = "Image <nginx> not found | retrying"
+
+	out := render(t, om, func() error {
+		return om.PrintReport(report)
+	})
+
+	for _, want := range []string{
+		"## kdebug: pod/web",
+		"🔴 **1 failed** · 🟡 **1 warnings**",
+		"| Status | Check | Resource | Message | Suggestion |",
+		"| 🔴 FAILED | Image Pull `POD-IMAGE-PULL` | pod/web | Image &lt;nginx&gt; not found \\| retrying | Check the image name |",
+		"<summary>🔴 FAILED Image Pull: pod/web</summary>",
+		"| image | `nginx:missing` |",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected markdown to contain %q, got:\n%s", want, out)
+		}
+	}
+
+	if strings.Contains(out, "\033[") {
+		t.Error("Expected no ANSI escape codes in markdown output")
+	}
+	if strings.Contains(out, "Pod Existence") || strings.Contains(out, "POD-NETWORK") {
+		t.Error("Expected only failed and warning checks in the findings table")
+	}
+}
+
+func TestPrintReports_Markdown(t *testing.T) {
+	om := NewOutputManager("markdown", false)
+
+	out := render(t, om, func() error {
+		return om.PrintReports([]*DiagnosticReport{createTestPodReport("web"), createTestPodReport("api")})
+	})
+
+	for _, want := range []string{
+		"## kdebug: 2 reports",
+		"🔴 **2 failed** · 🟡 **2 warnings**",
+		"### pod/web",
+		"### pod/api",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected markdown to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestMultiContextReport_Markdown(t *testing.T) {
+	om := NewOutputManager("markdown", false)
+
+	out := render(t, om, func() error {
+		return om.PrintMultiContextReport(createTestMultiContextReport())
+	})
+
+	if !strings.Contains(out, "### prod-eu: test-cluster") {
+		t.Errorf("Expected report sections prefixed with their context, got:\n%s", out)
+	}
+	if !strings.Contains(out, "### prod-us\n\n> ❌ **Diagnosis failed:** cluster unreachable") {
+		t.Errorf("Expected the unreachable context with its error, got:\n%s", out)
+	}
+}
+
+func TestMarkdownCell(t *testing.T) {
+	tests := map[string]string{
+		"plain":              "plain",
+		"a | b":              `a \| b`,
+		"line one\nline two": "line one<br>line two",
+		"<script>":           "&lt;script&gt;",
+		"[link](x)":          `\[link\](x)`,
+	}
+
+	for input, want := range tests {
+		if got := markdownCell(input); got != want {
+			t.Errorf("markdownCell(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// NDJSON lines are typed so consumers can tell results from the final
+// summary without inspecting their fields.
+const (
+	ndjsonResult  = "result"
+	ndjsonError   = "error"
+	ndjsonSummary = "summary"
+)
+
+// ndjsonStream serialises lines written by concurrent diagnostics.
+type ndjsonStream struct {
+	mu sync.Mutex
+}
+
+// ndjsonResultLine is a check result with the report it belongs to.
+type ndjsonResultLine struct {
+	Type    string `json:"type"`
+	Context string `json:"context,omitempty"`
+	Target  string `json:"target"`
+	CheckResult
+}
+
+// ndjsonErrorLine reports a context that could not be diagnosed.
+type ndjsonErrorLine struct {
+	Type    string `json:"type"`
+	Context string `json:"context"`
+	Error   string `json:"error"`
+}
+
+// ndjsonSummaryLine ends the stream with the totals of the run.
+type ndjsonSummaryLine struct {
+	Type          string           `json:"type"`
+	SchemaVersion string           `json:"schema_version"`
+	Timestamp     string           `json:"timestamp"`
+	Summary       ReportSetSummary `json:"summary"`
+
+	// Errors is the number of contexts that could not be diagnosed
+	Errors int `json:"errors,omitempty"`
+}
+
+// Streaming reports whether results are written as they are computed, in
+// which case diagnostics pass them to FinalizeResults.
+func (o *OutputManager) Streaming() bool {
+	return o.Format == FormatNDJSON && o.stream != nil
+}
+
+// FinalizeResults is called by diagnostics once the results of the report
+// with the given target are final, before they are summarized: findings
+// accepted by the baseline are suppressed, and the results are streamed.
+func (o *OutputManager) FinalizeResults(target string, results []CheckResult) {
+	if o.Baseline != nil {
+		o.Baseline.Suppress(o.Context, results)
+	}
+	o.StreamResults(target, results)
+}
+
+// StreamResults writes one NDJSON line per check result of the report with
+// the given target as soon as the results are final. It does nothing for the
+// other formats, which print complete reports.
+func (o *OutputManager) StreamResults(target string, results []CheckResult) {
+	if !o.Streaming() {
+		return
+	}
+
+	for _, result := range results {
+		if err := o.writeLine(ndjsonResultLine{Type: ndjsonResult, Context: o.Context, Target: target, CheckResult: result}); err != nil {
+			fmt.Fprintf(o.Err, "Error writing result: %v\n", err)
+			return
+		}
+	}
+}
+
+// ForContext returns an output manager for diagnosing one of several
+// kubeconfig contexts; streamed results are tagged with the context.
+func (o *OutputManager) ForContext(name string) *OutputManager {
+	contextMgr := *o
+	contextMgr.Context = name
+	return &contextMgr
+}
+
+// printNDJSONSummary ends the stream once every result was streamed.
+func (o *OutputManager) printNDJSONSummary(reports []*DiagnosticReport, errors int) error {
+	return o.writeLine(ndjsonSummaryLine{
+		Type:          ndjsonSummary,
+		SchemaVersion: SchemaVersion,
+		Timestamp:     time.Now().Format(time.RFC3339),
+		Summary:       NewReportSet(reports).Summary,
+		Errors:        errors,
+	})
+}
+
+// printNDJSONContexts ends a multi-context stream with a line per context
+// that could not be diagnosed and the summary.
+func (o *OutputManager) printNDJSONContexts(report *MultiContextReport) error {
+	var (
+		reports []*DiagnosticReport
+		errors  int
+	)
+	for _, name := range report.ContextNames() {
+		result := report.Contexts[name]
+		if result.Error != "" {
+			errors++
+			if err := o.writeLine(ndjsonErrorLine{Type: ndjsonError, Context: name, Error: result.Error}); err != nil {
+				return err
+			}
+			continue
+		}
+		reports = append(reports, result.Reports...)
+	}
+
+	return o.printNDJSONSummary(reports, errors)
+}
+
+func (o *OutputManager) writeLine(line interface{}) error {
+	data, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+
+	o.stream.mu.Lock()
+	defer o.stream.mu.Unlock()
+
+	_, err = o.Out.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,154 @@
+package output
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func decodeNDJSON(t *testing.T, out string) []map[string]interface{} {
+	t.Helper()
+
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var decoded map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &decoded); err != nil {
+			t.Fatalf("Invalid NDJSON line %q: %v", line, err)
+		}
+		lines = append(lines, decoded)
+	}
+
+	return lines
+}
+
+func TestStreamResults_NDJSON(t *testing.T) {
+	om := NewOutputManager("ndjson", false)
+	report := createTestPodReport("web")
+
+	out := render(t, om, func() error {
+		om.StreamResults(report.Target, report.Checks)
+		return om.PrintReport(report)
+	})
+
+	lines := decodeNDJSON(t, out)
+	if len(lines) != 5 {
+		t.Fatalf("Expected 4 result lines and a summary line, got %d:\n%s", len(lines), out)
+	}
+
+	failed := lines[1]
+	if failed["type"] != "result" || failed["target"] != "pod/web" || failed["id"] != "POD-IMAGE-PULL" || failed["status"] != "FAILED" {
+		t.Errorf("Unexpected result line: %v", failed)
+	}
+	if resource, ok := failed["resource"].(map[string]interface{}); !ok || resource["name"] != "web" {
+		t.Errorf("Expected the resource on result lines, got %v", failed["resource"])
+	}
+	if _, ok := failed["context"]; ok {
+		t.Error("Expected no context outside multi-context runs")
+	}
+
+	summary := lines[4]
+	if summary["type"] != "summary" {
+		t.Fatalf("Expected the summary last, got %v", summary)
+	}
+	if totals := summary["summary"].(map[string]interface{}); totals["total"] != float64(4) || totals["failed"] != float64(1) {
+		t.Errorf("Unexpected summary: %v", totals)
+	}
+}
+
+func TestStreamResults_Concurrent(t *testing.T) {
+	om := NewOutputManager("ndjson", false)
+	report := createTestPodReport("web")
+
+	out := render(t, om, func() error {
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				om.ForContext("prod").StreamResults(report.Target, report.Checks)
+			}()
+		}
+		wg.Wait()
+		return nil
+	})
+
+	lines := decodeNDJSON(t, out)
+	if len(lines) != 80 {
+		t.Fatalf("Expected 80 intact lines, got %d", len(lines))
+	}
+	if lines[0]["context"] != "prod" {
+		t.Errorf("Expected lines tagged with the context, got %v", lines[0]["context"])
+	}
+}
+
+func TestStreamResults_OtherFormats(t *testing.T) {
+	om := NewOutputManager("json", false)
+
+	out := render(t, om, func() error {
+		om.StreamResults("pod/web", createTestPodReport("web").Checks)
+		return nil
+	})
+
+	if out != "" {
+		t.Errorf("Expected no streamed output for json, got %q", out)
+	}
+}
+
+// suppressLogs accepts every POD-LOGS finding.
+type suppressLogs struct{ contexts []string }
+
+func (s *suppressLogs) Suppress(contextName string, results []CheckResult) {
+	s.contexts = append(s.contexts, contextName)
+	for i := range results {
+		if results[i].ID == "POD-LOGS" {
+			results[i].Suppression = &Suppression{Status: results[i].Status, Justification: "Known noisy logs"}
+			results[i].Status = StatusSuppressed
+		}
+	}
+}
+
+func TestFinalizeResults_Baseline(t *testing.T) {
+	baseline := &suppressLogs{}
+	om := NewOutputManager("ndjson", false)
+	om.Baseline = baseline
+	results := createTestPodReport("web").Checks
+
+	out := render(t, om, func() error {
+		om.ForContext("prod").FinalizeResults("pod/web", results)
+		return nil
+	})
+
+	if len(baseline.contexts) != 1 || baseline.contexts[0] != "prod" {
+		t.Errorf("Expected the baseline to be applied once for the context, got %v", baseline.contexts)
+	}
+	if results[2].Status != StatusSuppressed {
+		t.Errorf("Expected the results to be suppressed in place, got %s", results[2].Status)
+	}
+
+	lines := decodeNDJSON(t, out)
+	if lines[2]["status"] != "SUPPRESSED" {
+		t.Errorf("Expected the suppressed status to be streamed, got %v", lines[2])
+	}
+	if suppression, ok := lines[2]["suppression"].(map[string]interface{}); !ok || suppression["status"] != "WARNING" {
+		t.Errorf("Expected the original status with the suppression, got %v", lines[2]["suppression"])
+	}
+}
+
+func TestMultiContextReport_NDJSON(t *testing.T) {
+	om := NewOutputManager("ndjson", false)
+
+	lines := decodeNDJSON(t, render(t, om, func() error {
+		return om.PrintMultiContextReport(createTestMultiContextReport())
+	}))
+
+	if len(lines) != 2 {
+		t.Fatalf("Expected an error line and a summary line, got %d", len(lines))
+	}
+	if lines[0]["type"] != "error" || lines[0]["context"] != "prod-us" {
+		t.Errorf("Unexpected error line: %v", lines[0])
+	}
+	if lines[1]["type"] != "summary" || lines[1]["errors"] != float64(1) {
+		t.Errorf("Unexpected summary line: %v", lines[1])
+	}
+}
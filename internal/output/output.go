@@ -3,56 +3,148 @@ package output
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
-// ANSI color codes for terminal output
-const (
-	ColorReset  = "\033[0m"
-	ColorRed    = "\033[31m"
-	ColorGreen  = "\033[32m"
-	ColorYellow = "\033[33m"
-	ColorBlue   = "\033[34m"
-	ColorPurple = "\033[35m"
-	ColorCyan   = "\033[36m"
-	ColorWhite  = "\033[37m"
-	ColorBold   = "\033[1m"
-	ColorDim    = "\033[2m"
-)
-
-// Terminal formatting helpers
-func colorize(text, color string) string {
-	return color + text + ColorReset
-}
-
-func bold(text string) string {
-	return ColorBold + text + ColorReset
-}
-
-func dim(text string) string {
-	return ColorDim + text + ColorReset
-}
-
 // OutputFormat represents the supported output formats
 type OutputFormat string
 
 const (
-	FormatTable OutputFormat = "table"
-	FormatJSON  OutputFormat = "json"
-	FormatYAML  OutputFormat = "yaml"
+	FormatTable      OutputFormat = "table"
+	FormatJSON       OutputFormat = "json"
+	FormatYAML       OutputFormat = "yaml"
+	FormatSARIF      OutputFormat = "sarif"
+	FormatJUnit      OutputFormat = "junit"
+	FormatHTML       OutputFormat = "html"
+	FormatMarkdown   OutputFormat = "markdown"
+	FormatNDJSON     OutputFormat = "ndjson"
+	FormatPrometheus OutputFormat = "prometheus"
 )
 
+// SchemaVersion is the version of the JSON and YAML report schema. It is
+// bumped whenever a field is removed or changes meaning.
+const SchemaVersion = "v1"
+
 // CheckResult represents a single diagnostic check result
 type CheckResult struct {
-	Name       string            `json:"name" yaml:"name"`
+	// ID is the stable ID of the registered check that produced the result,
+	// e.g. POD-IMAGE-PULL; a check may produce several results
+	ID string `json:"id" yaml:"id"`
+
+	// Name is a human-readable title and may change between releases
+	Name string `json:"name" yaml:"name"`
+
 	Status     CheckStatus       `json:"status" yaml:"status"`
+	Severity   Severity          `json:"severity" yaml:"severity"`
+	Category   string            `json:"category" yaml:"category"`
+	Resource   *ResourceRef      `json:"resource,omitempty" yaml:"resource,omitempty"`
 	Message    string            `json:"message" yaml:"message"`
 	Suggestion string            `json:"suggestion,omitempty" yaml:"suggestion,omitempty"`
 	Details    map[string]string `json:"details,omitempty" yaml:"details,omitempty"`
 	Error      string            `json:"error,omitempty" yaml:"error,omitempty"`
+	Data       DataCompleteness  `json:"data,omitempty" yaml:"data,omitempty"`
+
+	// Suppression records why a SUPPRESSED result was accepted by the
+	// baseline and its status before
+	Suppression *Suppression `json:"suppression,omitempty" yaml:"suppression,omitempty"`
+
+	// DurationSeconds is the wall-clock time of the check that produced the
+	// result; results of the same check share it
+	DurationSeconds float64 `json:"duration_seconds" yaml:"duration_seconds"`
+}
+
+// Severity ranks how serious a finding is.
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityLow      Severity = "low"
+	SeverityMedium   Severity = "medium"
+	SeverityHigh     Severity = "high"
+	SeverityCritical Severity = "critical"
+)
+
+// severities lists the severities from least to most serious.
+var severities = []Severity{SeverityInfo, SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical}
+
+// Rank returns the position of the severity from info (0) to critical (4),
+// or -1 for an unknown severity.
+func (s Severity) Rank() int {
+	for i, severity := range severities {
+		if s == severity {
+			return i
+		}
+	}
+	return -1
+}
+
+// Lower returns the next less serious severity, stopping at low.
+func (s Severity) Lower() Severity {
+	if rank := s.Rank(); rank > 1 {
+		return severities[rank-1]
+	}
+	return s
+}
+
+// ResourceRef identifies the Kubernetes object a check result is about.
+type ResourceRef struct {
+	Kind      string `json:"kind" yaml:"kind"`
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Name      string `json:"name" yaml:"name"`
+	UID       string `json:"uid,omitempty" yaml:"uid,omitempty"`
+
+	// Controller is the workload controlling an object with a generated
+	// name, e.g. the Deployment of a pod. Such names change with every
+	// rollout, so reports are compared by the controller instead.
+	Controller *ControllerRef `json:"controller,omitempty" yaml:"controller,omitempty"`
+}
+
+// ControllerRef identifies the workload controlling an object.
+type ControllerRef struct {
+	Kind string `json:"kind" yaml:"kind"`
+	Name string `json:"name" yaml:"name"`
+}
+
+// String renders the reference like kubectl, e.g. "pod/web-1".
+func (r ResourceRef) String() string {
+	return strings.ToLower(r.Kind) + "/" + r.Name
+}
+
+// SetResource sets the resource of every check that does not already
+// reference one.
+func SetResource(checks []CheckResult, resource *ResourceRef) {
+	for i := range checks {
+		if checks[i].Resource == nil {
+			checks[i].Resource = resource
+		}
+	}
+}
+
+// DataCompleteness records whether a check was computed from everything it
+// needed to read or only from what the API server returned before failing.
+type DataCompleteness string
+
+const (
+	DataComplete DataCompleteness = "complete"
+	DataPartial  DataCompleteness = "partial"
+)
+
+// SetDataCompleteness stamps every check with the completeness of the data
+// it was computed from.
+func SetDataCompleteness(checks []CheckResult, partial bool) {
+	data := DataComplete
+	if partial {
+		data = DataPartial
+	}
+
+	for i := range checks {
+		checks[i].Data = data
+	}
 }
 
 // CheckStatus represents the status of a check
@@ -63,10 +155,36 @@ const (
 	StatusFailed  CheckStatus = "FAILED"
 	StatusWarning CheckStatus = "WARNING"
 	StatusSkipped CheckStatus = "SKIPPED"
+
+	// StatusSuppressed marks a failure or warning accepted by the baseline
+	StatusSuppressed CheckStatus = "SUPPRESSED"
 )
 
+// Suppression records how a baseline accepted a finding.
+type Suppression struct {
+	// Status is the status of the result before it was suppressed
+	Status        CheckStatus `json:"status" yaml:"status"`
+	Justification string      `json:"justification" yaml:"justification"`
+
+	// Expires is the last day the suppression applies, as YYYY-MM-DD
+	Expires string `json:"expires,omitempty" yaml:"expires,omitempty"`
+}
+
+// Suppressor marks the findings accepted by a baseline as suppressed.
+type Suppressor interface {
+	// Suppress changes the status of accepted failures and warnings to
+	// SUPPRESSED; contextName is empty outside multi-context runs
+	Suppress(contextName string, results []CheckResult)
+}
+
 // DiagnosticReport represents a complete diagnostic report
 type DiagnosticReport struct {
+	SchemaVersion string `json:"schema_version" yaml:"schema_version"`
+
+	// Resource is the object the report is about; it is empty for reports
+	// covering a namespace or the whole cluster
+	Resource *ResourceRef `json:"resource,omitempty" yaml:"resource,omitempty"`
+
 	ClusterInfo map[string]string      `json:"cluster_info" yaml:"cluster_info"`
 	Metadata    map[string]interface{} `json:"metadata,omitempty" yaml:"metadata,omitempty"`
 	Checks      []CheckResult          `json:"checks" yaml:"checks"`
@@ -82,30 +200,112 @@ type Summary struct {
 	Failed   int `json:"failed" yaml:"failed"`
 	Warnings int `json:"warnings" yaml:"warnings"`
 	Skipped  int `json:"skipped" yaml:"skipped"`
+
+	// Suppressed counts the findings accepted by the baseline
+	Suppressed int `json:"suppressed,omitempty" yaml:"suppressed,omitempty"`
 }
 
 // OutputManager handles formatting and outputting results
 type OutputManager struct {
 	Format  OutputFormat
 	Verbose bool
+
+	// Version is the kdebug version recorded by formats that name the tool
+	// producing them
+	Version string
+
+	// Rules describes the registered checks by ID
+	Rules map[string]Rule
+
+	// WarningsAsFailures reports warnings as failures in JUnit output
+	// instead of as system-out
+	WarningsAsFailures bool
+
+	// Context is the kubeconfig context being diagnosed in multi-context
+	// runs, recorded on streamed results
+	Context string
+
+	// Started is when the run began, reported as the run duration by the
+	// Prometheus format
+	Started time.Time
+
+	// TextfilePath is the file the Prometheus format writes to instead of
+	// stdout, for node-exporter's textfile collector
+	TextfilePath string
+
+	// Out receives the rendered output and, for the table format, the
+	// messages printed while diagnosing
+	Out io.Writer
+
+	// Err receives errors, and the messages of every other format so that
+	// the output stays parseable
+	Err io.Writer
+
+	// Color enables ANSI colors in the table format and messages
+	Color bool
+
+	// ASCII replaces emoji and box-drawing characters with plain ASCII
+	ASCII bool
+
+	// Baseline suppresses accepted findings as diagnostics finalize their
+	// results
+	Baseline Suppressor
+
+	stream *ndjsonStream
+	custom customPrinter
 }
 
-// NewOutputManager creates a new output manager
+// NewOutputManager creates a new output manager writing to stdout and
+// stderr, with colors and emoji when stdout is a terminal and NO_COLOR is
+// not set.
 func NewOutputManager(format string, verbose bool) *OutputManager {
 	outputFormat := OutputFormat(strings.ToLower(format))
 
 	// Validate format and default to table if invalid
-	switch outputFormat {
-	case FormatTable, FormatJSON, FormatYAML:
-		// Valid format
-	default:
+	if !builtinFormat(outputFormat) {
 		outputFormat = FormatTable
 	}
 
-	return &OutputManager{
+	outputMgr := &OutputManager{
 		Format:  outputFormat,
 		Verbose: verbose,
+		Started: time.Now(),
+		Out:     os.Stdout,
+		Err:     os.Stderr,
+		Color:   ColorModeAuto.Enabled(os.Stdout),
+	}
+	outputMgr.ASCII = !outputMgr.Color
+	// Custom formats also fall back to table when they do not compile
+	if custom, printer, err := parseCustomFormat(format); custom != "" && err == nil {
+		outputMgr.Format = custom
+		outputMgr.custom = printer
+	}
+	if outputFormat == FormatNDJSON {
+		outputMgr.stream = &ndjsonStream{}
 	}
+
+	return outputMgr
+}
+
+// builtinFormat reports whether a lower-case format is one of the formats
+// that take no value.
+func builtinFormat(format OutputFormat) bool {
+	switch format {
+	case FormatTable, FormatJSON, FormatYAML, FormatSARIF, FormatJUnit, FormatHTML, FormatMarkdown, FormatNDJSON, FormatPrometheus:
+		return true
+	}
+	return false
+}
+
+// KnownFormat reports whether format names an output format rather than
+// something NewOutputManager falls back to table for. Custom formats are
+// known whether or not their value compiles.
+func KnownFormat(format string) bool {
+	if builtinFormat(OutputFormat(strings.ToLower(format))) {
+		return true
+	}
+	name, _, _ := strings.Cut(strings.ToLower(format), "=")
+	return name == "template" || name == "template-file" || name == "jsonpath"
 }
 
 // PrintReport prints the diagnostic report in the specified format
@@ -115,6 +315,20 @@ func (o *OutputManager) PrintReport(report *DiagnosticReport) error {
 		return o.printJSON(report)
 	case FormatYAML:
 		return o.printYAML(report)
+	case FormatSARIF:
+		return o.printSARIF(map[string][]*DiagnosticReport{"": {report}})
+	case FormatJUnit:
+		return o.printJUnit(o.junitSuites("", []*DiagnosticReport{report}))
+	case FormatHTML:
+		return o.printHTML(reportSections([]*DiagnosticReport{report}))
+	case FormatMarkdown:
+		return o.printMarkdown(reportSections([]*DiagnosticReport{report}))
+	case FormatNDJSON:
+		return o.printNDJSONSummary([]*DiagnosticReport{report}, 0)
+	case FormatPrometheus:
+		return o.printPrometheus([]prometheusRun{{Reports: []*DiagnosticReport{report}}})
+	case FormatTemplate, FormatJSONPath:
+		return o.printCustom(report)
 	case FormatTable:
 		return o.printTable(report)
 	default:
@@ -122,19 +336,81 @@ func (o *OutputManager) PrintReport(report *DiagnosticReport) error {
 	}
 }
 
-// printJSON prints the report as JSON
-func (o *OutputManager) printJSON(report *DiagnosticReport) error {
-	encoder := json.NewEncoder(os.Stdout)
+// PrintReports prints the reports of a command that diagnosed several
+// resources. Every structured format combines them into one document, a
+// ReportSet for JSON and YAML; the table format prints each report in turn.
+// NDJSON results were streamed as they were computed, so only the summary
+// is left to print.
+func (o *OutputManager) PrintReports(reports []*DiagnosticReport) error {
+	switch o.Format {
+	case FormatJSON:
+		return o.printJSON(NewReportSet(reports))
+	case FormatYAML:
+		return o.printYAML(NewReportSet(reports))
+	case FormatSARIF:
+		return o.printSARIF(map[string][]*DiagnosticReport{"": reports})
+	case FormatJUnit:
+		return o.printJUnit(o.junitSuites("", reports))
+	case FormatHTML:
+		return o.printHTML(reportSections(reports))
+	case FormatMarkdown:
+		return o.printMarkdown(reportSections(reports))
+	case FormatNDJSON:
+		return o.printNDJSONSummary(reports, 0)
+	case FormatPrometheus:
+		return o.printPrometheus([]prometheusRun{{Reports: reports}})
+	case FormatTemplate, FormatJSONPath:
+		return o.printCustom(NewReportSet(reports))
+	}
+
+	for i, report := range reports {
+		if i > 0 {
+			fmt.Fprintln(o.Out, o.dim(strings.Repeat(o.rule(), 80)))
+		}
+		if err := o.printTable(report); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reportSection is a report rendered by a document format, with the context
+// it was diagnosed in for multi-context runs, or the error that prevented it.
+type reportSection struct {
+	Context string
+	Report  *DiagnosticReport
+	Error   string
+}
+
+// reportSections wraps the reports of a single cluster.
+func reportSections(reports []*DiagnosticReport) []reportSection {
+	sections := make([]reportSection, 0, len(reports))
+	for _, report := range reports {
+		sections = append(sections, reportSection{Report: report})
+	}
+	return sections
+}
+
+// structured reports whether the format is machine-readable, in which case
+// messages go to stderr to keep stdout parseable.
+func (o *OutputManager) structured() bool {
+	return o.Format != FormatTable
+}
+
+// printJSON prints a report or a set of reports as JSON
+func (o *OutputManager) printJSON(report interface{}) error {
+	encoder := json.NewEncoder(o.Out)
 	encoder.SetIndent("", "  ")
 	return encoder.Encode(report)
 }
 
-// printYAML prints the report as YAML
-func (o *OutputManager) printYAML(report *DiagnosticReport) error {
-	encoder := yaml.NewEncoder(os.Stdout)
+// printYAML prints a report or a set of reports as YAML
+func (o *OutputManager) printYAML(report interface{}) error {
+	encoder := yaml.NewEncoder(o.Out)
 	defer func() {
 		if err := encoder.Close(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error closing YAML encoder: %v\n", err)
+			fmt.Fprintf(o.Err, "Error closing YAML encoder: %v\n", err)
 		}
 	}()
 	return encoder.Encode(report)
@@ -143,113 +419,144 @@ func (o *OutputManager) printYAML(report *DiagnosticReport) error {
 // printTable prints the report as a pytest-style formatted output
 func (o *OutputManager) printTable(report *DiagnosticReport) error {
 	// Print clean header
-	fmt.Printf("%s\n", bold("KDEBUG KUBERNETES DIAGNOSTIC REPORT"))
-	fmt.Printf("Target: %s | Timestamp: %s\n", report.Target, report.Timestamp)
+	fmt.Fprintf(o.Out, "%s\n", o.bold("KDEBUG KUBERNETES DIAGNOSTIC REPORT"))
+	fmt.Fprintf(o.Out, "Target: %s | Timestamp: %s\n", report.Target, report.Timestamp)
 
 	// Print metadata if available
 	if len(report.Metadata) > 0 {
-		fmt.Printf("Resource: %v | Status: %v\n",
+		fmt.Fprintf(o.Out, "Resource: %v | Status: %v\n",
 			report.Metadata["pod_name"],
 			report.Metadata["status"])
 	}
 
-	fmt.Println()
+	fmt.Fprintln(o.Out)
 
 	// Print checks in pytest style
-	fmt.Printf("%s\n", bold("Diagnostic Checks:"))
-	fmt.Println()
+	fmt.Fprintf(o.Out, "%s\n", o.bold("Diagnostic Checks:"))
+	fmt.Fprintln(o.Out)
 
 	for _, check := range report.Checks {
 		status := o.formatStatusClean(check.Status)
-		fmt.Printf("%-50s %s\n", check.Name, status)
+		name := displayName(report, check)
+		if check.Data == DataPartial {
+			name += " (partial data)"
+		}
+		if o.Verbose && check.DurationSeconds > 0 {
+			status += " " + o.dim(formatDuration(check.DurationSeconds))
+		}
+		fmt.Fprintf(o.Out, "%-50s %s\n", name, status)
+		if check.Suppression != nil {
+			fmt.Fprintf(o.Out, "    %s\n", o.dim("Accepted: "+check.Suppression.Justification))
+		}
 
 		// Print detailed information if verbose and there are issues
 		if o.Verbose && (check.Status == StatusFailed || check.Status == StatusWarning) {
 			if check.Message != "" {
-				fmt.Printf("    %s\n", dim("Message: "+check.Message))
+				fmt.Fprintf(o.Out, "    %s\n", o.dim("Message: "+check.Message))
 			}
 			if check.Suggestion != "" {
-				fmt.Printf("    %s\n", dim("Suggestion: "+check.Suggestion))
+				fmt.Fprintf(o.Out, "    %s\n", o.dim("Suggestion: "+check.Suggestion))
 			}
 			if len(check.Details) > 0 {
 				for key, value := range check.Details {
-					fmt.Printf("    %s\n", dim(fmt.Sprintf("%s: %s", key, value)))
+					fmt.Fprintf(o.Out, "    %s\n", o.dim(fmt.Sprintf("%s: %s", key, value)))
 				}
 			}
-			fmt.Println()
+			fmt.Fprintln(o.Out)
 		}
 	}
 
 	// Print clean summary like pytest
-	fmt.Println()
-	fmt.Printf("%s\n", bold("Summary:"))
+	fmt.Fprintln(o.Out)
+	fmt.Fprintf(o.Out, "%s\n", o.bold("Summary:"))
 
 	// Count and categorize results
 	passed := report.Summary.Passed
 	failed := report.Summary.Failed
 	warnings := report.Summary.Warnings
 	skipped := report.Summary.Skipped
+	suppressed := report.Summary.Suppressed
 
 	// Print summary line with colors
 	summaryParts := []string{}
 	if passed > 0 {
-		summaryParts = append(summaryParts, colorize(fmt.Sprintf("%d passed", passed), ColorGreen))
+		summaryParts = append(summaryParts, o.colorize(fmt.Sprintf("%d passed", passed), ColorGreen))
 	}
 	if failed > 0 {
-		summaryParts = append(summaryParts, colorize(fmt.Sprintf("%d failed", failed), ColorRed))
+		summaryParts = append(summaryParts, o.colorize(fmt.Sprintf("%d failed", failed), ColorRed))
 	}
 	if warnings > 0 {
-		summaryParts = append(summaryParts, colorize(fmt.Sprintf("%d warnings", warnings), ColorYellow))
+		summaryParts = append(summaryParts, o.colorize(fmt.Sprintf("%d warnings", warnings), ColorYellow))
 	}
 	if skipped > 0 {
-		summaryParts = append(summaryParts, colorize(fmt.Sprintf("%d skipped", skipped), ColorCyan))
+		summaryParts = append(summaryParts, o.colorize(fmt.Sprintf("%d skipped", skipped), ColorCyan))
+	}
+	if suppressed > 0 {
+		summaryParts = append(summaryParts, o.colorize(fmt.Sprintf("%d suppressed", suppressed), ColorPurple))
 	}
 
-	fmt.Printf("%s in total\n", strings.Join(summaryParts, ", "))
+	fmt.Fprintf(o.Out, "%s in total\n", strings.Join(summaryParts, ", "))
 
 	// Print failed and warning details if not verbose
 	if !o.Verbose && (failed > 0 || warnings > 0) {
-		fmt.Println()
-		fmt.Printf("%s\n", bold("Issues found:"))
+		fmt.Fprintln(o.Out)
+		fmt.Fprintf(o.Out, "%s\n", o.bold("Issues found:"))
 
 		for _, check := range report.Checks {
 			if check.Status == StatusFailed {
-				fmt.Printf("  %s %s\n", colorize("FAILED", ColorRed), check.Name)
+				fmt.Fprintf(o.Out, "  %s %s\n", o.colorize("FAILED", ColorRed), displayName(report, check))
 				if check.Message != "" {
-					fmt.Printf("    %s\n", dim(check.Message))
+					fmt.Fprintf(o.Out, "    %s\n", o.dim(check.Message))
 				}
 			}
 		}
 
 		for _, check := range report.Checks {
 			if check.Status == StatusWarning {
-				fmt.Printf("  %s %s\n", colorize("WARNING", ColorYellow), check.Name)
+				fmt.Fprintf(o.Out, "  %s %s\n", o.colorize("WARNING", ColorYellow), displayName(report, check))
 				if check.Message != "" {
-					fmt.Printf("    %s\n", dim(check.Message))
+					fmt.Fprintf(o.Out, "    %s\n", o.dim(check.Message))
 				}
 			}
 		}
 
-		fmt.Println()
-		fmt.Printf("%s\n", dim("Run with --verbose for detailed information"))
+		fmt.Fprintln(o.Out)
+		fmt.Fprintf(o.Out, "%s\n", o.dim("Run with --verbose for detailed information"))
 	}
 
 	return nil
 }
 
+// formatDuration renders a check duration in seconds for the table, e.g.
+// "12ms" or "1.5s".
+func formatDuration(seconds float64) string {
+	return time.Duration(seconds * float64(time.Second)).Round(time.Millisecond).String()
+}
+
+// displayName returns the name a check is printed with, prefixed with its
+// resource when that is not the report's own resource.
+func displayName(report *DiagnosticReport, check CheckResult) string {
+	if check.Resource == nil || (report.Resource != nil && *check.Resource == *report.Resource) {
+		return check.Name
+	}
+	return check.Resource.String() + ": " + check.Name
+}
+
 // formatStatusClean returns a clean pytest-style status indicator
 func (o *OutputManager) formatStatusClean(status CheckStatus) string {
 	switch status {
 	case StatusPassed:
-		return colorize("PASSED", ColorGreen)
+		return o.colorize("PASSED", ColorGreen)
 	case StatusFailed:
-		return colorize("FAILED", ColorRed)
+		return o.colorize("FAILED", ColorRed)
 	case StatusWarning:
-		return colorize("WARNING", ColorYellow)
+		return o.colorize("WARNING", ColorYellow)
 	case StatusSkipped:
-		return colorize("SKIPPED", ColorCyan)
+		return o.colorize("SKIPPED", ColorCyan)
+	case StatusSuppressed:
+		return o.colorize("SUPPRESSED", ColorPurple)
 	default:
-		return colorize("UNKNOWN", ColorWhite)
+		return o.colorize("UNKNOWN", ColorWhite)
 	}
 }
 
@@ -264,6 +571,8 @@ func (o *OutputManager) formatStatus(status CheckStatus) string {
 		return "⚠️"
 	case StatusSkipped:
 		return "⏭️"
+	case StatusSuppressed:
+		return "🔕"
 	default:
 		return "❓"
 	}
@@ -271,41 +580,42 @@ func (o *OutputManager) formatStatus(status CheckStatus) string {
 
 // PrintError prints an error message
 func (o *OutputManager) PrintError(message string, err error) {
-	fmt.Fprintf(os.Stderr, "%s %s\n", colorize("ERROR:", ColorRed), message)
+	fmt.Fprintf(o.Err, "%s %s\n", o.colorize("ERROR:", ColorRed), message)
 
 	if err != nil {
 		if o.Verbose {
-			fmt.Fprintf(os.Stderr, "   Details: %v\n", err)
+			fmt.Fprintf(o.Err, "   Details: %v\n", err)
 		}
 	}
 }
 
+// PrintBlankLine separates groups of messages, on the same stream as the
+// messages themselves.
+func (o *OutputManager) PrintBlankLine() {
+	fmt.Fprintln(o.messages())
+}
+
 // PrintWarning prints a warning message
 func (o *OutputManager) PrintWarning(message string) {
-	// For structured output formats, write to stderr to avoid contaminating the output
-	if o.Format == FormatJSON || o.Format == FormatYAML {
-		fmt.Fprintf(os.Stderr, "%s %s\n", colorize("WARNING:", ColorYellow), message)
-	} else {
-		fmt.Printf("%s %s\n", colorize("WARNING:", ColorYellow), message)
-	}
+	fmt.Fprintf(o.messages(), "%s %s\n", o.colorize("WARNING:", ColorYellow), message)
 }
 
 // PrintInfo prints an informational message
 func (o *OutputManager) PrintInfo(message string) {
-	// For structured output formats, write to stderr to avoid contaminating the output
-	if o.Format == FormatJSON || o.Format == FormatYAML {
-		fmt.Fprintf(os.Stderr, "%s %s\n", colorize("INFO:", ColorCyan), message)
-	} else {
-		fmt.Printf("%s %s\n", colorize("INFO:", ColorCyan), message)
-	}
+	fmt.Fprintf(o.messages(), "%s %s\n", o.colorize("INFO:", ColorCyan), message)
 }
 
 // PrintSuccess prints a success message
 func (o *OutputManager) PrintSuccess(message string) {
-	// For structured output formats, write to stderr to avoid contaminating the output
-	if o.Format == FormatJSON || o.Format == FormatYAML {
-		fmt.Fprintf(os.Stderr, "%s %s\n", colorize("SUCCESS:", ColorGreen), message)
-	} else {
-		fmt.Printf("%s %s\n", colorize("SUCCESS:", ColorGreen), message)
+	fmt.Fprintf(o.messages(), "%s %s\n", o.colorize("SUCCESS:", ColorGreen), message)
+}
+
+// messages returns the writer for messages: the output itself for the
+// table format, and Err for structured formats to avoid contaminating the
+// output.
+func (o *OutputManager) messages() io.Writer {
+	if o.structured() {
+		return o.Err
 	}
+	return o.Out
 }
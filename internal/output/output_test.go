@@ -3,7 +3,6 @@ package output
 import (
 	"bytes"
 	"encoding/json"
-	"os"
 	"strings"
 	"testing"
 	"time"
@@ -38,27 +37,31 @@ func TestNewOutputManager(t *testing.T) {
 	}
 }
 
+func TestKnownFormat(t *testing.T) {
+	for format, want := range map[string]bool{
+		"table":                true,
+		"NDJSON":               true,
+		"template={{.target}}": true,
+		"jsonpath":             true,
+		"jsn":                  false,
+		"":                     false,
+	} {
+		if got := KnownFormat(format); got != want {
+			t.Errorf("KnownFormat(%q) = %v, want %v", format, got, want)
+		}
+	}
+}
+
 func TestDiagnosticReport_JSON(t *testing.T) {
 	report := createTestReport()
 	om := NewOutputManager("json", false)
 
-	// Capture stdout
-	old := os.Stdout
-	r, w, _ := os.Pipe()
-	os.Stdout = w
-
-	err := om.PrintReport(report)
-	_ = w.Close() // ignore close error in test
-	os.Stdout = old
+	buf := new(bytes.Buffer)
+	om.Out = buf
 
-	if err != nil {
+	if err := om.PrintReport(report); err != nil {
 		t.Fatalf("PrintReport() error = %v", err)
 	}
-
-	buf := new(bytes.Buffer)
-	if _, err := buf.ReadFrom(r); err != nil {
-		t.Fatalf("Failed to read output: %v", err)
-	}
 	output := buf.String()
 
 	// Validate JSON structure
@@ -80,23 +83,12 @@ func TestDiagnosticReport_YAML(t *testing.T) {
 	report := createTestReport()
 	om := NewOutputManager("yaml", false)
 
-	// Capture stdout
-	old := os.Stdout
-	r, w, _ := os.Pipe()
-	os.Stdout = w
-
-	err := om.PrintReport(report)
-	_ = w.Close() // ignore close error in test
-	os.Stdout = old
+	buf := new(bytes.Buffer)
+	om.Out = buf
 
-	if err != nil {
+	if err := om.PrintReport(report); err != nil {
 		t.Fatalf("PrintReport() error = %v", err)
 	}
-
-	buf := new(bytes.Buffer)
-	if _, err := buf.ReadFrom(r); err != nil {
-		t.Fatalf("Failed to read output: %v", err)
-	}
 	output := buf.String()
 
 	// Validate YAML structure
@@ -115,23 +107,12 @@ func TestDiagnosticReport_Table(t *testing.T) {
 	report := createTestReport()
 	om := NewOutputManager("table", false)
 
-	// Capture stdout
-	old := os.Stdout
-	r, w, _ := os.Pipe()
-	os.Stdout = w
-
-	err := om.PrintReport(report)
-	_ = w.Close() // ignore close error in test
-	os.Stdout = old
+	buf := new(bytes.Buffer)
+	om.Out = buf
 
-	if err != nil {
+	if err := om.PrintReport(report); err != nil {
 		t.Fatalf("PrintReport() error = %v", err)
 	}
-
-	buf := new(bytes.Buffer)
-	if _, err := buf.ReadFrom(r); err != nil {
-		t.Fatalf("Failed to read output: %v", err)
-	}
 	output := buf.String()
 
 	// Validate table output contains expected elements
@@ -153,23 +134,12 @@ func TestDiagnosticReport_TableVerbose(t *testing.T) {
 	report := createTestReport()
 	om := NewOutputManager("table", true)
 
-	// Capture stdout
-	old := os.Stdout
-	r, w, _ := os.Pipe()
-	os.Stdout = w
-
-	err := om.PrintReport(report)
-	_ = w.Close() // ignore close error in test
-	os.Stdout = old
+	buf := new(bytes.Buffer)
+	om.Out = buf
 
-	if err != nil {
+	if err := om.PrintReport(report); err != nil {
 		t.Fatalf("PrintReport() error = %v", err)
 	}
-
-	buf := new(bytes.Buffer)
-	if _, err := buf.ReadFrom(r); err != nil {
-		t.Fatalf("Failed to read output: %v", err)
-	}
 	output := buf.String()
 
 	// Verbose output should contain detailed information
@@ -211,63 +181,36 @@ func TestFormatStatus(t *testing.T) {
 }
 
 func TestOutputManager_PrintMessages(t *testing.T) {
-	om := NewOutputManager("table", false)
-
-	// Test each message type
 	tests := []struct {
-		name        string
-		fn          func()
-		expectEmpty bool
+		name   string
+		format string
+		fn     func(om *OutputManager)
+		stderr bool
 	}{
-		{"PrintError", func() { om.PrintError("test error", nil) }, false},
-		{"PrintWarning", func() { om.PrintWarning("test warning") }, false},
-		{"PrintInfo", func() { om.PrintInfo("test info") }, false},
-		{"PrintSuccess", func() { om.PrintSuccess("test success") }, false},
+		{"PrintError", "table", func(om *OutputManager) { om.PrintError("test error", nil) }, true},
+		{"PrintWarning", "table", func(om *OutputManager) { om.PrintWarning("test warning") }, false},
+		{"PrintInfo", "table", func(om *OutputManager) { om.PrintInfo("test info") }, false},
+		{"PrintSuccess", "table", func(om *OutputManager) { om.PrintSuccess("test success") }, false},
+		{"PrintInfo structured", "json", func(om *OutputManager) { om.PrintInfo("test info") }, true},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// For PrintError, we need to capture stderr, not stdout
-			if tt.name == "PrintError" {
-				// Capture stderr
-				old := os.Stderr
-				r, w, _ := os.Pipe()
-				os.Stderr = w
-
-				tt.fn()
-
-				_ = w.Close() // ignore close error in test
-				os.Stderr = old
-
-				buf := new(bytes.Buffer)
-				if _, err := buf.ReadFrom(r); err != nil {
-					t.Fatalf("Failed to read output: %v", err)
-				}
-				output := buf.String()
-
-				if !tt.expectEmpty && len(output) == 0 {
-					t.Errorf("%s produced no output", tt.name)
-				}
-			} else {
-				// Capture stdout for other messages
-				old := os.Stdout
-				r, w, _ := os.Pipe()
-				os.Stdout = w
-
-				tt.fn()
-
-				_ = w.Close() // ignore close error in test
-				os.Stdout = old
-
-				buf := new(bytes.Buffer)
-				if _, err := buf.ReadFrom(r); err != nil {
-					t.Fatalf("Failed to read output: %v", err)
-				}
-				output := buf.String()
-
-				if !tt.expectEmpty && len(output) == 0 {
-					t.Errorf("%s produced no output", tt.name)
-				}
+			om := NewOutputManager(tt.format, false)
+			stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+			om.Out, om.Err = stdout, stderr
+
+			tt.fn(om)
+
+			written, other := stdout, stderr
+			if tt.stderr {
+				written, other = stderr, stdout
+			}
+			if written.Len() == 0 {
+				t.Errorf("%s produced no output", tt.name)
+			}
+			if other.Len() != 0 {
+				t.Errorf("%s wrote to the wrong stream: %q", tt.name, other.String())
 			}
 		})
 	}
@@ -348,3 +291,66 @@ func createTestReport() *DiagnosticReport {
 		},
 	}
 }
+
+func TestSetDataCompleteness(t *testing.T) {
+	report := createTestReport()
+
+	SetDataCompleteness(report.Checks, true)
+	for _, check := range report.Checks {
+		if check.Data != DataPartial {
+			t.Errorf("Expected check %q to be marked partial, got %q", check.Name, check.Data)
+		}
+	}
+
+	om := NewOutputManager("table", false)
+	output := render(t, om, func() error { return om.PrintReport(report) })
+	if !strings.Contains(output, "(partial data)") {
+		t.Errorf("Expected table to flag partial data\nOutput: %s", output)
+	}
+
+	SetDataCompleteness(report.Checks, false)
+	om = NewOutputManager("json", false)
+	output = render(t, om, func() error { return om.PrintReport(report) })
+	if !strings.Contains(output, `"data": "complete"`) {
+		t.Errorf("Expected JSON to record complete data\nOutput: %s", output)
+	}
+}
+
+func TestCheckResourceDisplay(t *testing.T) {
+	pod := &ResourceRef{Kind: "Pod", Namespace: "default", Name: "web"}
+	report := createTestReport()
+	report.Resource = pod
+	SetResource(report.Checks, pod)
+	report.Checks[0].Resource = &ResourceRef{Kind: "Node", Name: "node-1"}
+
+	om := NewOutputManager("table", true)
+	output := render(t, om, func() error { return om.PrintReport(report) })
+	if !strings.Contains(output, "node/node-1: "+report.Checks[0].Name) {
+		t.Errorf("Expected a check about another resource to be prefixed with it\nOutput: %s", output)
+	}
+	if strings.Contains(output, "pod/web:") {
+		t.Errorf("Expected checks about the report's own resource not to be prefixed\nOutput: %s", output)
+	}
+
+	report.SchemaVersion = SchemaVersion
+	om = NewOutputManager("json", false)
+	output = render(t, om, func() error { return om.PrintReport(report) })
+	if !strings.Contains(output, `"schema_version": "v1"`) || !strings.Contains(output, `"kind": "Pod"`) {
+		t.Errorf("Expected JSON to carry the schema version and resource references\nOutput: %s", output)
+	}
+}
+
+func TestSeverityLower(t *testing.T) {
+	tests := map[Severity]Severity{
+		SeverityCritical: SeverityHigh,
+		SeverityHigh:     SeverityMedium,
+		SeverityMedium:   SeverityLow,
+		SeverityLow:      SeverityLow,
+		SeverityInfo:     SeverityInfo,
+	}
+	for severity, want := range tests {
+		if got := severity.Lower(); got != want {
+			t.Errorf("%s.Lower() = %s, want %s", severity, got, want)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// prometheusRun is the reports of one cluster, with the context it was
+// diagnosed in for multi-context runs, or the error that prevented it.
+type prometheusRun struct {
+	Context string
+	Reports []*DiagnosticReport
+	Error   string
+}
+
+// prometheusStatuses lists the status label values of kdebug_check_status;
+// every result has a series per status so alerts keep matching when a result
+// changes status. The other labels of a series identify the check and
+// resource only and never depend on the status.
+var prometheusStatuses = []CheckStatus{StatusPassed, StatusFailed, StatusWarning, StatusSkipped, StatusSuppressed}
+
+// prometheusLabel is a label of a series; labels with empty values are
+// omitted, which Prometheus treats the same as an empty value.
+type prometheusLabel struct {
+	Name  string
+	Value string
+}
+
+// prometheusFamily is a metric with its series in the order they were added.
+type prometheusFamily struct {
+	name   string
+	help   string
+	series []string
+}
+
+func (f *prometheusFamily) add(value float64, labels ...prometheusLabel) {
+	f.series = append(f.series, f.name+prometheusLabels(labels)+" "+strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+func (f *prometheusFamily) write(b *strings.Builder) {
+	if len(f.series) == 0 {
+		return
+	}
+
+	fmt.Fprintf(b, "# HELP %s %s\n", f.name, f.help)
+	fmt.Fprintf(b, "# TYPE %s gauge\n", f.name)
+	for _, series := range f.series {
+		b.WriteString(series)
+		b.WriteByte('\n')
+	}
+}
+
+// printPrometheus prints the runs in the Prometheus text exposition format:
+// a series per check result and status, the check durations, the number of
+// checks and reports by outcome, and when and how long kdebug ran. With
+// TextfilePath set the metrics replace that file instead, for node-exporter's
+// textfile collector.
+func (o *OutputManager) printPrometheus(runs []prometheusRun) error {
+	status := &prometheusFamily{name: "kdebug_check_status", help: "Status of a check result: 1 for its current status, 0 for the others."}
+	duration := &prometheusFamily{name: "kdebug_check_duration_seconds", help: "Wall-clock time of a check."}
+	checks := &prometheusFamily{name: "kdebug_checks", help: "Number of check results by status."}
+	reports := &prometheusFamily{name: "kdebug_reports", help: "Number of reports by their worst finding."}
+	up := &prometheusFamily{name: "kdebug_context_up", help: "Whether the context could be diagnosed."}
+	runDuration := &prometheusFamily{name: "kdebug_run_duration_seconds", help: "Wall-clock time of the kdebug run."}
+	lastRun := &prometheusFamily{name: "kdebug_last_run_timestamp_seconds", help: "Unix time the kdebug run finished."}
+
+	for _, run := range runs {
+		contextLabel := prometheusLabel{"context", run.Context}
+
+		if run.Context != "" {
+			value := 1.0
+			if run.Error != "" {
+				value = 0
+			}
+			up.add(value, contextLabel)
+		}
+		if run.Error != "" {
+			continue
+		}
+
+		results, durations := prometheusResults(run.Reports, o.Rules)
+		for _, result := range results {
+			for _, value := range prometheusStatuses {
+				series := 0.0
+				if result.status == value {
+					series = 1
+				}
+				status.add(series, append(append([]prometheusLabel{contextLabel}, result.labels...), prometheusLabel{"status", strings.ToLower(string(value))})...)
+			}
+		}
+		for _, result := range durations {
+			duration.add(result.seconds, append([]prometheusLabel{contextLabel}, result.labels...)...)
+		}
+
+		summary := NewReportSet(run.Reports).Summary
+		for _, count := range []struct {
+			status string
+			value  int
+		}{
+			{"passed", summary.Passed},
+			{"failed", summary.Failed},
+			{"warning", summary.Warnings},
+			{"skipped", summary.Skipped},
+			{"suppressed", summary.Suppressed},
+		} {
+			checks.add(float64(count.value), contextLabel, prometheusLabel{"status", count.status})
+		}
+		reports.add(float64(summary.Healthy), contextLabel, prometheusLabel{"health", "healthy"})
+		reports.add(float64(summary.Degraded), contextLabel, prometheusLabel{"health", "degraded"})
+		reports.add(float64(summary.Unhealthy), contextLabel, prometheusLabel{"health", "unhealthy"})
+	}
+
+	now := time.Now()
+	if !o.Started.IsZero() {
+		runDuration.add(now.Sub(o.Started).Seconds())
+	}
+	lastRun.add(float64(now.Unix()))
+
+	var metrics strings.Builder
+	for _, family := range []*prometheusFamily{status, duration, checks, reports, up, runDuration, lastRun} {
+		family.write(&metrics)
+	}
+
+	if o.TextfilePath != "" {
+		return writeTextfile(o.TextfilePath, metrics.String())
+	}
+
+	_, err := fmt.Fprint(o.Out, metrics.String())
+	return err
+}
+
+// prometheusResult is a check result reduced to its labels. A check may
+// report several results for the same resource, e.g. one per container; they
+// are merged into the most serious status and the longest duration so every
+// series is unique.
+type prometheusResult struct {
+	labels  []prometheusLabel
+	status  CheckStatus
+	seconds float64
+}
+
+// prometheusResults returns the status and duration series of the reports in
+// the order the checks were reported. Results are labelled with the severity
+// their check is registered with in rules, since a result's own severity
+// follows its status; checks without a rule have no severity label.
+func prometheusResults(reports []*DiagnosticReport, rules map[string]Rule) (results, durations []*prometheusResult) {
+	statusIndex := make(map[string]*prometheusResult)
+	durationIndex := make(map[string]*prometheusResult)
+
+	for _, report := range reports {
+		if report == nil {
+			continue
+		}
+
+		for _, check := range report.Checks {
+			id := check.ID
+			if id == "" {
+				id = check.Name
+			}
+
+			resource := check.Resource
+			if resource == nil {
+				resource = report.Resource
+			}
+			resourceLabels := []prometheusLabel{{"check", id}}
+			if resource != nil {
+				resourceLabels = append(resourceLabels,
+					prometheusLabel{"kind", resource.Kind},
+					prometheusLabel{"namespace", resource.Namespace},
+					prometheusLabel{"resource", resource.Name})
+			}
+
+			labels := append(append([]prometheusLabel{}, resourceLabels...),
+				prometheusLabel{"severity", string(rules[check.ID].Severity)})
+			key := prometheusLabels(labels)
+			if result, ok := statusIndex[key]; ok {
+				if prometheusRank(check.Status) > prometheusRank(result.status) {
+					result.status = check.Status
+				}
+			} else {
+				statusIndex[key] = &prometheusResult{labels: labels, status: check.Status}
+				results = append(results, statusIndex[key])
+			}
+
+			key = prometheusLabels(resourceLabels)
+			if result, ok := durationIndex[key]; ok {
+				if check.DurationSeconds > result.seconds {
+					result.seconds = check.DurationSeconds
+				}
+			} else {
+				durationIndex[key] = &prometheusResult{labels: resourceLabels, seconds: check.DurationSeconds}
+				durations = append(durations, durationIndex[key])
+			}
+		}
+	}
+
+	return results, durations
+}
+
+// prometheusRank orders statuses from passed to failed.
+func prometheusRank(status CheckStatus) int {
+	switch status {
+	case StatusFailed:
+		return 4
+	case StatusWarning:
+		return 3
+	case StatusSuppressed:
+		return 2
+	case StatusSkipped:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// prometheusLabels renders labels as {name="value",...}, leaving out empty
+// values.
+func prometheusLabels(labels []prometheusLabel) string {
+	var parts []string
+	for _, label := range labels {
+		if label.Value != "" {
+			parts = append(parts, label.Name+`="`+prometheusEscaper.Replace(label.Value)+`"`)
+		}
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+var prometheusEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// writeTextfile replaces path with the metrics atomically, so the textfile
+// collector never reads a partially written file. The temporary file does
+// not end in .prom and is ignored by the collector.
+func writeTextfile(path, metrics string) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(file.Name()) // no-op once renamed
+	}()
+
+	if _, err := file.WriteString(metrics); err != nil {
+		_ = file.Close() // the write error is reported
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	if err := file.Chmod(0o644); err != nil {
+		_ = file.Close() // the chmod error is reported
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	return nil
+}
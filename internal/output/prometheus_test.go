@@ -0,0 +1,167 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiagnosticReport_Prometheus(t *testing.T) {
+	om := NewOutputManager("prometheus", false)
+	om.Rules = map[string]Rule{
+		"POD-IMAGE-PULL": {ID: "POD-IMAGE-PULL", Severity: SeverityHigh},
+		"POD-LOGS":       {ID: "POD-LOGS", Severity: SeverityMedium},
+	}
+
+	out := render(t, om, func() error {
+		return om.PrintReport(createTestPodReport("web"))
+	})
+
+	for _, want := range []string{
+		"# TYPE kdebug_check_status gauge\n",
+		`kdebug_check_status{check="POD-IMAGE-PULL",kind="Pod",namespace="default",resource="web",severity="high",status="failed"} 1`,
+		`kdebug_check_status{check="POD-IMAGE-PULL",kind="Pod",namespace="default",resource="web",severity="high",status="passed"} 0`,
+		`kdebug_check_status{check="POD-LOGS",kind="Pod",namespace="default",resource="web",severity="medium",status="warning"} 1`,
+		`kdebug_check_status{check="POD-NETWORK",kind="Pod",namespace="default",resource="web",status="skipped"} 1`,
+		`kdebug_check_duration_seconds{check="POD-EXISTENCE",kind="Pod",namespace="default",resource="web"} 0`,
+		`kdebug_checks{status="failed"} 1`,
+		`kdebug_reports{health="unhealthy"} 1`,
+		"kdebug_run_duration_seconds ",
+		"kdebug_last_run_timestamp_seconds ",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in output:\n%s", want, out)
+		}
+	}
+
+	if strings.Contains(out, "kdebug_context_up") {
+		t.Error("Expected no context metric for a single-cluster run")
+	}
+
+	// Four results with a series per status
+	if got := strings.Count(out, "kdebug_check_status{"); got != 20 {
+		t.Errorf("Expected 20 status series, got %d", got)
+	}
+}
+
+func TestPrometheusMergesDuplicateSeries(t *testing.T) {
+	ref := &ResourceRef{Kind: "Node", Name: "node-1"}
+	report := &DiagnosticReport{
+		Target: "cluster",
+		Checks: []CheckResult{
+			{ID: "CLUSTER-NODES", Name: "Node Pressure", Status: StatusWarning, Resource: ref, DurationSeconds: 0.5},
+			{ID: "CLUSTER-NODES", Name: "Node Pressure", Status: StatusFailed, Resource: ref, DurationSeconds: 0.5},
+			{ID: "CLUSTER-NODES", Name: "Node Pressure", Status: StatusPassed, Resource: ref, DurationSeconds: 0.5},
+		},
+	}
+
+	om := NewOutputManager("prometheus", false)
+	out := render(t, om, func() error {
+		return om.PrintReport(report)
+	})
+
+	if got := strings.Count(out, "kdebug_check_status{"); got != 5 {
+		t.Errorf("Expected duplicate results to share their series, got %d series:\n%s", got, out)
+	}
+	if !strings.Contains(out, `kdebug_check_status{check="CLUSTER-NODES",kind="Node",resource="node-1",status="failed"} 1`) {
+		t.Errorf("Expected the most serious status to win:\n%s", out)
+	}
+	if !strings.Contains(out, `kdebug_check_duration_seconds{check="CLUSTER-NODES",kind="Node",resource="node-1"} 0.5`) {
+		t.Errorf("Expected a single duration series:\n%s", out)
+	}
+}
+
+func TestPrometheusLabelsDoNotDependOnStatus(t *testing.T) {
+	om := NewOutputManager("prometheus", false)
+	om.Rules = map[string]Rule{"POD-IMAGE-PULL": {ID: "POD-IMAGE-PULL", Severity: SeverityHigh}}
+
+	ref := &ResourceRef{Kind: "Pod", Namespace: "default", Name: "web"}
+	series := func(status CheckStatus, severity Severity, name string) []string {
+		report := &DiagnosticReport{
+			Target: "pod/web",
+			Checks: []CheckResult{{ID: "POD-IMAGE-PULL", Name: name, Status: status, Severity: severity, Resource: ref}},
+		}
+		out := render(t, om, func() error {
+			return om.PrintReport(report)
+		})
+
+		var labels []string
+		for _, line := range strings.Split(out, "\n") {
+			if strings.HasPrefix(line, "kdebug_check_status{") {
+				labels = append(labels, line[:strings.LastIndex(line, " ")])
+			}
+		}
+		return labels
+	}
+
+	passed := series(StatusPassed, SeverityInfo, "Container app - Image Pull")
+	failed := series(StatusFailed, SeverityHigh, "Container app - Image Name")
+	if len(passed) != len(prometheusStatuses) || strings.Join(passed, "\n") != strings.Join(failed, "\n") {
+		t.Errorf("Expected the same label sets for a passed and a failed result, got\n%s\nand\n%s",
+			strings.Join(passed, "\n"), strings.Join(failed, "\n"))
+	}
+}
+
+func TestMultiContextReport_Prometheus(t *testing.T) {
+	om := NewOutputManager("prometheus", false)
+	out := render(t, om, func() error {
+		return om.PrintMultiContextReport(createTestMultiContextReport())
+	})
+
+	for _, want := range []string{
+		`kdebug_context_up{context="prod-eu"} 1`,
+		`kdebug_context_up{context="prod-us"} 0`,
+		`kdebug_checks{context="prod-eu",status="failed"} 1`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, `kdebug_checks{context="prod-us"`) {
+		t.Error("Expected no check counts for a context that could not be diagnosed")
+	}
+}
+
+func TestPrometheusTextfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kdebug.prom")
+	if err := os.WriteFile(path, []byte("stale\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	om := NewOutputManager("prometheus", false)
+	om.TextfilePath = path
+
+	out := render(t, om, func() error {
+		return om.PrintReport(createTestPodReport("web"))
+	})
+	if out != "" {
+		t.Errorf("Expected nothing on stdout, got %q", out)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "# HELP kdebug_check_status") {
+		t.Errorf("Expected the file to be replaced with the metrics, got:\n%s", data)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected the temporary file to be renamed, found %d files", len(entries))
+	}
+}
+
+func TestPrometheusLabels(t *testing.T) {
+	got := prometheusLabels([]prometheusLabel{{"message", "say \"hi\"\nC:\\"}, {"empty", ""}})
+	if want := `{message="say \"hi\"\nC:\\"}`; got != want {
+		t.Errorf("prometheusLabels() = %s, want %s", got, want)
+	}
+	if got := prometheusLabels([]prometheusLabel{{"context", ""}}); got != "" {
+		t.Errorf("Expected no braces without labels, got %s", got)
+	}
+}
@@ -0,0 +1,58 @@
+package output
+
+import "time"
+
+// ReportSet is the JSON and YAML document of a command that diagnosed
+// several resources, such as service --all or ingress --all: every report
+// plus the combined summary, so each invocation emits a single document.
+type ReportSet struct {
+	SchemaVersion string              `json:"schema_version" yaml:"schema_version"`
+	Timestamp     string              `json:"timestamp" yaml:"timestamp"`
+	Reports       []*DiagnosticReport `json:"reports" yaml:"reports"`
+	Summary       ReportSetSummary    `json:"summary" yaml:"summary"`
+}
+
+// ReportSetSummary totals the checks of a report set and counts its reports
+// by their worst finding.
+type ReportSetSummary struct {
+	Summary `yaml:",inline"`
+
+	// Reports is the number of reports in the set
+	Reports int `json:"reports" yaml:"reports"`
+
+	// Healthy, Degraded and Unhealthy count the reports whose worst finding
+	// is none, a warning and a failed check respectively
+	Healthy   int `json:"healthy" yaml:"healthy"`
+	Degraded  int `json:"degraded" yaml:"degraded"`
+	Unhealthy int `json:"unhealthy" yaml:"unhealthy"`
+}
+
+// NewReportSet builds a report set and totals its reports.
+func NewReportSet(reports []*DiagnosticReport) *ReportSet {
+	set := &ReportSet{
+		SchemaVersion: SchemaVersion,
+		Timestamp:     time.Now().Format(time.RFC3339),
+		Reports:       []*DiagnosticReport{},
+	}
+
+	for _, report := range reports {
+		if report == nil {
+			continue
+		}
+
+		set.Reports = append(set.Reports, report)
+		set.Summary.Add(report.Summary)
+		set.Summary.Reports++
+
+		switch {
+		case report.Summary.Failed > 0:
+			set.Summary.Unhealthy++
+		case report.Summary.Warnings > 0:
+			set.Summary.Degraded++
+		default:
+			set.Summary.Healthy++
+		}
+	}
+
+	return set
+}
@@ -0,0 +1,88 @@
+package output
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestNewReportSet(t *testing.T) {
+	healthy := &DiagnosticReport{Target: "healthy", Summary: Summary{Total: 2, Passed: 2}}
+	degraded := &DiagnosticReport{Target: "degraded", Summary: Summary{Total: 2, Passed: 1, Warnings: 1}}
+
+	set := NewReportSet([]*DiagnosticReport{healthy, nil, degraded, createTestPodReport("web")})
+
+	if set.SchemaVersion != SchemaVersion {
+		t.Errorf("Expected schema version %s, got %s", SchemaVersion, set.SchemaVersion)
+	}
+	if len(set.Reports) != 3 {
+		t.Fatalf("Expected nil reports to be dropped, got %d reports", len(set.Reports))
+	}
+
+	want := ReportSetSummary{
+		Summary:   Summary{Total: 8, Passed: 4, Failed: 1, Warnings: 2, Skipped: 1},
+		Reports:   3,
+		Healthy:   1,
+		Degraded:  1,
+		Unhealthy: 1,
+	}
+	if set.Summary != want {
+		t.Errorf("Summary = %+v, want %+v", set.Summary, want)
+	}
+}
+
+func TestPrintReports_JSON(t *testing.T) {
+	om := NewOutputManager("json", false)
+
+	out := render(t, om, func() error {
+		return om.PrintReports([]*DiagnosticReport{createTestPodReport("web"), createTestPodReport("api")})
+	})
+
+	// A single document that decodes without trailing data
+	decoder := json.NewDecoder(strings.NewReader(out))
+	var set ReportSet
+	if err := decoder.Decode(&set); err != nil {
+		t.Fatalf("Invalid JSON output: %v\n%s", err, out)
+	}
+	if decoder.More() {
+		t.Errorf("Expected a single JSON document, got:\n%s", out)
+	}
+
+	if len(set.Reports) != 2 || set.Reports[1].Target != "pod/api" {
+		t.Errorf("Expected both reports in order, got %+v", set.Reports)
+	}
+	if set.Summary.Total != 8 || set.Summary.Reports != 2 {
+		t.Errorf("Expected the combined summary, got %+v", set.Summary)
+	}
+
+	var fields struct {
+		Summary map[string]int `json:"summary"`
+	}
+	if err := json.Unmarshal([]byte(out), &fields); err != nil {
+		t.Fatal(err)
+	}
+	if fields.Summary["total"] != 8 || fields.Summary["unhealthy"] != 2 {
+		t.Errorf("Expected check totals at the top level of the summary, got %v", fields.Summary)
+	}
+}
+
+func TestPrintReports_YAMLEmpty(t *testing.T) {
+	om := NewOutputManager("yaml", false)
+
+	out := render(t, om, func() error {
+		return om.PrintReports(nil)
+	})
+
+	var set map[string]interface{}
+	if err := yaml.Unmarshal([]byte(out), &set); err != nil {
+		t.Fatalf("Invalid YAML output: %v\n%s", err, out)
+	}
+	if reports, ok := set["reports"].([]interface{}); !ok || len(reports) != 0 {
+		t.Errorf("Expected an empty report list, got %v", set["reports"])
+	}
+	if summary, ok := set["summary"].(map[string]interface{}); !ok || summary["total"] != 0 {
+		t.Errorf("Expected an inlined zero summary, got %v", set["summary"])
+	}
+}
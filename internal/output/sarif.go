@@ -0,0 +1,314 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SARIF 2.1.0 document, limited to the properties kdebug populates.
+// See https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html.
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	toolURI      = "https://github.com/timkrebs/kdebug"
+)
+
+// Rule describes a registered check for formats that catalogue the checks
+// they report on, such as SARIF.
+type Rule struct {
+	ID          string
+	Description string
+	Category    string
+	Severity    Severity
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool              sarifTool               `json:"tool"`
+	AutomationDetails *sarifAutomationDetails `json:"automationDetails,omitempty"`
+	Results           []sarifResult           `json:"results"`
+	Properties        map[string]string       `json:"properties,omitempty"`
+}
+
+type sarifAutomationDetails struct {
+	ID string `json:"id"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+	Properties           sarifRuleProps     `json:"properties"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifRuleProps struct {
+	Tags            []string `json:"tags,omitempty"`
+	ProblemSeverity string   `json:"problem.severity"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string             `json:"ruleId"`
+	RuleIndex           int                `json:"ruleIndex"`
+	Kind                string             `json:"kind"`
+	Level               string             `json:"level"`
+	Message             sarifMessage       `json:"message"`
+	Locations           []sarifLocation    `json:"locations"`
+	PartialFingerprints map[string]string  `json:"partialFingerprints"`
+	Suppressions        []sarifSuppression `json:"suppressions,omitempty"`
+	Properties          sarifResultProps   `json:"properties"`
+}
+
+// sarifSuppression records that a finding was accepted in a baseline file.
+type sarifSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification,omitempty"`
+}
+
+type sarifResultProps struct {
+	Name       string            `json:"name"`
+	Status     CheckStatus       `json:"status"`
+	Severity   Severity          `json:"severity"`
+	Category   string            `json:"category,omitempty"`
+	Suggestion string            `json:"suggestion,omitempty"`
+	Details    map[string]string `json:"details,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	Data       DataCompleteness  `json:"data,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// printSARIF prints the reports of each run as one SARIF document. Runs are
+// keyed by kubeconfig context; a single-cluster invocation has one run with
+// an empty key.
+func (o *OutputManager) printSARIF(runs map[string][]*DiagnosticReport) error {
+	names := make([]string, 0, len(runs))
+	for name := range runs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	log := sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{}}
+	for _, name := range names {
+		log.Runs = append(log.Runs, o.sarifRun(name, runs[name]))
+	}
+
+	encoder := json.NewEncoder(o.Out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(log)
+}
+
+// sarifRun converts the non-passing results of reports into a SARIF run with
+// one rule per check.
+func (o *OutputManager) sarifRun(contextName string, reports []*DiagnosticReport) sarifRun {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "kdebug",
+			Version:        o.Version,
+			InformationURI: toolURI,
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+	if contextName != "" {
+		run.AutomationDetails = &sarifAutomationDetails{ID: "kdebug/" + contextName + "/"}
+		run.Properties = map[string]string{"context": contextName}
+	}
+
+	ruleIndex := make(map[string]int)
+	for _, report := range reports {
+		for _, check := range report.Checks {
+			if check.Status == StatusPassed {
+				continue
+			}
+
+			index, ok := ruleIndex[check.ID]
+			if !ok {
+				index = len(run.Tool.Driver.Rules)
+				ruleIndex[check.ID] = index
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, o.sarifRule(check))
+			}
+
+			run.Results = append(run.Results, sarifResultFor(check, index, report))
+		}
+	}
+
+	return run
+}
+
+// sarifRule describes the check that produced a result, from the registered
+// rules when available.
+func (o *OutputManager) sarifRule(check CheckResult) sarifRule {
+	rule, ok := o.Rules[check.ID]
+	if !ok {
+		rule = Rule{ID: check.ID, Description: check.Name, Category: check.Category, Severity: check.Severity}
+	}
+
+	sarif := sarifRule{
+		ID:                   check.ID,
+		ShortDescription:     sarifMessage{Text: rule.Description},
+		DefaultConfiguration: sarifConfiguration{Level: defaultLevel(rule.Severity)},
+		Properties:           sarifRuleProps{ProblemSeverity: problemSeverity(rule.Severity)},
+	}
+	if rule.Category != "" {
+		sarif.Properties.Tags = []string{"kubernetes", rule.Category}
+	}
+
+	return sarif
+}
+
+// sarifResultFor converts a non-passing check result.
+func sarifResultFor(check CheckResult, ruleIndex int, report *DiagnosticReport) sarifResult {
+	// Suppressed findings keep the level of the status they were accepted
+	// with, and are marked as suppressed instead
+	status := check.Status
+	var suppressions []sarifSuppression
+	if check.Suppression != nil {
+		status = check.Suppression.Status
+		suppressions = []sarifSuppression{{Kind: "external", Justification: check.Suppression.Justification}}
+	}
+
+	kind, level := "fail", "error"
+	switch status {
+	case StatusWarning:
+		level = "warning"
+	case StatusSkipped:
+		kind, level = "notApplicable", "none"
+	}
+
+	text := check.Message
+	if text == "" {
+		text = check.Name
+	}
+	if check.Suggestion != "" {
+		text += "\nSuggestion: " + check.Suggestion
+	}
+
+	resource := check.Resource
+	if resource == nil {
+		resource = report.Resource
+	}
+	uri, qualifiedName, locationKind := "cluster", "cluster", "cluster"
+	if resource != nil {
+		uri = resourcePath(resource)
+		qualifiedName = uri
+		locationKind = strings.ToLower(resource.Kind)
+	}
+
+	return sarifResult{
+		RuleID:    check.ID,
+		RuleIndex: ruleIndex,
+		Kind:      kind,
+		Level:     level,
+		Message:   sarifMessage{Text: text},
+		Locations: []sarifLocation{{
+			PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: uri}},
+			LogicalLocations: []sarifLogicalLocation{{Name: locationName(resource), FullyQualifiedName: qualifiedName, Kind: locationKind}},
+		}},
+		PartialFingerprints: map[string]string{
+			"kdebugFinding/v1": fmt.Sprintf("%s|%s|%s", check.ID, qualifiedName, check.Name),
+		},
+		Suppressions: suppressions,
+		Properties: sarifResultProps{
+			Name:       check.Name,
+			Status:     check.Status,
+			Severity:   check.Severity,
+			Category:   check.Category,
+			Suggestion: check.Suggestion,
+			Details:    check.Details,
+			Error:      check.Error,
+			Data:       check.Data,
+		},
+	}
+}
+
+// resourcePath renders a resource like its API path, e.g.
+// "namespaces/default/pods/web" or "nodes/node-1".
+func resourcePath(resource *ResourceRef) string {
+	plural := strings.ToLower(resource.Kind)
+	if strings.HasSuffix(plural, "s") {
+		plural += "es"
+	} else {
+		plural += "s"
+	}
+
+	path := plural + "/" + resource.Name
+	if resource.Namespace != "" {
+		path = "namespaces/" + resource.Namespace + "/" + path
+	}
+	return path
+}
+
+func locationName(resource *ResourceRef) string {
+	if resource == nil {
+		return "cluster"
+	}
+	return resource.Name
+}
+
+// defaultLevel maps the severity of a check's failures to a SARIF level.
+func defaultLevel(severity Severity) string {
+	switch severity {
+	case SeverityCritical, SeverityHigh:
+		return "error"
+	case SeverityMedium:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// problemSeverity maps a severity to the problem.severity property code
+// scanning dashboards use to rank rules.
+func problemSeverity(severity Severity) string {
+	switch severity {
+	case SeverityCritical, SeverityHigh:
+		return "error"
+	case SeverityMedium:
+		return "warning"
+	default:
+		return "recommendation"
+	}
+}
@@ -0,0 +1,190 @@
+package output
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func createTestPodReport(name string) *DiagnosticReport {
+	ref := &ResourceRef{Kind: "Pod", Namespace: "default", Name: name}
+	return &DiagnosticReport{
+		Target:   "pod/" + name,
+		Resource: ref,
+		Summary:  Summary{Total: 4, Passed: 1, Failed: 1, Warnings: 1, Skipped: 1},
+		Checks: []CheckResult{
+			{ID: "POD-EXISTENCE", Name: "Pod Existence", Status: StatusPassed, Severity: SeverityInfo, Resource: ref},
+			{
+				ID:         "POD-IMAGE-PULL",
+				Name:       "Image Pull",
+				Status:     StatusFailed,
+				Severity:   SeverityHigh,
+				Category:   "runtime",
+				Message:    "Image not found",
+				Suggestion: "Check the image name",
+				Details:    map[string]string{"image": "nginx:missing"},
+				Resource:   ref,
+			},
+			{ID: "POD-LOGS", Name: "Container Logs", Status: StatusWarning, Severity: SeverityLow, Resource: ref},
+			{ID: "POD-NETWORK", Name: "Network", Status: StatusSkipped, Severity: SeverityInfo, Resource: ref},
+		},
+	}
+}
+
+func decodeSARIF(t *testing.T, out string) sarifLog {
+	t.Helper()
+
+	var log sarifLog
+	if err := json.Unmarshal([]byte(out), &log); err != nil {
+		t.Fatalf("Failed to decode SARIF output: %v\n%s", err, out)
+	}
+	if log.Version != sarifVersion {
+		t.Errorf("Expected SARIF version %s, got %s", sarifVersion, log.Version)
+	}
+
+	return log
+}
+
+func TestDiagnosticReport_SARIF(t *testing.T) {
+	om := NewOutputManager("sarif", false)
+	om.Version = "1.2.3"
+	om.Rules = map[string]Rule{
+		"POD-IMAGE-PULL": {ID: "POD-IMAGE-PULL", Description: "Container images can be pulled", Category: "runtime", Severity: SeverityHigh},
+	}
+
+	log := decodeSARIF(t, render(t, om, func() error {
+		return om.PrintReport(createTestPodReport("web"))
+	}))
+
+	if len(log.Runs) != 1 {
+		t.Fatalf("Expected 1 run, got %d", len(log.Runs))
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Version != "1.2.3" {
+		t.Errorf("Expected tool version 1.2.3, got %q", run.Tool.Driver.Version)
+	}
+	if run.AutomationDetails != nil {
+		t.Error("Expected no automation details for a single-cluster run")
+	}
+
+	// Passed results are omitted
+	if len(run.Results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(run.Results))
+	}
+
+	wantLevels := map[string]string{"POD-IMAGE-PULL": "error", "POD-LOGS": "warning", "POD-NETWORK": "none"}
+	for _, result := range run.Results {
+		if want := wantLevels[result.RuleID]; result.Level != want {
+			t.Errorf("Expected level %s for %s, got %s", want, result.RuleID, result.Level)
+		}
+		if rule := run.Tool.Driver.Rules[result.RuleIndex]; rule.ID != result.RuleID {
+			t.Errorf("Expected rule index of %s to point at its rule, got %s", result.RuleID, rule.ID)
+		}
+		if uri := result.Locations[0].PhysicalLocation.ArtifactLocation.URI; uri != "namespaces/default/pods/web" {
+			t.Errorf("Expected location namespaces/default/pods/web, got %s", uri)
+		}
+	}
+
+	failed := run.Results[0]
+	if !strings.Contains(failed.Message.Text, "Suggestion: Check the image name") {
+		t.Errorf("Expected suggestion in message, got %q", failed.Message.Text)
+	}
+	if failed.Properties.Details["image"] != "nginx:missing" {
+		t.Errorf("Expected details in properties, got %v", failed.Properties.Details)
+	}
+
+	rule := run.Tool.Driver.Rules[failed.RuleIndex]
+	if rule.ShortDescription.Text != "Container images can be pulled" {
+		t.Errorf("Expected registered rule description, got %q", rule.ShortDescription.Text)
+	}
+	if rule.Properties.ProblemSeverity != "error" {
+		t.Errorf("Expected problem.severity error, got %s", rule.Properties.ProblemSeverity)
+	}
+}
+
+func TestDiagnosticReport_SARIFSuppressed(t *testing.T) {
+	report := createTestPodReport("web")
+	report.Checks[1].Suppression = &Suppression{Status: StatusFailed, Justification: "Mirror outage, see OPS-12"}
+	report.Checks[1].Status = StatusSuppressed
+
+	om := NewOutputManager("sarif", false)
+	log := decodeSARIF(t, render(t, om, func() error {
+		return om.PrintReport(report)
+	}))
+
+	result := log.Runs[0].Results[0]
+	if result.RuleID != "POD-IMAGE-PULL" || result.Level != "error" || result.Kind != "fail" {
+		t.Errorf("Expected the suppressed failure to keep its level, got %+v", result)
+	}
+	if len(result.Suppressions) != 1 || result.Suppressions[0].Kind != "external" ||
+		result.Suppressions[0].Justification != "Mirror outage, see OPS-12" {
+		t.Errorf("Expected an external suppression, got %+v", result.Suppressions)
+	}
+	if len(log.Runs[0].Results[1].Suppressions) != 0 {
+		t.Error("Expected no suppressions on other results")
+	}
+}
+
+func TestPrintReports_SARIF(t *testing.T) {
+	om := NewOutputManager("sarif", false)
+
+	out := render(t, om, func() error {
+		return om.PrintReports([]*DiagnosticReport{createTestPodReport("web"), createTestPodReport("api")})
+	})
+
+	log := decodeSARIF(t, out)
+	if len(log.Runs) != 1 {
+		t.Fatalf("Expected 1 run, got %d", len(log.Runs))
+	}
+
+	run := log.Runs[0]
+	if len(run.Results) != 6 {
+		t.Errorf("Expected 6 results, got %d", len(run.Results))
+	}
+	if len(run.Tool.Driver.Rules) != 3 {
+		t.Errorf("Expected rules to be shared across reports, got %d", len(run.Tool.Driver.Rules))
+	}
+
+	fingerprints := make(map[string]bool)
+	for _, result := range run.Results {
+		fingerprints[result.PartialFingerprints["kdebugFinding/v1"]] = true
+	}
+	if len(fingerprints) != 6 {
+		t.Errorf("Expected a distinct fingerprint per finding, got %d", len(fingerprints))
+	}
+}
+
+func TestMultiContextReport_SARIF(t *testing.T) {
+	om := NewOutputManager("sarif", false)
+
+	log := decodeSARIF(t, render(t, om, func() error {
+		return om.PrintMultiContextReport(createTestMultiContextReport())
+	}))
+
+	if len(log.Runs) != 2 {
+		t.Fatalf("Expected a run per context, got %d", len(log.Runs))
+	}
+	if log.Runs[0].Properties["context"] != "prod-eu" || log.Runs[1].Properties["context"] != "prod-us" {
+		t.Errorf("Expected runs in context order, got %v and %v", log.Runs[0].Properties, log.Runs[1].Properties)
+	}
+	if len(log.Runs[0].Results) != 2 {
+		t.Errorf("Expected 2 results for prod-eu, got %d", len(log.Runs[0].Results))
+	}
+}
+
+func TestResourcePath(t *testing.T) {
+	tests := []struct {
+		ref  ResourceRef
+		want string
+	}{
+		{ResourceRef{Kind: "Pod", Namespace: "default", Name: "web"}, "namespaces/default/pods/web"},
+		{ResourceRef{Kind: "Ingress", Namespace: "prod", Name: "shop"}, "namespaces/prod/ingresses/shop"},
+		{ResourceRef{Kind: "Node", Name: "node-1"}, "nodes/node-1"},
+	}
+
+	for _, tt := range tests {
+		if got := resourcePath(&tt.ref); got != tt.want {
+			t.Errorf("resourcePath(%+v) = %s, want %s", tt.ref, got, tt.want)
+		}
+	}
+}
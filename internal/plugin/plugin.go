@@ -0,0 +1,499 @@
+// Package plugin runs external check plugins: executables named
+// kdebug-check-<name> found in the plugin directories or on PATH, like
+// kubectl plugins. Plugins can be written in any language.
+//
+// kdebug runs "<plugin> describe" once to learn the checks a plugin
+// provides, and registers them with the check registry like built-in ones.
+// Each check then runs as "<plugin> run" with an Input on stdin, for every
+// object the command diagnoses, and prints its results as CheckResult JSON
+// on stdout. A plugin that fails, prints invalid output or runs out of time
+// only affects its own checks, which are reported as skipped.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"kdebug/internal/checks"
+	"kdebug/internal/client"
+	"kdebug/internal/output"
+)
+
+const (
+	// Prefix is the file name prefix of plugin executables.
+	Prefix = "kdebug-check-"
+
+	// ProtocolVersion is the version of the describe and run protocol.
+	ProtocolVersion = "1"
+
+	// DescribeTimeout bounds "<plugin> describe".
+	DescribeTimeout = 5 * time.Second
+
+	// maxOutput bounds what kdebug reads from a plugin's stdout, and
+	// maxStderr what it keeps of its stderr for error messages.
+	maxOutput = 8 << 20
+	maxStderr = 64 << 10
+)
+
+// Commands are the commands plugin checks can run with.
+var Commands = []string{"pod", "service", "ingress", "cluster"}
+
+// Plugin is a discovered plugin executable.
+type Plugin struct {
+	// Name is the file name without the prefix, e.g. image-policy
+	Name string
+
+	// Path is the absolute path of the executable
+	Path string
+}
+
+// Description is what "<plugin> describe" prints.
+type Description struct {
+	ProtocolVersion string       `json:"protocolVersion"`
+	Checks          []Definition `json:"checks"`
+}
+
+// Definition describes a check a plugin provides.
+type Definition struct {
+	// ID is the stable, upper-case identifier, e.g. ACME-IMAGE-POLICY
+	ID string `json:"id"`
+
+	// Name is the display name of results (default the ID)
+	Name string `json:"name,omitempty"`
+
+	Description string `json:"description,omitempty"`
+
+	// Command is the command that runs the check: pod, service, ingress
+	// or cluster
+	Command string `json:"command"`
+
+	// Category defaults to configuration
+	Category checks.Category `json:"category,omitempty"`
+
+	// Severity of a failed result (default medium)
+	Severity output.Severity `json:"severity,omitempty"`
+
+	// DefaultEnabled runs the check by default (default true)
+	DefaultEnabled *bool `json:"defaultEnabled,omitempty"`
+
+	Inputs      []string            `json:"inputs,omitempty"`
+	Permissions []checks.Permission `json:"permissions,omitempty"`
+}
+
+// Input is what "<plugin> run" reads on stdin.
+type Input struct {
+	ProtocolVersion string `json:"protocolVersion"`
+
+	// Check is the ID of the check to run
+	Check string `json:"check"`
+
+	Command string `json:"command"`
+
+	// Cluster describes the cluster: context, server and version, and
+	// source "snapshot" when diagnosing offline
+	Cluster map[string]string `json:"cluster"`
+
+	// Objects are the objects the command diagnoses: the pod, service or
+	// ingress, or the nodes for the cluster command
+	Objects []k8sruntime.Object `json:"objects"`
+}
+
+// Check is a check provided by a plugin.
+type Check struct {
+	checks.Check
+
+	Plugin *Plugin
+}
+
+// DefaultDir returns the user's plugin directory next to the user's
+// configuration file, or "" when there is no home directory.
+func DefaultDir(userConfig string) string {
+	if userConfig == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(userConfig), "plugins")
+}
+
+// Discover returns the plugins in dirs and then in the directories of the
+// PATH list. A plugin found earlier shadows later ones of the same name, and
+// directories that do not exist are skipped. Plugins are returned by name.
+func Discover(dirs []string, pathList string) []*Plugin {
+	found := make(map[string]*Plugin)
+	for _, dir := range append(slices.Clone(dirs), filepath.SplitList(pathList)...) {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			name, ok := pluginName(entry.Name())
+			if !ok || found[name] != nil {
+				continue
+			}
+			path, err := filepath.Abs(filepath.Join(dir, entry.Name()))
+			if err != nil || !executable(path) {
+				continue
+			}
+			found[name] = &Plugin{Name: name, Path: path}
+		}
+	}
+
+	plugins := make([]*Plugin, 0, len(found))
+	for _, plugin := range found {
+		plugins = append(plugins, plugin)
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins
+}
+
+// pluginName returns the plugin name of an executable's file name.
+func pluginName(file string) (string, bool) {
+	if runtime.GOOS == "windows" {
+		if !strings.EqualFold(filepath.Ext(file), ".exe") {
+			return "", false
+		}
+		file = strings.TrimSuffix(file, filepath.Ext(file))
+	}
+	name := strings.TrimPrefix(file, Prefix)
+	return name, name != file && name != ""
+}
+
+// executable reports whether path is a regular file that can be executed.
+func executable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	return runtime.GOOS == "windows" || info.Mode().Perm()&0o111 != 0
+}
+
+// Load describes the plugins concurrently and returns their checks in
+// plugin order. A plugin that cannot be described, or describes an invalid
+// check, contributes no checks and an error.
+func Load(ctx context.Context, plugins []*Plugin) ([]*Check, []error) {
+	loaded := make([][]*Check, len(plugins))
+	errs := make([]error, len(plugins))
+
+	var wg sync.WaitGroup
+	for i, plugin := range plugins {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			loaded[i], errs[i] = plugin.describe(ctx)
+		}()
+	}
+	wg.Wait()
+
+	var all []*Check
+	for _, pluginChecks := range loaded {
+		all = append(all, pluginChecks...)
+	}
+	return all, nonNil(errs)
+}
+
+// describe runs "<plugin> describe" and validates the checks it describes.
+func (p *Plugin) describe(ctx context.Context) ([]*Check, error) {
+	ctx, cancel := context.WithTimeout(ctx, DescribeTimeout)
+	defer cancel()
+
+	stdout, err := p.exec(ctx, nil, "describe")
+	if err != nil {
+		return nil, err
+	}
+
+	var description Description
+	if err := json.Unmarshal(stdout, &description); err != nil {
+		return nil, fmt.Errorf("plugin %s printed an invalid description: %w", p.Path, err)
+	}
+	if description.ProtocolVersion != ProtocolVersion {
+		return nil, fmt.Errorf("plugin %s uses protocol version %q, this kdebug supports %q", p.Path, description.ProtocolVersion, ProtocolVersion)
+	}
+	if len(description.Checks) == 0 {
+		return nil, fmt.Errorf("plugin %s describes no checks", p.Path)
+	}
+
+	described := make([]*Check, 0, len(description.Checks))
+	for i, definition := range description.Checks {
+		check, err := p.check(definition)
+		if err != nil {
+			return nil, fmt.Errorf("plugin %s: checks[%d]: %w", p.Path, i, err)
+		}
+		described = append(described, check)
+	}
+	return described, nil
+}
+
+// check validates a definition and applies its defaults.
+func (p *Plugin) check(definition Definition) (*Check, error) {
+	if !checks.ValidID(definition.ID) {
+		return nil, fmt.Errorf("id %q must be upper-case letters, digits and dashes, e.g. ACME-IMAGE-POLICY", definition.ID)
+	}
+	if !slices.Contains(Commands, definition.Command) {
+		return nil, fmt.Errorf("%s: command must be one of %s, got %q", definition.ID, strings.Join(Commands, ", "), definition.Command)
+	}
+	if definition.Category == "" {
+		definition.Category = checks.CategoryConfiguration
+	}
+	if !definition.Category.Known() {
+		return nil, fmt.Errorf("%s: unknown category %q", definition.ID, definition.Category)
+	}
+	if definition.Severity == "" {
+		definition.Severity = output.SeverityMedium
+	}
+	if definition.Severity.Rank() < 0 {
+		return nil, fmt.Errorf("%s: unknown severity %q (want info, low, medium, high or critical)", definition.ID, definition.Severity)
+	}
+
+	name := definition.Name
+	if name == "" {
+		name = definition.ID
+	}
+	description := definition.Description
+	if description == "" {
+		description = name
+	}
+	check := &Check{Plugin: p}
+	check.Check = checks.Check{
+		ID:             definition.ID,
+		Name:           name,
+		Command:        definition.Command,
+		Alias:          strings.ToLower(definition.ID),
+		Category:       definition.Category,
+		Severity:       definition.Severity,
+		Description:    description,
+		DefaultEnabled: definition.DefaultEnabled == nil || *definition.DefaultEnabled,
+		Inputs:         definition.Inputs,
+		Permissions:    definition.Permissions,
+	}
+	return check, nil
+}
+
+// Register adds the checks to a registry and returns those it accepted.
+// Checks whose ID or alias is taken are left out with an error, so that a
+// plugin cannot replace a built-in check.
+func Register(registry *checks.Registry, plugins []*Check) ([]*Check, []error) {
+	var registered []*Check
+	var errs []error
+	for _, check := range plugins {
+		if err := registry.Add(check.Check); err != nil {
+			errs = append(errs, fmt.Errorf("plugin %s: %w", check.Plugin.Path, err))
+			continue
+		}
+		registered = append(registered, check)
+	}
+	return registered, errs
+}
+
+// Runnables binds the plugin checks of a command to the input of a
+// diagnostic package. objects returns the objects an input is about.
+func Runnables[T any](plugins []*Check, command string, kubeClient func(T) *client.KubernetesClient, objects func(context.Context, T) ([]k8sruntime.Object, error)) []checks.Runnable[T] {
+	var runnables []checks.Runnable[T]
+	for _, check := range plugins {
+		if check.Command != command {
+			continue
+		}
+		runnables = append(runnables, checks.Runnable[T]{
+			Check: check.Check,
+			Run: func(ctx context.Context, input T) []output.CheckResult {
+				targets, err := objects(ctx, input)
+				if err != nil {
+					return []output.CheckResult{check.skipped(fmt.Errorf("failed to read the objects to check: %w", err))}
+				}
+				return check.Run(ctx, Input{
+					Cluster: clusterInfo(ctx, kubeClient(input)),
+					Objects: targets,
+				})
+			},
+		})
+	}
+	return runnables
+}
+
+// Run runs the check with "<plugin> run" and returns the results it
+// printed, or a skipped result describing why it has none.
+func (c *Check) Run(ctx context.Context, input Input) []output.CheckResult {
+	input.ProtocolVersion = ProtocolVersion
+	input.Check = c.ID
+	input.Command = c.Command
+	if input.Objects == nil {
+		input.Objects = []k8sruntime.Object{}
+	}
+
+	stdin, err := encodeInput(input)
+	if err != nil {
+		return []output.CheckResult{c.skipped(err)}
+	}
+	stdout, err := c.Plugin.exec(ctx, stdin, "run")
+	if err != nil {
+		return []output.CheckResult{c.skipped(err)}
+	}
+	results, err := c.decodeResults(stdout)
+	if err != nil {
+		return []output.CheckResult{c.skipped(fmt.Errorf("plugin %s printed invalid results: %w", c.Plugin.Path, err))}
+	}
+	return results
+}
+
+// encodeInput encodes the input with the apiVersion and kind that typed
+// objects leave empty.
+func encodeInput(input Input) ([]byte, error) {
+	objects := make([]k8sruntime.Object, 0, len(input.Objects))
+	for _, obj := range input.Objects {
+		if gvks, _, err := scheme.Scheme.ObjectKinds(obj); err == nil && len(gvks) > 0 {
+			obj = obj.DeepCopyObject()
+			obj.GetObjectKind().SetGroupVersionKind(gvks[0])
+		}
+		objects = append(objects, obj)
+	}
+	input.Objects = objects
+
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the plugin input: %w", err)
+	}
+	return data, nil
+}
+
+// decodeResults decodes a single result or a list of results. The ID,
+// category and duration are set by kdebug, and only kdebug suppresses
+// findings.
+func (c *Check) decodeResults(stdout []byte) ([]output.CheckResult, error) {
+	stdout = bytes.TrimSpace(stdout)
+	if len(stdout) == 0 {
+		return nil, errors.New("no output")
+	}
+
+	var results []output.CheckResult
+	if stdout[0] == '[' {
+		if err := json.Unmarshal(stdout, &results); err != nil {
+			return nil, err
+		}
+	} else {
+		var result output.CheckResult
+		if err := json.Unmarshal(stdout, &result); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	for i := range results {
+		result := &results[i]
+		switch result.Status {
+		case output.StatusPassed, output.StatusFailed, output.StatusWarning, output.StatusSkipped:
+		default:
+			return nil, fmt.Errorf("results[%d]: unknown status %q", i, result.Status)
+		}
+		if result.Severity != "" && result.Severity.Rank() < 0 {
+			return nil, fmt.Errorf("results[%d]: unknown severity %q", i, result.Severity)
+		}
+		if result.Name == "" {
+			result.Name = c.Name
+		}
+		result.Suppression = nil
+	}
+	return results, nil
+}
+
+// skipped returns the result reported when the check could not run.
+func (c *Check) skipped(err error) output.CheckResult {
+	return output.CheckResult{
+		Name:       c.Name,
+		Status:     output.StatusSkipped,
+		Message:    fmt.Sprintf("Plugin %s did not return results", c.Plugin.Name),
+		Suggestion: fmt.Sprintf("Check that %s follows the kdebug plugin protocol", c.Plugin.Path),
+		Error:      err.Error(),
+		Details: map[string]string{
+			"plugin": c.Plugin.Path,
+		},
+	}
+}
+
+// exec runs the plugin with stdin and returns its stdout. The plugin is
+// killed when ctx is done.
+func (p *Plugin) exec(ctx context.Context, stdin []byte, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, p.Path, args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	stdout := &limitedBuffer{limit: maxOutput}
+	stderr := &limitedBuffer{limit: maxStderr}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.Env = append(os.Environ(), "KDEBUG_PLUGIN_PROTOCOL="+ProtocolVersion)
+	// Children that keep the output open must not hold up kdebug
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	switch {
+	case ctx.Err() != nil:
+		return nil, fmt.Errorf("plugin %s %s did not finish: %w", p.Path, args[0], ctx.Err())
+	case err != nil:
+		if message := lastLine(stderr.String()); message != "" {
+			return nil, fmt.Errorf("plugin %s %s failed: %w: %s", p.Path, args[0], err, message)
+		}
+		return nil, fmt.Errorf("plugin %s %s failed: %w", p.Path, args[0], err)
+	case stdout.truncated:
+		return nil, fmt.Errorf("plugin %s %s printed more than %d bytes", p.Path, args[0], maxOutput)
+	}
+	return stdout.Bytes(), nil
+}
+
+// clusterInfo returns the cluster information of a client, which the client
+// reads once. When the server version cannot be read, plugins still get the
+// context and server.
+func clusterInfo(ctx context.Context, kubeClient *client.KubernetesClient) map[string]string {
+	info, err := kubeClient.GetClusterInfo(ctx)
+	if err != nil {
+		return map[string]string{"context": kubeClient.Context, "server": kubeClient.Config.Host()}
+	}
+	return info
+}
+
+// limitedBuffer keeps the first limit bytes written to it. It never fails
+// a write, so that a chatty plugin is not killed by a broken pipe.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); len(p) > room {
+		b.truncated = true
+		b.Buffer.Write(p[:max(room, 0)])
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+// lastLine returns the last non-empty line of s.
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+func nonNil(errs []error) []error {
+	var kept []error
+	for _, err := range errs {
+		if err != nil {
+			kept = append(kept, err)
+		}
+	}
+	return kept
+}
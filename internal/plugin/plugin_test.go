@@ -0,0 +1,237 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"kdebug/internal/checks"
+	"kdebug/internal/client"
+	"kdebug/internal/output"
+)
+
+const describeImagePolicy = `{"protocolVersion": "1", "checks": [
+  {"id": "ACME-IMAGE-POLICY", "name": "Image Policy", "command": "pod", "category": "security", "severity": "high"},
+  {"id": "ACME-NODE-LABELS", "command": "cluster", "defaultEnabled": false}
+]}`
+
+// writePlugin writes a shell script plugin that prints describe for
+// "describe" and runs run for "run".
+func writePlugin(t *testing.T, dir, name, describe, run string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are shell scripts")
+	}
+
+	script := "#!/bin/sh\nif [ \"$1\" = describe ]; then\ncat <<'EOF'\n" + describe + "\nEOF\nexit 0\nfi\n" + run + "\n"
+	path := filepath.Join(dir, Prefix+name)
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func loadPlugin(t *testing.T, run string) []*Check {
+	t.Helper()
+	dir := t.TempDir()
+	writePlugin(t, dir, "image-policy", describeImagePolicy, run)
+
+	loaded, errs := Load(context.Background(), Discover([]string{dir}, ""))
+	if len(errs) > 0 {
+		t.Fatalf("Load() unexpected errors: %v", errs)
+	}
+	return loaded
+}
+
+func TestDiscover(t *testing.T) {
+	pluginDir, pathDir := t.TempDir(), t.TempDir()
+	preferred := writePlugin(t, pluginDir, "image-policy", describeImagePolicy, "")
+	writePlugin(t, pathDir, "image-policy", describeImagePolicy, "")
+	writePlugin(t, pathDir, "quotas", describeImagePolicy, "")
+	if err := os.WriteFile(filepath.Join(pathDir, Prefix+"notes"), []byte("not executable"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pathDir, "kubectl-check-x"), []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	plugins := Discover([]string{pluginDir, filepath.Join(pluginDir, "missing")}, pathDir)
+	if len(plugins) != 2 {
+		t.Fatalf("Expected 2 plugins, got %+v", plugins)
+	}
+	if plugins[0].Name != "image-policy" || plugins[0].Path != preferred {
+		t.Errorf("Expected the plugin directory to shadow PATH, got %+v", plugins[0])
+	}
+	if plugins[1].Name != "quotas" {
+		t.Errorf("Expected the quotas plugin from PATH, got %+v", plugins[1])
+	}
+}
+
+func TestLoad(t *testing.T) {
+	loaded := loadPlugin(t, "")
+	if len(loaded) != 2 {
+		t.Fatalf("Expected 2 checks, got %d", len(loaded))
+	}
+
+	policy := loaded[0]
+	if policy.Command != "pod" || policy.Alias != "acme-image-policy" || policy.Category != checks.CategorySecurity ||
+		policy.Severity != output.SeverityHigh || !policy.DefaultEnabled || policy.Name != "Image Policy" {
+		t.Errorf("Unexpected check: %+v", policy.Check)
+	}
+	labels := loaded[1]
+	if labels.Category != checks.CategoryConfiguration || labels.Severity != output.SeverityMedium || labels.DefaultEnabled || labels.Description != "ACME-NODE-LABELS" {
+		t.Errorf("Expected defaults for the second check, got %+v", labels.Check)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := map[string]string{
+		"invalid JSON":     `{"protocolVersion": "1", "checks": [`,
+		"protocol version": `{"protocolVersion": "2", "checks": [{"id": "ACME-A", "command": "pod"}]}`,
+		"no checks":        `{"protocolVersion": "1", "checks": []}`,
+		"lower-case id":    `{"protocolVersion": "1", "checks": [{"id": "acme-a", "command": "pod"}]}`,
+		"unknown command":  `{"protocolVersion": "1", "checks": [{"id": "ACME-A", "command": "dns"}]}`,
+		"unknown severity": `{"protocolVersion": "1", "checks": [{"id": "ACME-A", "command": "pod", "severity": "urgent"}]}`,
+		"unknown category": `{"protocolVersion": "1", "checks": [{"id": "ACME-A", "command": "pod", "category": "style"}]}`,
+	}
+
+	for name, describe := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := writePlugin(t, dir, "broken", describe, "")
+			writePlugin(t, dir, "image-policy", describeImagePolicy, "")
+
+			loaded, errs := Load(context.Background(), Discover([]string{dir}, ""))
+			if len(errs) != 1 || !strings.Contains(errs[0].Error(), path) {
+				t.Errorf("Expected one error naming the plugin, got %v", errs)
+			}
+			if len(loaded) != 2 {
+				t.Errorf("Expected the other plugin to load, got %d checks", len(loaded))
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	loaded := loadPlugin(t, "")
+
+	registry := &checks.Registry{}
+	registry.Register(checks.Check{ID: "ACME-IMAGE-POLICY", Command: "pod", Alias: "image-policy"})
+
+	registered, errs := Register(registry, loaded)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "duplicate check ID ACME-IMAGE-POLICY") {
+		t.Errorf("Expected a duplicate ID error, got %v", errs)
+	}
+	if len(registered) != 1 || registered[0].ID != "ACME-NODE-LABELS" {
+		t.Errorf("Expected only the new check to be registered, got %+v", registered)
+	}
+}
+
+func TestRunnables(t *testing.T) {
+	inputFile := filepath.Join(t.TempDir(), "input.json")
+	loaded := loadPlugin(t, `cat > `+inputFile+`
+echo '[{"status": "FAILED", "message": "Image nginx:latest is not pinned", "severity": "critical"},
+       {"name": "Registry", "status": "PASSED", "message": "Images come from the allowed registry"}]'`)
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}}
+	kubeClient := client.NewKubernetesClientFromClientset(fake.NewClientset(pod), client.StaticConfig{Server: "https://cluster.example"}, "prod")
+
+	runnables := Runnables(loaded, "pod", func(*corev1.Pod) *client.KubernetesClient { return kubeClient },
+		func(_ context.Context, pod *corev1.Pod) ([]k8sruntime.Object, error) {
+			return []k8sruntime.Object{pod}, nil
+		})
+	if len(runnables) != 1 || runnables[0].ID != "ACME-IMAGE-POLICY" {
+		t.Fatalf("Expected the pod check only, got %+v", runnables)
+	}
+
+	registry := &checks.Registry{}
+	if _, errs := Register(registry, loaded); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	selection, err := registry.Select("pod", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := checks.Run(context.Background(), runnables, selection, pod)
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %+v", results)
+	}
+	if failed := results[0]; failed.ID != "ACME-IMAGE-POLICY" || failed.Name != "Image Policy" || failed.Category != "security" ||
+		failed.Severity != output.SeverityCritical || failed.Status != output.StatusFailed {
+		t.Errorf("Expected a stamped failure keeping the plugin's severity, got %+v", failed)
+	}
+	if passed := results[1]; passed.Name != "Registry" || passed.Severity != output.SeverityInfo {
+		t.Errorf("Expected a stamped pass, got %+v", passed)
+	}
+
+	data, err := os.ReadFile(inputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var input struct {
+		Input
+		Objects []map[string]any `json:"objects"`
+	}
+	if err := json.Unmarshal(data, &input); err != nil {
+		t.Fatalf("Expected JSON on stdin, got %s: %v", data, err)
+	}
+	if input.ProtocolVersion != ProtocolVersion || input.Check != "ACME-IMAGE-POLICY" || input.Command != "pod" {
+		t.Errorf("Unexpected input: %s", data)
+	}
+	if input.Cluster["context"] != "prod" || input.Cluster["server"] != "https://cluster.example" {
+		t.Errorf("Expected cluster information, got %v", input.Cluster)
+	}
+	if len(input.Objects) != 1 || input.Objects[0]["kind"] != "Pod" || input.Objects[0]["apiVersion"] != "v1" {
+		t.Errorf("Expected the pod with its kind, got %v", input.Objects)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := map[string]struct {
+		run     string
+		timeout time.Duration
+		want    string
+	}{
+		"exit status":    {run: "echo 'cannot reach the registry' >&2\nexit 2", want: "cannot reach the registry"},
+		"invalid JSON":   {run: "echo 'all good'", want: "invalid results"},
+		"no output":      {run: "exit 0", want: "no output"},
+		"unknown status": {run: `echo '{"status": "BROKEN"}'`, want: `unknown status "BROKEN"`},
+		"timeout":        {run: "sleep 5", timeout: 100 * time.Millisecond, want: "did not finish"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			check := loadPlugin(t, tt.run)[0]
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			start := time.Now()
+			results := check.Run(ctx, Input{})
+			if len(results) != 1 || results[0].Status != output.StatusSkipped || !strings.Contains(results[0].Error, tt.want) {
+				t.Errorf("Expected a skipped result with %q, got %+v", tt.want, results)
+			}
+			if results[0].Details["plugin"] != check.Plugin.Path {
+				t.Errorf("Expected the plugin path in the details, got %v", results[0].Details)
+			}
+			if elapsed := time.Since(start); elapsed > 3*time.Second {
+				t.Errorf("Expected the plugin to be stopped, took %s", elapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+// WriteArchive writes the snapshot as a gzip-compressed tarball. The output
+// is deterministic for a given snapshot: entries and objects are sorted and
+// every entry carries the manifest creation time.
+func (s *Snapshot) WriteArchive(w io.Writer) error {
+	if s.Manifest == nil {
+		return fmt.Errorf("snapshot has no manifest")
+	}
+
+	files, err := s.renderFiles()
+	if err != nil {
+		return err
+	}
+
+	modTime, err := time.Parse(time.RFC3339, s.Manifest.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("invalid manifest creation time: %w", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		header := &tar.Header{
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(len(files[name])),
+			ModTime:  modTime,
+			Typeflag: tar.TypeReg,
+			Format:   tar.FormatPAX,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		if _, err := tw.Write(files[name]); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to finalize snapshot archive: %w", err)
+	}
+	return gz.Close()
+}
+
+// renderFiles renders every file of the snapshot, keyed by archive path.
+func (s *Snapshot) renderFiles() (map[string][]byte, error) {
+	files := make(map[string][]byte)
+
+	// Objects, one List per kind, sorted by namespace and name
+	byKind := make(map[string][]runtime.Object)
+	for _, obj := range s.Objects {
+		kind := obj.GetObjectKind().GroupVersionKind().Kind
+		byKind[kind] = append(byKind[kind], obj)
+	}
+	for kind, objects := range byKind {
+		sort.SliceStable(objects, func(i, j int) bool {
+			return objectKey(objects[i]) < objectKey(objects[j])
+		})
+
+		data, err := yaml.Marshal(map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "List",
+			"items":      objects,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s objects: %w", kind, err)
+		}
+		files[objectsPath(kind)] = data
+	}
+
+	for key, logs := range s.Logs {
+		files["logs/"+key+".log"] = []byte(logs)
+	}
+
+	if s.ServerVersion != nil {
+		data, err := yaml.Marshal(map[string]interface{}{"serverVersion": s.ServerVersion})
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode server version: %w", err)
+		}
+		files[versionFile] = data
+	}
+
+	manifest := *s.Manifest
+	manifest.Logs = len(s.Logs)
+	manifest.Files = make([]string, 0, len(files))
+	for name := range files {
+		manifest.Files = append(manifest.Files, name)
+	}
+	sort.Strings(manifest.Files)
+
+	data, err := yaml.Marshal(&manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	files[manifestFile] = data
+
+	return files, nil
+}
+
+// objectKey returns the sort key of an object.
+func objectKey(obj runtime.Object) string {
+	if accessor, ok := obj.(metav1.Object); ok {
+		return accessor.GetNamespace() + "/" + accessor.GetName()
+	}
+	return ""
+}
@@ -0,0 +1,354 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"kdebug/internal/client"
+)
+
+const (
+	// FormatVersion is the snapshot layout version written by Capture.
+	// Loaders refuse snapshots with a newer format version.
+	FormatVersion = 1
+
+	manifestFile = "manifest.yaml"
+	versionFile  = "version.yaml"
+)
+
+// Manifest describes the contents of a snapshot written by `kdebug snapshot`.
+type Manifest struct {
+	FormatVersion int            `json:"formatVersion"`
+	KdebugVersion string         `json:"kdebugVersion,omitempty"`
+	CreatedAt     string         `json:"createdAt"`
+	Context       string         `json:"context,omitempty"`
+	Server        string         `json:"server,omitempty"`
+	Namespaces    []string       `json:"namespaces,omitempty"`
+	Objects       map[string]int `json:"objects"`
+	Kinds         []string       `json:"kinds,omitempty"`
+	Logs          int            `json:"logs"`
+	Files         []string       `json:"files"`
+	Warnings      []string       `json:"warnings,omitempty"`
+
+	// RedactedConfigMaps is set when the values of ConfigMaps were dropped
+	RedactedConfigMaps bool `json:"redactedConfigMaps,omitempty"`
+}
+
+// CaptureOptions controls what Capture reads from the cluster.
+type CaptureOptions struct {
+	// Namespaces to capture; empty captures all namespaces
+	Namespaces []string
+
+	// LogsFor selects the pods whose container logs are recorded, e.g. the
+	// failing ones; nil records no logs
+	LogsFor func(*corev1.Pod) bool
+
+	// LogLines bounds the number of log lines recorded per container
+	LogLines int
+
+	// MaxLogBytes bounds the size of the logs recorded per container
+	MaxLogBytes int64
+
+	// RedactConfigMaps drops the values of ConfigMaps like those of
+	// Secrets; otherwise ConfigMaps are recorded as they are
+	RedactConfigMaps bool
+
+	// KdebugVersion is recorded in the manifest
+	KdebugVersion string
+}
+
+// Capture performs the same API reads as the pod, service, ingress and
+// cluster diagnostics, lists every kind custom checks can target or relate
+// to, and records the results as a snapshot. Lists go through the client's
+// shared cache, so they are paginated, throttled and retried like the
+// diagnostics' own reads. Read failures and partial lists of optional data
+// are recorded as manifest warnings rather than aborting; the manifest's
+// kinds list only the kinds that could be listed, so that offline runs can
+// tell an empty list from one that was never captured.
+func Capture(ctx context.Context, k8sClient *client.KubernetesClient, opts CaptureOptions) (*Snapshot, error) {
+	snap := &Snapshot{
+		Logs: make(map[string]string),
+		seen: make(map[string]bool),
+		Manifest: &Manifest{
+			FormatVersion: FormatVersion,
+			KdebugVersion: opts.KdebugVersion,
+			CreatedAt:     time.Now().UTC().Format(time.RFC3339),
+			Context:       k8sClient.Context,
+			Server:        k8sClient.Config.Host(),
+			Namespaces:    opts.Namespaces,
+			Objects:       make(map[string]int),
+
+			RedactedConfigMaps: opts.RedactConfigMaps,
+		},
+	}
+
+	// Server version as read by GetClusterInfo
+	serverVersion, err := k8sClient.Discovery.ServerVersion()
+	if err != nil {
+		snap.warn("server version: %v", err)
+	} else {
+		snap.ServerVersion = serverVersion
+	}
+
+	cache := k8sClient.Cache()
+	failed := sets.New[string]()
+
+	// Cluster-scoped kinds, such as the nodes read by the node health checks
+	for _, kind := range captureKinds(false) {
+		if !snap.capture(ctx, kind.Resource, func(ctx context.Context) ([]runtime.Object, error) {
+			return cache.Objects(ctx, kind.Kind, metav1.NamespaceAll)
+		}) {
+			failed.Insert(kind.Kind)
+		}
+	}
+
+	namespaces := opts.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+
+	for _, namespace := range namespaces {
+		if err := snap.captureNamespace(ctx, k8sClient, namespace, opts, failed); err != nil {
+			return nil, err
+		}
+	}
+
+	// Control plane and DNS checks read kube-system pods regardless of the
+	// namespace being diagnosed
+	if !sets.New(namespaces...).HasAny(metav1.NamespaceAll, metav1.NamespaceSystem) {
+		snap.capture(ctx, "kube-system pods", func(ctx context.Context) ([]runtime.Object, error) {
+			return cache.Objects(ctx, "Pod", metav1.NamespaceSystem)
+		})
+	}
+
+	snap.Manifest.Kinds = sets.List(sets.New(client.KindNames()...).Difference(failed))
+
+	return snap, nil
+}
+
+// captureKinds returns the namespaced or the cluster-scoped kinds that
+// client.Cache.Objects can list.
+func captureKinds(namespaced bool) []client.Kind {
+	var kinds []client.Kind
+	for _, name := range client.KindNames() {
+		if kind, _ := client.LookupKind(name); kind.Namespaced == namespaced {
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds
+}
+
+// captureNamespace records the namespaced objects read by the diagnostics
+// and custom checks, adding the kinds that could not be listed to failed.
+func (s *Snapshot) captureNamespace(ctx context.Context, k8sClient *client.KubernetesClient, namespace string, opts CaptureOptions, failed sets.Set[string]) error {
+	cache := k8sClient.Cache()
+
+	// Pods are the primary target; failing to list them is fatal
+	podsCtx, tracker := client.TrackData(ctx)
+	pods, err := cache.Pods(podsCtx, namespace)
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+	if tracker.Partial() {
+		s.warn("pods in %q: only part of the list could be read", namespace)
+	}
+	for _, p := range pods {
+		s.record(p)
+	}
+
+	s.capture(ctx, fmt.Sprintf("events in %q", namespace), func(ctx context.Context) ([]runtime.Object, error) {
+		return runtimeObjects(cache.Events(ctx, namespace))
+	})
+	s.capture(ctx, fmt.Sprintf("endpoint slices in %q", namespace), func(ctx context.Context) ([]runtime.Object, error) {
+		return runtimeObjects(cache.EndpointSlices(ctx, namespace))
+	})
+
+	for _, kind := range captureKinds(true) {
+		// Pods and ingresses are read above and below
+		if kind.Kind == "Pod" || kind.Kind == "Ingress" {
+			continue
+		}
+		if !s.capture(ctx, fmt.Sprintf("%s in %q", kind.Resource, namespace), func(ctx context.Context) ([]runtime.Object, error) {
+			return cache.Objects(ctx, kind.Kind, namespace)
+		}) {
+			failed.Insert(kind.Kind)
+		}
+	}
+
+	ingressCtx, tracker := client.TrackData(ctx)
+	ingresses, err := cache.Ingresses(ingressCtx, namespace)
+	if err != nil {
+		s.warn("ingresses in %q: %v", namespace, err)
+		failed.Insert("Ingress")
+	}
+	if tracker.Partial() && err == nil {
+		s.warn("ingresses in %q: only part of the list could be read", namespace)
+	}
+	for _, ingress := range ingresses {
+		s.record(ingress)
+
+		// Only TLS secrets referenced by ingresses are read by the checks
+		for _, tls := range ingress.Spec.TLS {
+			if tls.SecretName == "" {
+				continue
+			}
+			secret, err := cache.Secret(ctx, ingress.Namespace, tls.SecretName)
+			if err != nil {
+				continue
+			}
+			s.record(secret)
+		}
+	}
+
+	if opts.LogsFor != nil {
+		for _, p := range pods {
+			if opts.LogsFor(p) {
+				s.captureLogs(ctx, k8sClient, p, opts)
+			}
+		}
+	}
+
+	return nil
+}
+
+// capture records the objects returned by list, warning about read failures
+// and lists that could only be read in part. It reports whether anything
+// could be listed.
+func (s *Snapshot) capture(ctx context.Context, what string, list func(ctx context.Context) ([]runtime.Object, error)) bool {
+	listCtx, tracker := client.TrackData(ctx)
+
+	objects, err := list(listCtx)
+	if err != nil {
+		s.warn("%s: %v", what, err)
+		return false
+	}
+	if tracker.Partial() {
+		s.warn("%s: only part of the list could be read", what)
+	}
+
+	for _, obj := range objects {
+		s.record(obj)
+	}
+	return true
+}
+
+// runtimeObjects converts a typed cache list to runtime objects.
+func runtimeObjects[T runtime.Object](items []T, err error) ([]runtime.Object, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]runtime.Object, len(items))
+	for i, item := range items {
+		objects[i] = item
+	}
+	return objects, nil
+}
+
+// captureLogs records bounded current (or previous) logs of every container.
+func (s *Snapshot) captureLogs(ctx context.Context, k8sClient *client.KubernetesClient, p *corev1.Pod, opts CaptureOptions) {
+	containers := make([]string, 0, len(p.Spec.Containers)+len(p.Spec.InitContainers))
+	for _, container := range p.Spec.InitContainers {
+		containers = append(containers, container.Name)
+	}
+	for _, container := range p.Spec.Containers {
+		containers = append(containers, container.Name)
+	}
+
+	for _, containerName := range containers {
+		logOptions := &corev1.PodLogOptions{Container: containerName}
+		if opts.LogLines > 0 {
+			logOptions.TailLines = int64ptr(int64(opts.LogLines))
+		}
+		if opts.MaxLogBytes > 0 {
+			logOptions.LimitBytes = int64ptr(opts.MaxLogBytes)
+		}
+
+		logs, err := readLogs(ctx, k8sClient, p, logOptions)
+		if err != nil {
+			logOptions.Previous = true
+			logs, err = readLogs(ctx, k8sClient, p, logOptions)
+		}
+		if err != nil {
+			s.warn("logs for %s/%s container %s: %v", p.Namespace, p.Name, containerName, err)
+			continue
+		}
+
+		s.Logs[logKey(p.Namespace, p.Name, containerName)] = logs
+	}
+}
+
+// readLogs streams a single container's logs.
+func readLogs(ctx context.Context, k8sClient *client.KubernetesClient, p *corev1.Pod, logOptions *corev1.PodLogOptions) (string, error) {
+	stream, err := k8sClient.Clientset.CoreV1().Pods(p.Namespace).GetLogs(p.Name, logOptions).Stream(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer stream.Close()
+
+	// Enforce the byte bound locally as well; not every server honours it
+	var reader io.Reader = stream
+	if logOptions.LimitBytes != nil {
+		reader = io.LimitReader(stream, *logOptions.LimitBytes)
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// record adds a copy of a live object to the snapshot, restoring its type
+// metadata, dropping fields that diagnostics never read and redacting
+// ConfigMaps if asked to. Cached objects are shared, so the original is left
+// untouched.
+func (s *Snapshot) record(obj runtime.Object) {
+	obj = obj.DeepCopyObject()
+	if gvks, _, err := scheme.Scheme.ObjectKinds(obj); err == nil && len(gvks) > 0 {
+		obj.GetObjectKind().SetGroupVersionKind(gvks[0])
+	}
+	if accessor, ok := obj.(metav1.Object); ok {
+		accessor.SetManagedFields(nil)
+	}
+	if configMap, ok := obj.(*corev1.ConfigMap); ok && s.Manifest.RedactedConfigMaps {
+		redactConfigMap(configMap)
+	}
+
+	before := len(s.Objects)
+	s.add(obj)
+	if len(s.Objects) > before {
+		s.Manifest.Objects[obj.GetObjectKind().GroupVersionKind().Kind]++
+	}
+}
+
+// warn records a non-fatal capture problem in the manifest.
+func (s *Snapshot) warn(format string, args ...interface{}) {
+	s.Manifest.Warnings = append(s.Manifest.Warnings, fmt.Sprintf(format, args...))
+}
+
+// objectsPath returns the archive file for a kind, e.g. "Ingress" -> "objects/ingresses.yaml".
+func objectsPath(kind string) string {
+	name := strings.ToLower(kind)
+	switch {
+	case strings.HasSuffix(name, "ss"):
+		name += "es"
+	case !strings.HasSuffix(name, "s"):
+		name += "s"
+	}
+	return "objects/" + name + ".yaml"
+}
+
+func int64ptr(i int64) *int64 {
+	return &i
+}
@@ -0,0 +1,292 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"kdebug/internal/client"
+)
+
+func newCaptureClient() *client.KubernetesClient {
+	clientset := fake.NewClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "crashing", Namespace: "prod"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+			Status:     corev1.PodStatus{Phase: corev1.PodFailed},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "healthy", Namespace: "prod"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system"},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "elsewhere", Namespace: "staging"},
+		},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "prod"}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "prod"}},
+		&networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "prod"},
+			Spec: networkingv1.IngressSpec{
+				TLS: []networkingv1.IngressTLS{{SecretName: "web-tls"}},
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-tls", Namespace: "prod"},
+			Data:       map[string][]byte{"tls.crt": []byte("cert"), "tls.key": []byte("key")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "prod"},
+			Data:       map[string][]byte{"password": []byte("hunter2")},
+		},
+	)
+	clientset.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{GitVersion: "v1.30.1"}
+
+	return client.NewKubernetesClientFromClientset(clientset, client.StaticConfig{Server: "https://fake:6443"}, "prod-cluster")
+}
+
+// isFailed selects the failed pods for log capture.
+func isFailed(p *corev1.Pod) bool {
+	return p.Status.Phase == corev1.PodFailed
+}
+
+func TestCapture(t *testing.T) {
+	snap, err := Capture(context.Background(), newCaptureClient(), CaptureOptions{
+		Namespaces:    []string{"prod"},
+		LogsFor:       isFailed,
+		LogLines:      10,
+		MaxLogBytes:   4,
+		KdebugVersion: "1.0.1",
+	})
+	if err != nil {
+		t.Fatalf("Capture() unexpected error: %v", err)
+	}
+
+	expected := map[string]int{"Node": 1, "Pod": 3, "Service": 1, "Deployment": 1, "Ingress": 1, "Secret": 1}
+	for kind, count := range expected {
+		if snap.Manifest.Objects[kind] != count {
+			t.Errorf("Expected %d %s objects, got %d", count, kind, snap.Manifest.Objects[kind])
+		}
+	}
+
+	logs, ok := snap.ContainerLogs("prod", "crashing", "app")
+	if !ok {
+		t.Fatal("Expected logs for failing pod")
+	}
+	if len(logs) > 4 {
+		t.Errorf("Expected logs bounded to 4 bytes, got %q", logs)
+	}
+	if _, ok := snap.ContainerLogs("prod", "healthy", "app"); ok {
+		t.Error("Expected no logs for healthy pod")
+	}
+}
+
+func TestCapturePaginatesLists(t *testing.T) {
+	k8sClient := newCaptureClient()
+	k8sClient.ChunkSize = 1
+	clientset := k8sClient.Clientset.(*fake.Clientset)
+
+	if _, err := Capture(context.Background(), k8sClient, CaptureOptions{Namespaces: []string{"prod"}}); err != nil {
+		t.Fatalf("Capture() unexpected error: %v", err)
+	}
+
+	lists := 0
+	for _, action := range clientset.Actions() {
+		list, ok := action.(k8stesting.ListAction)
+		if !ok {
+			continue
+		}
+		lists++
+		if limit := list.(k8stesting.ListActionImpl).ListOptions.Limit; limit != 1 {
+			t.Errorf("Expected %s to be listed in chunks of 1, got limit %d", action.GetResource().Resource, limit)
+		}
+	}
+	if lists == 0 {
+		t.Error("Expected Capture to list through the client")
+	}
+}
+
+func TestCaptureRecordsKinds(t *testing.T) {
+	ctx := context.Background()
+	k8sClient := newCaptureClient()
+	k8sClient.Clientset.(*fake.Clientset).PrependReactor("list", "cronjobs", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("forbidden")
+	})
+
+	snap, err := Capture(ctx, k8sClient, CaptureOptions{Namespaces: []string{"prod"}})
+	if err != nil {
+		t.Fatalf("Capture() unexpected error: %v", err)
+	}
+
+	if slices.Contains(snap.Manifest.Kinds, "CronJob") {
+		t.Error("Expected kinds that could not be listed to be left out of the manifest")
+	}
+	for _, kind := range []string{"ConfigMap", "Deployment", "Namespace", "Pod"} {
+		if !slices.Contains(snap.Manifest.Kinds, kind) {
+			t.Errorf("Expected %s in manifest kinds %v", kind, snap.Manifest.Kinds)
+		}
+	}
+
+	offline := NewClient(snap)
+
+	// Captured kinds without objects are empty lists
+	listCtx, tracker := client.TrackData(ctx)
+	configMaps, err := offline.Cache().Objects(listCtx, "ConfigMap", "prod")
+	if err != nil || len(configMaps) != 0 || tracker.Partial() {
+		t.Errorf("Expected an empty complete list of config maps, got %d objects, error %v, partial %v", len(configMaps), err, tracker.Partial())
+	}
+
+	// Kinds missing from the snapshot are partial data, not empty lists
+	listCtx, tracker = client.TrackData(ctx)
+	if _, err := offline.Cache().Objects(listCtx, "CronJob", "prod"); !errors.Is(err, ErrNotCaptured) {
+		t.Errorf("Expected ErrNotCaptured for cron jobs, got %v", err)
+	}
+	if !tracker.Partial() {
+		t.Error("Expected listing a kind missing from the snapshot to mark the data partial")
+	}
+}
+
+func TestCaptureRedactsConfigMaps(t *testing.T) {
+	ctx := context.Background()
+	k8sClient := newCaptureClient()
+	settings := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "prod"},
+		Data:       map[string]string{"DATABASE_URL": "postgres://admin:hunter2@db"},
+	}
+	if err := k8sClient.Clientset.(*fake.Clientset).Tracker().Add(settings); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, redact := range []bool{false, true} {
+		snap, err := Capture(ctx, k8sClient, CaptureOptions{Namespaces: []string{"prod"}, RedactConfigMaps: redact})
+		if err != nil {
+			t.Fatalf("Capture() unexpected error: %v", err)
+		}
+		if snap.Manifest.RedactedConfigMaps != redact {
+			t.Errorf("Expected the manifest to record redaction %v", redact)
+		}
+
+		configMap, err := NewClient(snap).Clientset.CoreV1().ConfigMaps("prod").Get(ctx, "settings", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Expected config map in snapshot: %v", err)
+		}
+		value, ok := configMap.Data["DATABASE_URL"]
+		if !ok || (value == "") != redact {
+			t.Errorf("Expected the value to be redacted only with RedactConfigMaps=%v, got %q", redact, value)
+		}
+	}
+
+	// The cache shared with the diagnostics keeps the real values
+	cached, err := k8sClient.Cache().Objects(ctx, "ConfigMap", "prod")
+	if err != nil || len(cached) != 1 || cached[0].(*corev1.ConfigMap).Data["DATABASE_URL"] == "" {
+		t.Errorf("Expected the cached config map to be left untouched, got %v, %v", cached, err)
+	}
+}
+
+func TestCaptureRoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	snap, err := Capture(ctx, newCaptureClient(), CaptureOptions{
+		Namespaces: []string{"prod"},
+		LogsFor:    isFailed,
+	})
+	if err != nil {
+		t.Fatalf("Capture() unexpected error: %v", err)
+	}
+
+	var first, second bytes.Buffer
+	if err := snap.WriteArchive(&first); err != nil {
+		t.Fatalf("WriteArchive() unexpected error: %v", err)
+	}
+	if err := snap.WriteArchive(&second); err != nil {
+		t.Fatalf("WriteArchive() unexpected error: %v", err)
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Error("Expected archive output to be reproducible")
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.tar.gz")
+	if err := os.WriteFile(path, first.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+
+	if loaded.Manifest == nil || loaded.Manifest.FormatVersion != FormatVersion {
+		t.Fatalf("Expected manifest with format version %d, got %+v", FormatVersion, loaded.Manifest)
+	}
+	if loaded.Manifest.Context != "prod-cluster" {
+		t.Errorf("Expected context prod-cluster, got %q", loaded.Manifest.Context)
+	}
+	if len(loaded.Objects) != len(snap.Objects) {
+		t.Errorf("Expected %d objects after round trip, got %d", len(snap.Objects), len(loaded.Objects))
+	}
+	if loaded.ServerVersion == nil || loaded.ServerVersion.GitVersion != "v1.30.1" {
+		t.Errorf("Expected server version v1.30.1, got %+v", loaded.ServerVersion)
+	}
+
+	offline := NewClient(loaded)
+	logs, ok := offline.RecordedLogs.ContainerLogs("prod", "crashing", "app")
+	if !ok || logs == "" {
+		t.Error("Expected recorded logs to be served by the offline client")
+	}
+
+	secret, err := offline.Clientset.CoreV1().Secrets("prod").Get(ctx, "web-tls", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected TLS secret in snapshot: %v", err)
+	}
+	if len(secret.Data["tls.crt"]) != 0 {
+		t.Error("Expected secret data to be redacted")
+	}
+	if _, err := offline.Clientset.CoreV1().Secrets("prod").Get(ctx, "unrelated", metav1.GetOptions{}); err == nil {
+		t.Error("Expected unreferenced secret to be left out of the snapshot")
+	}
+}
+
+func TestLoadRejectsNewerFormat(t *testing.T) {
+	dir := writeSnapshotDir(t)
+	if err := os.WriteFile(filepath.Join(dir, "manifest.yaml"), []byte("formatVersion: 99\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(dir); err == nil {
+		t.Error("Expected error for unsupported format version")
+	}
+}
+
+func TestObjectsPath(t *testing.T) {
+	tests := map[string]string{
+		"Pod":           "objects/pods.yaml",
+		"Ingress":       "objects/ingresses.yaml",
+		"Endpoints":     "objects/endpoints.yaml",
+		"EndpointSlice": "objects/endpointslices.yaml",
+	}
+
+	for kind, expected := range tests {
+		if got := objectsPath(kind); got != expected {
+			t.Errorf("objectsPath(%q) = %q, want %q", kind, got, expected)
+		}
+	}
+}
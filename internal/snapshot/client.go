@@ -0,0 +1,154 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"kdebug/internal/client"
+)
+
+// ErrNotCaptured is returned for lists of kinds the snapshot does not hold.
+var ErrNotCaptured = errors.New("not captured in the snapshot")
+
+// legacyKinds are the kinds listed by snapshots written before the manifest
+// recorded its kinds.
+var legacyKinds = []string{"Endpoints", "Ingress", "Node", "Pod", "Service", "ServiceAccount"}
+
+// NewClient builds a KubernetesClient that serves reads from the snapshot's
+// in-memory object store instead of a live API server. Lists of kinds the
+// snapshot does not hold fail with ErrNotCaptured, so that they mark results
+// partial instead of reading as empty.
+func NewClient(snap *Snapshot) *client.KubernetesClient {
+	clientset := fake.NewClientset(snap.Objects...)
+
+	// The object tracker ignores field selectors; diagnostics rely on them
+	// to scope event lists to a single object
+	clientset.PrependReactor("list", "events", eventFieldSelectorReactor(clientset.Tracker()))
+
+	captured := snap.capturedKinds()
+	for _, name := range client.KindNames() {
+		if captured.Has(name) {
+			continue
+		}
+		kind, _ := client.LookupKind(name)
+		clientset.PrependReactor("list", kind.Resource, func(k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, fmt.Errorf("%s: %w", kind.Resource, ErrNotCaptured)
+		})
+	}
+
+	k8sClient := client.NewKubernetesClientFromClientset(
+		clientset,
+		client.StaticConfig{Server: fmt.Sprintf("snapshot://%s", snap.Path)},
+		"snapshot",
+	)
+	k8sClient.Discovery = &offlineDiscovery{version: snap.ServerVersion}
+	k8sClient.Offline = true
+	k8sClient.RecordedLogs = snap
+
+	return k8sClient
+}
+
+// LoadClient loads a snapshot and returns a client backed by it.
+func LoadClient(path string) (*client.KubernetesClient, error) {
+	snap, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewClient(snap), nil
+}
+
+// capturedKinds returns the kinds the snapshot holds complete lists of.
+// Snapshots written by Capture record them in the manifest; for plain
+// manifest dumps only the kinds with objects are known to be present.
+func (s *Snapshot) capturedKinds() sets.Set[string] {
+	captured := sets.New[string]()
+	for _, obj := range s.Objects {
+		captured.Insert(obj.GetObjectKind().GroupVersionKind().Kind)
+	}
+
+	if s.Manifest != nil {
+		kinds := s.Manifest.Kinds
+		if kinds == nil {
+			kinds = legacyKinds
+		}
+		captured.Insert(kinds...)
+	}
+
+	return captured
+}
+
+// offlineDiscovery answers server version requests from recorded data.
+type offlineDiscovery struct {
+	version *version.Info
+}
+
+// ServerVersion returns the recorded version or client.ErrOffline.
+func (d *offlineDiscovery) ServerVersion() (*version.Info, error) {
+	if d.version == nil {
+		return nil, fmt.Errorf("server version: %w", client.ErrOffline)
+	}
+	return d.version, nil
+}
+
+// eventFieldSelectorReactor filters event lists by field selector, supporting
+// the involvedObject fields used by the diagnostics.
+func eventFieldSelectorReactor(tracker k8stesting.ObjectTracker) k8stesting.ReactionFunc {
+	return func(action k8stesting.Action) (bool, runtime.Object, error) {
+		listAction, ok := action.(k8stesting.ListAction)
+		if !ok {
+			return false, nil, nil
+		}
+
+		selector := listAction.GetListRestrictions().Fields
+		if selector == nil || selector.Empty() {
+			return false, nil, nil
+		}
+
+		obj, err := tracker.List(
+			corev1.SchemeGroupVersion.WithResource("events"),
+			corev1.SchemeGroupVersion.WithKind("Event"),
+			action.GetNamespace(),
+		)
+		if err != nil {
+			return true, nil, err
+		}
+
+		list := obj.(*corev1.EventList)
+		filtered := &corev1.EventList{ListMeta: list.ListMeta}
+		for i := range list.Items {
+			event := &list.Items[i]
+			if selector.Matches(eventFields(event)) {
+				filtered.Items = append(filtered.Items, *event)
+			}
+		}
+
+		return true, filtered, nil
+	}
+}
+
+// eventFields returns the selectable fields of an event.
+func eventFields(event *corev1.Event) fields.Set {
+	return fields.Set{
+		"metadata.name":                  event.Name,
+		"metadata.namespace":             event.Namespace,
+		"involvedObject.kind":            event.InvolvedObject.Kind,
+		"involvedObject.name":            event.InvolvedObject.Name,
+		"involvedObject.namespace":       event.InvolvedObject.Namespace,
+		"involvedObject.uid":             string(event.InvolvedObject.UID),
+		"involvedObject.apiVersion":      event.InvolvedObject.APIVersion,
+		"involvedObject.resourceVersion": event.InvolvedObject.ResourceVersion,
+		"involvedObject.fieldPath":       event.InvolvedObject.FieldPath,
+		"reason":                         event.Reason,
+		"source":                         event.Source.Component,
+		"type":                           event.Type,
+	}
+}
@@ -0,0 +1,351 @@
+// Package snapshot loads offline cluster snapshots so that diagnostics can run
+// without access to a live API server.
+//
+// A snapshot is either a directory or a gzip-compressed tarball containing
+// Kubernetes manifests in YAML or JSON. Both single objects and List documents
+// (as produced by `kubectl get -A -o yaml`) are accepted, and multi-document
+// YAML files are split automatically. Files and kinds that kdebug does not use
+// are ignored, which makes must-gather style archives work as-is.
+//
+// Snapshots written by `kdebug snapshot` additionally carry a manifest.yaml,
+// a version.yaml and bounded container logs under logs/<namespace>/<pod>/.
+package snapshot
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"kdebug/internal/client"
+)
+
+// Snapshot holds the objects loaded from a snapshot directory or archive.
+type Snapshot struct {
+	// Path is the directory or archive the snapshot was loaded from
+	Path string
+
+	// Objects are the typed objects relevant to kdebug diagnostics
+	Objects []runtime.Object
+
+	// ServerVersion is the recorded API server version, if present
+	ServerVersion *version.Info
+
+	// Manifest describes a snapshot written by `kdebug snapshot`; it is nil
+	// for plain manifest dumps
+	Manifest *Manifest
+
+	// Logs holds recorded container logs keyed by namespace/pod/container
+	Logs map[string]string
+
+	// Ignored counts documents that were skipped (unsupported kinds or
+	// files that are not Kubernetes manifests)
+	Ignored int
+
+	seen map[string]bool
+}
+
+// ContainerLogs returns the recorded logs of a container.
+func (s *Snapshot) ContainerLogs(namespace, pod, container string) (string, bool) {
+	logs, ok := s.Logs[logKey(namespace, pod, container)]
+	return logs, ok
+}
+
+// Load reads a snapshot from a directory or a .tar.gz/.tgz archive.
+func Load(path string) (*Snapshot, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+
+	snap := &Snapshot{
+		Path: path,
+		Logs: make(map[string]string),
+		seen: make(map[string]bool),
+	}
+
+	if stat.IsDir() {
+		err = snap.loadDir(path)
+	} else {
+		err = snap.loadArchive(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if snap.Manifest != nil && snap.Manifest.FormatVersion > FormatVersion {
+		return nil, fmt.Errorf("snapshot %s uses format version %d, this kdebug supports up to %d",
+			path, snap.Manifest.FormatVersion, FormatVersion)
+	}
+
+	if len(snap.Objects) == 0 {
+		return nil, fmt.Errorf("snapshot %s contains no supported Kubernetes objects", path)
+	}
+
+	return snap, nil
+}
+
+// loadDir walks a directory and loads every manifest file in it.
+func (s *Snapshot) loadDir(root string) error {
+	return filepath.WalkDir(root, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		defer file.Close()
+
+		rel, _ := filepath.Rel(root, path)
+		return s.loadFile(filepath.ToSlash(rel), file)
+	})
+}
+
+// loadArchive reads every manifest file from a gzip-compressed tarball.
+func (s *Snapshot) loadArchive(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot archive: %w", err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("snapshot %s is neither a directory nor a gzip archive: %w", path, err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read snapshot archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if err := s.loadFile(strings.TrimPrefix(header.Name, "./"), tr); err != nil {
+			return err
+		}
+	}
+}
+
+// loadFile decodes all documents from a single manifest file.
+func (s *Snapshot) loadFile(name string, r io.Reader) error {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml", ".json", ".log":
+	default:
+		return nil
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	if namespace, pod, container, ok := parseLogPath(name); ok {
+		s.Logs[logKey(namespace, pod, container)] = string(data)
+		return nil
+	}
+	if strings.EqualFold(filepath.Ext(name), ".log") {
+		return nil
+	}
+
+	if isVersionFile(name) {
+		s.ServerVersion = decodeVersion(data)
+		return nil
+	}
+
+	if isManifestFile(name) {
+		var manifest Manifest
+		if err := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096).Decode(&manifest); err != nil {
+			return fmt.Errorf("failed to parse snapshot manifest: %w", err)
+		}
+		s.Manifest = &manifest
+		return nil
+	}
+
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		var raw runtime.RawExtension
+		if err := decoder.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			// Not a manifest we understand; skip the rest of the file
+			s.Ignored++
+			return nil
+		}
+		if len(bytes.TrimSpace(raw.Raw)) == 0 || string(raw.Raw) == "null" {
+			continue
+		}
+
+		s.addRaw(raw.Raw)
+	}
+}
+
+// addRaw decodes a single document, expanding List kinds into their items.
+func (s *Snapshot) addRaw(data []byte) {
+	obj, _, err := scheme.Codecs.UniversalDeserializer().Decode(data, nil, nil)
+	if err != nil {
+		s.Ignored++
+		return
+	}
+
+	var items []runtime.RawExtension
+	switch list := obj.(type) {
+	case *corev1.List:
+		items = list.Items
+	case *metav1.List:
+		items = list.Items
+	default:
+		s.add(obj)
+		return
+	}
+
+	for _, item := range items {
+		if item.Object != nil {
+			s.add(item.Object)
+			continue
+		}
+		s.addRaw(item.Raw)
+	}
+}
+
+// add records an object if it is one of the kinds the diagnostics or custom
+// checks read.
+func (s *Snapshot) add(obj runtime.Object) {
+	var meta metav1.Object
+
+	switch o := obj.(type) {
+	case *corev1.Pod:
+		meta = o
+	case *corev1.Node:
+		meta = o
+	case *corev1.Event:
+		meta = o
+	case *corev1.Service:
+		meta = o
+	case *corev1.Endpoints:
+		meta = o
+	case *corev1.ServiceAccount:
+		meta = o
+	case *discoveryv1.EndpointSlice:
+		meta = o
+	case *networkingv1.Ingress:
+		meta = o
+	case *corev1.Secret:
+		redactSecret(o)
+		meta = o
+	default:
+		// Other kinds custom checks can target or relate to
+		accessor, err := apimeta.Accessor(obj)
+		if _, ok := client.LookupKind(obj.GetObjectKind().GroupVersionKind().Kind); !ok || err != nil {
+			s.Ignored++
+			return
+		}
+		meta = accessor
+	}
+
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	key := fmt.Sprintf("%s/%s/%s", gvk.GroupKind(), meta.GetNamespace(), meta.GetName())
+	if s.seen[key] {
+		return
+	}
+	s.seen[key] = true
+
+	s.Objects = append(s.Objects, obj)
+}
+
+// redactSecret keeps the key names of a secret but drops all values, so that
+// checks can validate structure without the snapshot holding secret material.
+func redactSecret(secret *corev1.Secret) {
+	for key := range secret.Data {
+		secret.Data[key] = []byte{}
+	}
+	for key := range secret.StringData {
+		secret.StringData[key] = ""
+	}
+	delete(secret.Annotations, corev1.LastAppliedConfigAnnotation)
+}
+
+// redactConfigMap keeps the key names of a config map but drops all values,
+// for clusters that keep credentials in config maps.
+func redactConfigMap(configMap *corev1.ConfigMap) {
+	for key := range configMap.Data {
+		configMap.Data[key] = ""
+	}
+	for key := range configMap.BinaryData {
+		configMap.BinaryData[key] = []byte{}
+	}
+	delete(configMap.Annotations, corev1.LastAppliedConfigAnnotation)
+}
+
+// isManifestFile reports whether the file is a kdebug snapshot manifest.
+// Only a manifest at the snapshot root is recognised.
+func isManifestFile(name string) bool {
+	return name == manifestFile
+}
+
+// isVersionFile reports whether the file holds the recorded server version.
+func isVersionFile(name string) bool {
+	base := strings.ToLower(filepath.Base(name))
+	return base == "version.yaml" || base == "version.yml" || base == "version.json"
+}
+
+// decodeVersion parses either a bare version.Info or `kubectl version -o yaml`
+// output. It returns nil when no server version can be found.
+func decodeVersion(data []byte) *version.Info {
+	var wrapped struct {
+		ServerVersion *version.Info `json:"serverVersion"`
+	}
+	if err := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096).Decode(&wrapped); err == nil && wrapped.ServerVersion != nil {
+		return wrapped.ServerVersion
+	}
+
+	var info version.Info
+	if err := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096).Decode(&info); err == nil && info.GitVersion != "" {
+		return &info
+	}
+
+	return nil
+}
+
+// logKey returns the key under which container logs are stored.
+func logKey(namespace, pod, container string) string {
+	return namespace + "/" + pod + "/" + container
+}
+
+// parseLogPath extracts the container identity from a logs/<ns>/<pod>/<container>.log path.
+func parseLogPath(name string) (namespace, pod, container string, ok bool) {
+	parts := strings.Split(name, "/")
+	if len(parts) != 4 || parts[0] != "logs" || !strings.HasSuffix(parts[3], ".log") {
+		return "", "", "", false
+	}
+
+	return parts[1], parts[2], strings.TrimSuffix(parts[3], ".log"), true
+}
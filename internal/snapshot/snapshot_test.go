@@ -0,0 +1,273 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kdebug/internal/client"
+)
+
+const podListYAML = `apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Pod
+  metadata:
+    name: web
+    namespace: default
+  spec:
+    containers:
+    - name: app
+      image: nginx
+  status:
+    phase: Running
+- apiVersion: v1
+  kind: Node
+  metadata:
+    name: node-1
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: web
+    namespace: default
+- apiVersion: rbac.authorization.k8s.io/v1
+  kind: Role
+  metadata:
+    name: web
+    namespace: default
+`
+
+const multiDocYAML = `apiVersion: v1
+kind: Secret
+metadata:
+  name: web-tls
+  namespace: default
+type: kubernetes.io/tls
+data:
+  tls.crt: Y2VydA==
+  tls.key: a2V5
+---
+apiVersion: v1
+kind: Event
+metadata:
+  name: web.1
+  namespace: default
+involvedObject:
+  kind: Pod
+  name: web
+reason: BackOff
+---
+apiVersion: v1
+kind: Event
+metadata:
+  name: other.1
+  namespace: default
+involvedObject:
+  kind: Pod
+  name: other
+reason: Scheduled
+`
+
+const versionYAML = `clientVersion:
+  gitVersion: v1.31.0
+serverVersion:
+  gitVersion: v1.30.2
+  platform: linux/amd64
+`
+
+func writeSnapshotDir(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"all.yaml":              podListYAML,
+		"namespaces/misc.yaml":  multiDocYAML,
+		"version.yaml":          versionYAML,
+		"README.txt":            "not a manifest",
+		"namespaces/notes.yaml": "just: some\nrandom: yaml\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestLoadDirectory(t *testing.T) {
+	snap, err := Load(writeSnapshotDir(t))
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+
+	// Pod, Node, Deployment, Secret and two Events; the Role is ignored
+	if len(snap.Objects) != 6 {
+		t.Errorf("Expected 6 objects, got %d", len(snap.Objects))
+	}
+	if snap.Ignored == 0 {
+		t.Error("Expected unsupported documents to be counted as ignored")
+	}
+	if snap.ServerVersion == nil || snap.ServerVersion.GitVersion != "v1.30.2" {
+		t.Errorf("Expected server version v1.30.2, got %+v", snap.ServerVersion)
+	}
+}
+
+func TestLoadArchive(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "snapshot.tar.gz")
+
+	file, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+	for name, content := range map[string]string{"./cluster/all.yaml": podListYAML, "./cluster/misc.yaml": multiDocYAML} {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	snap, err := Load(archive)
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if len(snap.Objects) != 6 {
+		t.Errorf("Expected 6 objects, got %d", len(snap.Objects))
+	}
+	if snap.ServerVersion != nil {
+		t.Errorf("Expected no server version, got %+v", snap.ServerVersion)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Expected error for missing snapshot")
+	}
+
+	if _, err := Load(t.TempDir()); err == nil {
+		t.Error("Expected error for empty snapshot")
+	}
+
+	notArchive := filepath.Join(t.TempDir(), "dump.tar.gz")
+	if err := os.WriteFile(notArchive, []byte("plain text"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(notArchive); err == nil {
+		t.Error("Expected error for invalid archive")
+	}
+}
+
+func TestSecretsAreRedacted(t *testing.T) {
+	snap, err := Load(writeSnapshotDir(t))
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+
+	k8sClient := NewClient(snap)
+	secret, err := k8sClient.Clientset.CoreV1().Secrets("default").Get(context.Background(), "web-tls", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected secret in store: %v", err)
+	}
+
+	for _, key := range []string{"tls.crt", "tls.key"} {
+		value, ok := secret.Data[key]
+		if !ok {
+			t.Errorf("Expected key %s to be preserved", key)
+		}
+		if len(value) != 0 {
+			t.Errorf("Expected key %s to be redacted, got %q", key, value)
+		}
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	snap, err := Load(writeSnapshotDir(t))
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+
+	k8sClient := NewClient(snap)
+	ctx := context.Background()
+
+	if !k8sClient.Offline {
+		t.Error("Expected snapshot client to be offline")
+	}
+	if err := k8sClient.TestConnection(ctx); err != nil {
+		t.Errorf("TestConnection() unexpected error: %v", err)
+	}
+
+	info, err := k8sClient.GetClusterInfo(ctx)
+	if err != nil {
+		t.Fatalf("GetClusterInfo() unexpected error: %v", err)
+	}
+	if info["gitVersion"] != "v1.30.2" || info["source"] != "snapshot" {
+		t.Errorf("Unexpected cluster info: %v", info)
+	}
+
+	pod, err := k8sClient.Clientset.CoreV1().Pods("default").Get(ctx, "web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected pod in store: %v", err)
+	}
+	if pod.Status.Phase != corev1.PodRunning {
+		t.Errorf("Expected Running pod, got %s", pod.Status.Phase)
+	}
+
+	events, err := k8sClient.Clientset.CoreV1().Events("default").List(ctx, metav1.ListOptions{
+		FieldSelector: "involvedObject.name=web,involvedObject.kind=Pod",
+	})
+	if err != nil {
+		t.Fatalf("Events list unexpected error: %v", err)
+	}
+	if len(events.Items) != 1 || events.Items[0].Name != "web.1" {
+		t.Errorf("Expected only event web.1, got %d events", len(events.Items))
+	}
+
+	// A plain dump holds only the kinds it has objects of
+	if _, err := k8sClient.Cache().Objects(ctx, "Deployment", "default"); err != nil {
+		t.Errorf("Expected deployments to be listed, got %v", err)
+	}
+	if _, err := k8sClient.Cache().Objects(ctx, "StatefulSet", "default"); !errors.Is(err, ErrNotCaptured) {
+		t.Errorf("Expected ErrNotCaptured for stateful sets, got %v", err)
+	}
+}
+
+func TestNewClientWithoutVersion(t *testing.T) {
+	k8sClient := NewClient(&Snapshot{Path: "dump"})
+
+	if _, err := k8sClient.Discovery.ServerVersion(); !errors.Is(err, client.ErrOffline) {
+		t.Errorf("Expected ErrOffline, got %v", err)
+	}
+
+	info, err := k8sClient.GetClusterInfo(context.Background())
+	if err != nil {
+		t.Fatalf("GetClusterInfo() unexpected error: %v", err)
+	}
+	if info["version"] != "unknown (offline snapshot)" {
+		t.Errorf("Expected unknown version, got %q", info["version"])
+	}
+}
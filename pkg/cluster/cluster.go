@@ -3,13 +3,20 @@ package cluster
 import (
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
 
+	"kdebug/internal/checks"
 	"kdebug/internal/client"
+	"kdebug/internal/custom"
 	"kdebug/internal/output"
+	"kdebug/internal/plugin"
 )
 
 // ClusterDiagnostic handles cluster-level health checks
@@ -26,8 +33,147 @@ func NewClusterDiagnostic(k8sClient *client.KubernetesClient, outputMgr *output.
 	}
 }
 
-// RunDiagnostics runs all cluster-level diagnostic checks
+// DiagnosticConfig selects the cluster checks to run
+type DiagnosticConfig struct {
+	// Checks lists the IDs or aliases of the checks to run (empty = the
+	// default-enabled checks)
+	Checks []string
+
+	// SkipChecks lists the IDs or aliases of checks not to run
+	SkipChecks []string
+
+	// CheckTimeout bounds each check; a check that runs out of time is
+	// reported as skipped (zero disables the per-check deadline)
+	CheckTimeout time.Duration
+
+	// SlowResponse is the API server response time above which the
+	// connectivity check warns (zero uses DefaultSlowResponse)
+	SlowResponse time.Duration
+
+	// CustomChecks are the loaded custom checks; those targeting kinds
+	// other than pods, services and ingresses run after the built-in checks
+	CustomChecks []*custom.Check
+
+	// Plugins are the checks of external plugins; those run with the
+	// cluster command get the nodes and run after the custom checks
+	Plugins []*plugin.Check
+}
+
+// DefaultSlowResponse is the API server response time above which the
+// connectivity check warns unless configured otherwise.
+const DefaultSlowResponse = 5 * time.Second
+
+var (
+	readNodes = checks.Permission{Verbs: []string{"list"}, Resource: "nodes"}
+	readPods  = checks.Permission{Verbs: []string{"list"}, Resource: "pods"}
+)
+
+// Registered cluster checks, in execution order.
+var (
+	connectivityCheck = checks.Register(checks.Check{
+		ID: "CLUSTER-CONNECTIVITY", Command: "cluster", Alias: "connectivity", Category: checks.CategoryAvailability,
+		Name:           "API Server Connectivity",
+		Severity:       output.SeverityCritical,
+		Description:    "API server reachability, version and response time",
+		DefaultEnabled: true,
+		Inputs:         []string{"server-version"},
+	})
+	nodesCheck = checks.Register(checks.Check{
+		ID: "CLUSTER-NODES", Command: "cluster", Alias: "nodes", Category: checks.CategoryAvailability,
+		Name:           "Node Health",
+		Severity:       output.SeverityHigh,
+		Description:    "Node readiness and pressure conditions",
+		DefaultEnabled: true,
+		Inputs:         []string{"nodes"},
+		Permissions:    []checks.Permission{readNodes},
+	})
+	controlPlaneCheck = checks.Register(checks.Check{
+		ID: "CLUSTER-CONTROL-PLANE", Command: "cluster", Alias: "control-plane", Category: checks.CategoryControlPlane,
+		Name:           "Control Plane Health",
+		Severity:       output.SeverityCritical,
+		Description:    "Control plane pods in kube-system (etcd, API server, scheduler, controller manager)",
+		DefaultEnabled: true,
+		Inputs:         []string{"pods"},
+		Permissions:    []checks.Permission{readPods},
+	})
+	dnsCheck = checks.Register(checks.Check{
+		ID: "CLUSTER-DNS", Command: "cluster", Alias: "dns", Category: checks.CategoryNetworking,
+		Name:           "DNS Health",
+		Severity:       output.SeverityHigh,
+		Description:    "CoreDNS or kube-dns pods in kube-system",
+		DefaultEnabled: true,
+		Inputs:         []string{"pods"},
+		Permissions:    []checks.Permission{readPods},
+	})
+)
+
+// clusterInput is the input of the cluster checks: the diagnostic and the
+// configuration of a single RunChecks call.
+type clusterInput struct {
+	*ClusterDiagnostic
+	config DiagnosticConfig
+}
+
+// clusterChecks binds the registered cluster checks to their implementations.
+// Each check tracks whether it was computed from complete data.
+var clusterChecks = []checks.Runnable[*clusterInput]{
+	{Check: connectivityCheck, Run: tracked(func(ctx context.Context, in *clusterInput) []output.CheckResult {
+		return []output.CheckResult{in.checkConnectivity(ctx, in.config.SlowResponse)}
+	})},
+	{Check: nodesCheck, Run: tracked(func(ctx context.Context, in *clusterInput) []output.CheckResult {
+		return in.checkNodeHealth(ctx)
+	})},
+	{Check: controlPlaneCheck, Run: tracked(func(ctx context.Context, in *clusterInput) []output.CheckResult {
+		return in.checkControlPlane(ctx)
+	})},
+	{Check: dnsCheck, Run: tracked(func(ctx context.Context, in *clusterInput) []output.CheckResult {
+		return []output.CheckResult{in.checkDNS(ctx)}
+	})},
+}
+
+// tracked stamps a check's results with the completeness of the data it read.
+func tracked(run func(context.Context, *clusterInput) []output.CheckResult) func(context.Context, *clusterInput) []output.CheckResult {
+	return func(ctx context.Context, in *clusterInput) []output.CheckResult {
+		ctx, tracker := client.TrackData(ctx)
+		results := run(ctx, in)
+		output.SetDataCompleteness(results, tracker.Partial())
+		return results
+	}
+}
+
+// runnables returns the built-in cluster checks followed by the custom and
+// plugin checks the cluster command runs.
+func (c *ClusterDiagnostic) runnables(config DiagnosticConfig) []checks.Runnable[*clusterInput] {
+	extra := custom.Runnables(config.CustomChecks, "cluster",
+		func(in *clusterInput) custom.Lister { return in.client.Cache() },
+		func(*clusterInput) runtime.Object { return nil })
+	extra = append(extra, plugin.Runnables(config.Plugins, "cluster",
+		func(in *clusterInput) *client.KubernetesClient { return in.client },
+		func(ctx context.Context, in *clusterInput) ([]runtime.Object, error) {
+			return in.client.Cache().Objects(ctx, "Node", metav1.NamespaceAll)
+		})...)
+
+	runnables := slices.Clone(clusterChecks)
+	for _, runnable := range extra {
+		runnable.Run = tracked(runnable.Run)
+		runnables = append(runnables, runnable)
+	}
+	return runnables
+}
+
+// RunDiagnostics runs the default cluster-level diagnostic checks
 func (c *ClusterDiagnostic) RunDiagnostics(ctx context.Context) (*output.DiagnosticReport, error) {
+	return c.RunChecks(ctx, DiagnosticConfig{})
+}
+
+// RunChecks runs the selected cluster-level diagnostic checks
+func (c *ClusterDiagnostic) RunChecks(ctx context.Context, config DiagnosticConfig) (*output.DiagnosticReport, error) {
+	selection, err := checks.Select("cluster", config.Checks, config.SkipChecks)
+	if err != nil {
+		return nil, err
+	}
+	selection = selection.WithTimeout(config.CheckTimeout)
+
 	// Get cluster info
 	clusterInfo, err := c.client.GetClusterInfo(ctx)
 	if err != nil {
@@ -36,28 +182,15 @@ func (c *ClusterDiagnostic) RunDiagnostics(ctx context.Context) (*output.Diagnos
 
 	// Initialize report
 	report := &output.DiagnosticReport{
-		Target:      "cluster",
-		Timestamp:   time.Now().Format(time.RFC3339),
-		ClusterInfo: clusterInfo,
-		Checks:      []output.CheckResult{},
-		Metadata:    make(map[string]interface{}),
+		SchemaVersion: output.SchemaVersion,
+		Target:        "cluster",
+		Timestamp:     time.Now().Format(time.RFC3339),
+		ClusterInfo:   clusterInfo,
+		Checks:        checks.Run(ctx, c.runnables(config), selection, &clusterInput{ClusterDiagnostic: c, config: config}),
+		Metadata:      make(map[string]interface{}),
 	}
 
-	// Run connectivity check
-	connectivityResult := c.checkConnectivity(ctx)
-	report.Checks = append(report.Checks, connectivityResult)
-
-	// Run node health checks
-	nodeResults := c.checkNodeHealth(ctx)
-	report.Checks = append(report.Checks, nodeResults...)
-
-	// Run control plane checks
-	controlPlaneResults := c.checkControlPlane(ctx)
-	report.Checks = append(report.Checks, controlPlaneResults...)
-
-	// Run DNS checks
-	dnsResult := c.checkDNS(ctx)
-	report.Checks = append(report.Checks, dnsResult)
+	c.output.FinalizeResults(report.Target, report.Checks)
 
 	// Calculate summary
 	report.Summary = c.calculateSummary(report.Checks)
@@ -65,8 +198,21 @@ func (c *ClusterDiagnostic) RunDiagnostics(ctx context.Context) (*output.Diagnos
 	return report, nil
 }
 
-// checkConnectivity tests basic connectivity to the Kubernetes API server
-func (c *ClusterDiagnostic) checkConnectivity(ctx context.Context) output.CheckResult {
+// checkConnectivity tests basic connectivity to the Kubernetes API server,
+// warning when it responds slower than slowResponse (zero uses
+// DefaultSlowResponse)
+func (c *ClusterDiagnostic) checkConnectivity(ctx context.Context, slowResponse time.Duration) output.CheckResult {
+	if c.client.Offline {
+		return output.CheckResult{
+			Name:    "API Server Connectivity",
+			Status:  output.StatusSkipped,
+			Message: "API server connectivity cannot be checked from an offline snapshot",
+			Details: map[string]string{
+				"source": c.client.Config.Host(),
+			},
+		}
+	}
+
 	start := time.Now()
 	err := c.client.TestConnection(ctx)
 	duration := time.Since(start)
@@ -83,11 +229,16 @@ func (c *ClusterDiagnostic) checkConnectivity(ctx context.Context) output.CheckR
 
 	details := map[string]string{
 		"response_time": duration.String(),
-		"server":        c.client.Config.Host,
+		"server":        c.client.Config.Host(),
+	}
+
+	if slowResponse <= 0 {
+		slowResponse = DefaultSlowResponse
 	}
 
 	message := fmt.Sprintf("Successfully connected to API server (response time: %v)", duration)
-	if duration > 5*time.Second {
+	if duration > slowResponse {
+		details["threshold"] = slowResponse.String()
 		return output.CheckResult{
 			Name:       "API Server Connectivity",
 			Status:     output.StatusWarning,
@@ -109,7 +260,7 @@ func (c *ClusterDiagnostic) checkConnectivity(ctx context.Context) output.CheckR
 func (c *ClusterDiagnostic) checkNodeHealth(ctx context.Context) []output.CheckResult {
 	var results []output.CheckResult
 
-	nodes, err := c.client.Clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	nodes, err := c.client.Cache().Nodes(ctx)
 	if err != nil {
 		return []output.CheckResult{{
 			Name:       "Node Health",
@@ -120,7 +271,7 @@ func (c *ClusterDiagnostic) checkNodeHealth(ctx context.Context) []output.CheckR
 		}}
 	}
 
-	if len(nodes.Items) == 0 {
+	if len(nodes) == 0 {
 		return []output.CheckResult{{
 			Name:       "Node Health",
 			Status:     output.StatusFailed,
@@ -130,14 +281,13 @@ func (c *ClusterDiagnostic) checkNodeHealth(ctx context.Context) []output.CheckR
 	}
 
 	// Overall node summary
-	totalNodes := len(nodes.Items)
+	totalNodes := len(nodes)
 	readyNodes := 0
 
 	var problematicNodes []string
 
 	// Check each node
-	for i := range nodes.Items {
-		node := &nodes.Items[i]
+	for _, node := range nodes {
 		nodeReady := false
 
 		var nodeIssues []string
@@ -181,9 +331,10 @@ func (c *ClusterDiagnostic) checkNodeHealth(ctx context.Context) []output.CheckR
 			}
 
 			results = append(results, output.CheckResult{
-				Name:    fmt.Sprintf("Node: %s", node.Name),
-				Status:  status,
-				Message: fmt.Sprintf("Node has issues: %v", nodeIssues),
+				Name:     "Node Conditions",
+				Status:   status,
+				Resource: &output.ResourceRef{Kind: "Node", Name: node.Name, UID: string(node.UID)},
+				Message:  fmt.Sprintf("Node has issues: %v", nodeIssues),
 				Details: map[string]string{
 					"node_name": node.Name,
 					"issues":    fmt.Sprintf("%v", nodeIssues),
@@ -232,9 +383,8 @@ func (c *ClusterDiagnostic) checkControlPlane(ctx context.Context) []output.Chec
 	results := make([]output.CheckResult, 0, 8) // pre-allocate with capacity
 
 	// Check if we can access system namespaces (indicates control plane access)
-	systemPods, err := c.client.Clientset.CoreV1().Pods("kube-system").List(ctx, metav1.ListOptions{
-		LabelSelector: "component in (etcd,kube-apiserver,kube-controller-manager,kube-scheduler)",
-	})
+	systemPods, err := c.client.Cache().PodsMatching(ctx, metav1.NamespaceSystem,
+		inSelector("component", "etcd", "kube-apiserver", "kube-controller-manager", "kube-scheduler"))
 	if err != nil {
 		return []output.CheckResult{{
 			Name:       "Control Plane Health",
@@ -245,7 +395,7 @@ func (c *ClusterDiagnostic) checkControlPlane(ctx context.Context) []output.Chec
 		}}
 	}
 
-	if len(systemPods.Items) == 0 {
+	if len(systemPods) == 0 {
 		return []output.CheckResult{{
 			Name:       "Control Plane Health",
 			Status:     output.StatusWarning,
@@ -256,8 +406,7 @@ func (c *ClusterDiagnostic) checkControlPlane(ctx context.Context) []output.Chec
 
 	// Group pods by component
 	components := make(map[string][]*corev1.Pod)
-	for i := range systemPods.Items {
-		pod := &systemPods.Items[i]
+	for _, pod := range systemPods {
 		if component, exists := pod.Labels["component"]; exists {
 			components[component] = append(components[component], pod)
 		}
@@ -322,9 +471,8 @@ func (c *ClusterDiagnostic) checkControlPlane(ctx context.Context) []output.Chec
 // checkDNS performs basic DNS functionality check
 func (c *ClusterDiagnostic) checkDNS(ctx context.Context) output.CheckResult {
 	// Check CoreDNS/kube-dns pods
-	dnsPods, err := c.client.Clientset.CoreV1().Pods("kube-system").List(ctx, metav1.ListOptions{
-		LabelSelector: "k8s-app in (kube-dns,coredns)",
-	})
+	dnsPods, err := c.client.Cache().PodsMatching(ctx, metav1.NamespaceSystem,
+		inSelector("k8s-app", "kube-dns", "coredns"))
 	if err != nil {
 		return output.CheckResult{
 			Name:       "DNS Health",
@@ -335,7 +483,7 @@ func (c *ClusterDiagnostic) checkDNS(ctx context.Context) output.CheckResult {
 		}
 	}
 
-	if len(dnsPods.Items) == 0 {
+	if len(dnsPods) == 0 {
 		return output.CheckResult{
 			Name:       "DNS Health",
 			Status:     output.StatusFailed,
@@ -346,8 +494,7 @@ func (c *ClusterDiagnostic) checkDNS(ctx context.Context) output.CheckResult {
 
 	runningDNSPods := 0
 
-	for i := range dnsPods.Items {
-		pod := &dnsPods.Items[i]
+	for _, pod := range dnsPods {
 		if pod.Status.Phase == corev1.PodRunning {
 			runningDNSPods++
 		}
@@ -355,7 +502,7 @@ func (c *ClusterDiagnostic) checkDNS(ctx context.Context) output.CheckResult {
 
 	details := map[string]string{
 		"dns_pods_running": fmt.Sprintf("%d", runningDNSPods),
-		"dns_pods_total":   fmt.Sprintf("%d", len(dnsPods.Items)),
+		"dns_pods_total":   fmt.Sprintf("%d", len(dnsPods)),
 	}
 
 	if runningDNSPods == 0 {
@@ -368,11 +515,11 @@ func (c *ClusterDiagnostic) checkDNS(ctx context.Context) output.CheckResult {
 		}
 	}
 
-	if runningDNSPods < len(dnsPods.Items) {
+	if runningDNSPods < len(dnsPods) {
 		return output.CheckResult{
 			Name:       "DNS Health",
 			Status:     output.StatusWarning,
-			Message:    fmt.Sprintf("DNS partially functional: %d/%d pods running", runningDNSPods, len(dnsPods.Items)),
+			Message:    fmt.Sprintf("DNS partially functional: %d/%d pods running", runningDNSPods, len(dnsPods)),
 			Details:    details,
 			Suggestion: "Some DNS pods are not running, check pod status and logs",
 		}
@@ -381,7 +528,7 @@ func (c *ClusterDiagnostic) checkDNS(ctx context.Context) output.CheckResult {
 	return output.CheckResult{
 		Name:    "DNS Health",
 		Status:  output.StatusPassed,
-		Message: fmt.Sprintf("DNS is healthy: %d/%d pods running", runningDNSPods, len(dnsPods.Items)),
+		Message: fmt.Sprintf("DNS is healthy: %d/%d pods running", runningDNSPods, len(dnsPods)),
 		Details: details,
 	}
 }
@@ -440,8 +587,20 @@ func (c *ClusterDiagnostic) calculateSummary(checks []output.CheckResult) output
 			summary.Warnings++
 		case output.StatusSkipped:
 			summary.Skipped++
+		case output.StatusSuppressed:
+			summary.Suppressed++
 		}
 	}
 
 	return summary
 }
+
+// inSelector returns a selector matching objects whose label key has one of
+// the given values.
+func inSelector(key string, values ...string) labels.Selector {
+	requirement, err := labels.NewRequirement(key, selection.In, values)
+	if err != nil {
+		return labels.Nothing()
+	}
+	return labels.NewSelector().Add(*requirement)
+}
@@ -1,7 +1,17 @@
 package cluster
 
 import (
+	"context"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 
 	"kdebug/internal/client"
 	"kdebug/internal/output"
@@ -156,16 +166,306 @@ func TestGetControlPlaneSuggestion(t *testing.T) {
 	}
 }
 
-// Test with fake Kubernetes client - currently disabled due to type compatibility
-// TODO: Implement interface-based client for better testability
+// newFakeClusterDiagnostic builds a ClusterDiagnostic backed by a fake clientset seeded with objs.
+func newFakeClusterDiagnostic(objs ...runtime.Object) *ClusterDiagnostic {
+	k8sClient := client.NewKubernetesClientFromClientset(fake.NewClientset(objs...), client.StaticConfig{Server: "https://fake:6443"}, "fake")
+	return NewClusterDiagnostic(k8sClient, output.NewOutputManager("table", false))
+}
+
+func newNode(name string, ready bool, pressure ...corev1.NodeConditionType) *corev1.Node {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+
+	conditions := []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}}
+	for _, condType := range pressure {
+		conditions = append(conditions, corev1.NodeCondition{Type: condType, Status: corev1.ConditionTrue})
+	}
+
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     corev1.NodeStatus{Conditions: conditions},
+	}
+}
+
+func newSystemPod(name string, labels map[string]string, phase corev1.PodPhase) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "kube-system", Labels: labels},
+		Status:     corev1.PodStatus{Phase: phase},
+	}
+}
+
 func TestCheckNodeHealthWithFakeClient(t *testing.T) {
-	t.Skip("Fake client tests disabled - TODO: implement interface-based client")
+	t.Run("all nodes healthy", func(t *testing.T) {
+		cd := newFakeClusterDiagnostic(newNode("node-1", true), newNode("node-2", true))
+
+		results := cd.checkNodeHealth(context.Background())
+
+		if len(results) != 1 {
+			t.Fatalf("Expected 1 result, got %d", len(results))
+		}
+		if results[0].Status != output.StatusPassed {
+			t.Errorf("Expected status PASSED, got %s", results[0].Status)
+		}
+		if results[0].Details["ready_nodes"] != "2" {
+			t.Errorf("Expected 2 ready nodes, got %s", results[0].Details["ready_nodes"])
+		}
+	})
+
+	t.Run("node not ready", func(t *testing.T) {
+		cd := newFakeClusterDiagnostic(newNode("node-1", true), newNode("node-2", false))
+
+		results := cd.checkNodeHealth(context.Background())
+
+		if len(results) != 2 {
+			t.Fatalf("Expected 2 results, got %d", len(results))
+		}
+		if results[0].Status != output.StatusWarning {
+			t.Errorf("Expected overview status WARNING, got %s", results[0].Status)
+		}
+		if results[1].Status != output.StatusFailed {
+			t.Errorf("Expected node status FAILED, got %s", results[1].Status)
+		}
+		if results[1].Details["node_name"] != "node-2" {
+			t.Errorf("Expected node-2, got %s", results[1].Details["node_name"])
+		}
+		if ref := results[1].Resource; results[1].Name != "Node Conditions" || ref == nil || ref.Kind != "Node" || ref.Name != "node-2" {
+			t.Errorf("Expected a Node Conditions result referencing node-2, got %q %+v", results[1].Name, ref)
+		}
+	})
+
+	t.Run("node under pressure", func(t *testing.T) {
+		cd := newFakeClusterDiagnostic(newNode("node-1", true, corev1.NodeMemoryPressure))
+
+		results := cd.checkNodeHealth(context.Background())
+
+		if len(results) != 2 {
+			t.Fatalf("Expected 2 results, got %d", len(results))
+		}
+		if results[1].Status != output.StatusWarning {
+			t.Errorf("Expected node status WARNING, got %s", results[1].Status)
+		}
+	})
+
+	t.Run("no nodes", func(t *testing.T) {
+		cd := newFakeClusterDiagnostic()
+
+		results := cd.checkNodeHealth(context.Background())
+
+		if len(results) != 1 || results[0].Status != output.StatusFailed {
+			t.Errorf("Expected single FAILED result, got %+v", results)
+		}
+	})
 }
 
 func TestCheckDNSWithFakeClient(t *testing.T) {
-	t.Skip("Fake client tests disabled - TODO: implement interface-based client")
+	dnsLabels := map[string]string{"k8s-app": "kube-dns"}
+
+	tests := []struct {
+		name     string
+		objs     []runtime.Object
+		expected output.CheckStatus
+	}{
+		{
+			name:     "no dns pods",
+			expected: output.StatusFailed,
+		},
+		{
+			name: "all dns pods running",
+			objs: []runtime.Object{
+				newSystemPod("coredns-1", dnsLabels, corev1.PodRunning),
+				newSystemPod("coredns-2", dnsLabels, corev1.PodRunning),
+			},
+			expected: output.StatusPassed,
+		},
+		{
+			name: "partial dns pods running",
+			objs: []runtime.Object{
+				newSystemPod("coredns-1", dnsLabels, corev1.PodRunning),
+				newSystemPod("coredns-2", dnsLabels, corev1.PodPending),
+			},
+			expected: output.StatusWarning,
+		},
+		{
+			name: "no dns pods running",
+			objs: []runtime.Object{
+				newSystemPod("coredns-1", dnsLabels, corev1.PodPending),
+			},
+			expected: output.StatusFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cd := newFakeClusterDiagnostic(tt.objs...)
+
+			result := cd.checkDNS(context.Background())
+
+			if result.Status != tt.expected {
+				t.Errorf("checkDNS() status = %s, want %s (%s)", result.Status, tt.expected, result.Message)
+			}
+		})
+	}
 }
 
 func TestCheckControlPlaneWithFakeClient(t *testing.T) {
-	t.Skip("Fake client tests disabled - TODO: implement interface-based client")
+	t.Run("managed control plane", func(t *testing.T) {
+		cd := newFakeClusterDiagnostic()
+
+		results := cd.checkControlPlane(context.Background())
+
+		if len(results) != 1 || results[0].Status != output.StatusWarning {
+			t.Errorf("Expected single WARNING result, got %+v", results)
+		}
+	})
+
+	t.Run("component down", func(t *testing.T) {
+		cd := newFakeClusterDiagnostic(
+			newSystemPod("etcd-1", map[string]string{"component": "etcd"}, corev1.PodRunning),
+			newSystemPod("kube-scheduler-1", map[string]string{"component": "kube-scheduler"}, corev1.PodFailed),
+		)
+
+		results := cd.checkControlPlane(context.Background())
+
+		if len(results) != 3 {
+			t.Fatalf("Expected 3 results, got %d", len(results))
+		}
+		if results[0].Status != output.StatusWarning {
+			t.Errorf("Expected overview status WARNING, got %s", results[0].Status)
+		}
+
+		for _, result := range results[1:] {
+			switch result.Details["component"] {
+			case "etcd":
+				if result.Status != output.StatusPassed {
+					t.Errorf("Expected etcd PASSED, got %s", result.Status)
+				}
+			case "kube-scheduler":
+				if result.Status != output.StatusFailed {
+					t.Errorf("Expected kube-scheduler FAILED, got %s", result.Status)
+				}
+			default:
+				t.Errorf("Unexpected component %q", result.Details["component"])
+			}
+		}
+	})
+}
+
+func TestRunDiagnosticsWithFakeClient(t *testing.T) {
+	cd := newFakeClusterDiagnostic(
+		newNode("node-1", true),
+		newSystemPod("coredns-1", map[string]string{"k8s-app": "kube-dns"}, corev1.PodRunning),
+	)
+
+	report, err := cd.RunDiagnostics(context.Background())
+	if err != nil {
+		t.Fatalf("RunDiagnostics() unexpected error: %v", err)
+	}
+
+	if report.ClusterInfo["context"] != "fake" {
+		t.Errorf("Expected context 'fake', got %q", report.ClusterInfo["context"])
+	}
+	if report.Summary.Total != len(report.Checks) {
+		t.Errorf("Summary total %d does not match %d checks", report.Summary.Total, len(report.Checks))
+	}
+	if report.Summary.Failed != 0 {
+		t.Errorf("Expected no failed checks, got %d", report.Summary.Failed)
+	}
+	if report.Checks[0].Details["server"] != "https://fake:6443" {
+		t.Errorf("Expected connectivity check to report fake server, got %q", report.Checks[0].Details["server"])
+	}
+}
+
+func TestRunChecksSelection(t *testing.T) {
+	cd := newFakeClusterDiagnostic(newNode("node-1", true), newNode("node-2", true))
+
+	report, err := cd.RunChecks(context.Background(), DiagnosticConfig{Checks: []string{"connectivity", "CLUSTER-NODES"}})
+	if err != nil {
+		t.Fatalf("RunChecks() unexpected error: %v", err)
+	}
+	if len(report.Checks) != 2 {
+		t.Errorf("Expected the connectivity and node overview checks, got %d", len(report.Checks))
+	}
+
+	report, err = cd.RunChecks(context.Background(), DiagnosticConfig{SkipChecks: []string{"dns", "control-plane"}})
+	if err != nil {
+		t.Fatalf("RunChecks() unexpected error: %v", err)
+	}
+	for _, check := range report.Checks {
+		if strings.Contains(check.Name, "DNS") || strings.Contains(check.Name, "Control Plane") {
+			t.Errorf("Expected skipped check %q not to run", check.Name)
+		}
+	}
+
+	if _, err := cd.RunChecks(context.Background(), DiagnosticConfig{Checks: []string{"images"}}); err == nil {
+		t.Error("Expected an error for a check that is not a cluster check")
+	}
+}
+
+func TestRunChecksTimeout(t *testing.T) {
+	cd := newFakeClusterDiagnostic(newNode("node-1", true))
+	cd.client.Clientset.(*fake.Clientset).PrependReactor("list", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+		time.Sleep(100 * time.Millisecond)
+		return false, nil, nil
+	})
+
+	report, err := cd.RunChecks(context.Background(), DiagnosticConfig{Checks: []string{"nodes", "dns"}, CheckTimeout: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("RunChecks() unexpected error: %v", err)
+	}
+
+	if len(report.Checks) != 2 {
+		t.Fatalf("Expected the node overview and DNS results, got %+v", report.Checks)
+	}
+	if report.Checks[0].Status != output.StatusPassed {
+		t.Errorf("Expected the node check to finish, got %+v", report.Checks[0])
+	}
+	dns := report.Checks[1]
+	if dns.ID != "CLUSTER-DNS" || dns.Status != output.StatusSkipped || dns.Details["reason"] != "timeout" {
+		t.Errorf("Expected the slow DNS check to be skipped on timeout, got %+v", dns)
+	}
+}
+
+func TestCheckConnectivityOffline(t *testing.T) {
+	cd := newFakeClusterDiagnostic()
+	cd.client.Offline = true
+
+	result := cd.checkConnectivity(context.Background(), 0)
+
+	if result.Status != output.StatusSkipped {
+		t.Errorf("Expected status SKIPPED, got %s", result.Status)
+	}
+}
+
+func TestCheckConnectivitySlowResponse(t *testing.T) {
+	cd := newFakeClusterDiagnostic()
+
+	if result := cd.checkConnectivity(context.Background(), 0); result.Status != output.StatusPassed {
+		t.Errorf("Expected the fake API server to be fast enough by default, got %+v", result)
+	}
+
+	// Concurrent runs with different thresholds share the diagnostic
+	statuses := make([]output.CheckStatus, 2)
+	var wg sync.WaitGroup
+	for i, slowResponse := range []time.Duration{time.Nanosecond, time.Hour} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report, err := cd.RunChecks(context.Background(), DiagnosticConfig{
+				Checks:       []string{"connectivity"},
+				SlowResponse: slowResponse,
+			})
+			if err != nil || len(report.Checks) != 1 {
+				t.Errorf("RunChecks() = %v, %v", report, err)
+				return
+			}
+			statuses[i] = report.Checks[0].Status
+		}()
+	}
+	wg.Wait()
+
+	if statuses[0] != output.StatusWarning || statuses[1] != output.StatusPassed {
+		t.Errorf("Expected a warning only above the configured threshold, got %v", statuses)
+	}
 }
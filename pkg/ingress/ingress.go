@@ -3,6 +3,7 @@ package ingress
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -10,9 +11,13 @@ import (
 	discoveryv1 "k8s.io/api/discovery/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 
+	"kdebug/internal/checks"
 	"kdebug/internal/client"
+	"kdebug/internal/custom"
 	"kdebug/internal/output"
+	"kdebug/internal/plugin"
 )
 
 // IngressDiagnostic handles ingress-related diagnostics
@@ -33,7 +38,59 @@ type DiagnosticConfig struct {
 	CheckConflicts bool
 	Controllers    bool
 	Checks         []string
-	Timeout        time.Duration
+	SkipChecks     []string
+
+	// Timeout bounds the diagnostics; DiagnoseAllIngresses bounds each
+	// ingress separately by it (zero leaves ingresses unbounded)
+	Timeout time.Duration
+
+	// CheckTimeout bounds each check (zero disables the per-check deadline)
+	CheckTimeout time.Duration
+
+	// Parallelism is the number of ingresses diagnosed concurrently by
+	// DiagnoseAllIngresses
+	Parallelism int
+
+	// TLS is what the TLS check expects of the secrets an ingress uses
+	TLS TLSExpectations
+
+	// CustomChecks are the loaded custom checks; those targeting ingresses
+	// run after the built-in checks
+	CustomChecks []*custom.Check
+
+	// Plugins are the checks of external plugins; those run with the
+	// ingress command run after the custom checks
+	Plugins []*plugin.Check
+}
+
+// TLSExpectations configure the TLS check. The zero value expects
+// kubernetes.io/tls secrets with tls.crt and tls.key, and hosts on every TLS
+// block.
+type TLSExpectations struct {
+	// SecretTypes are the accepted secret types; other types are a warning
+	SecretTypes []string
+
+	// RequiredKeys are the data keys a secret must have
+	RequiredKeys []string
+
+	// AllowMissingHosts accepts TLS blocks that list no hosts
+	AllowMissingHosts bool
+}
+
+// secretTypes returns the accepted secret types.
+func (e TLSExpectations) secretTypes() []string {
+	if len(e.SecretTypes) == 0 {
+		return []string{string(corev1.SecretTypeTLS)}
+	}
+	return e.SecretTypes
+}
+
+// requiredKeys returns the data keys a secret must have.
+func (e TLSExpectations) requiredKeys() []string {
+	if len(e.RequiredKeys) == 0 {
+		return []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey}
+	}
+	return e.RequiredKeys
 }
 
 // IngressInfo contains information about an ingress resource
@@ -66,7 +123,14 @@ func NewIngressDiagnostic(kubeClient *client.KubernetesClient, outputMgr *output
 
 // DiagnoseIngress performs diagnostics on a single ingress resource
 func (id *IngressDiagnostic) DiagnoseIngress(ctx context.Context, ingressName string, config DiagnosticConfig) (*output.DiagnosticReport, error) {
-	id.output.PrintInfo(fmt.Sprintf("🔍 Analyzing ingress: %s", ingressName))
+	id.output.PrintInfo(id.output.Icon("🔍") + fmt.Sprintf("Analyzing ingress: %s", ingressName))
+	ctx, tracker := client.TrackData(ctx)
+
+	selection, err := checks.Select("ingress", config.Checks, config.SkipChecks)
+	if err != nil {
+		return nil, err
+	}
+	selection = selection.WithTimeout(config.CheckTimeout)
 
 	// Analyze the ingress resource
 	ingressInfo, err := id.analyzeIngress(ctx, config.Namespace, ingressName)
@@ -75,23 +139,32 @@ func (id *IngressDiagnostic) DiagnoseIngress(ctx context.Context, ingressName st
 	}
 
 	// Run diagnostic checks
-	results := id.runIngressChecks(ctx, ingressInfo, config)
+	results := id.runIngressChecks(ctx, ingressInfo, config, selection)
+	output.SetDataCompleteness(results, tracker.Partial())
 
-	// Create diagnostic report
+	return id.newReport(ingressInfo.Ingress, config, results), nil
+}
+
+// newReport builds the report on an ingress from its check results.
+func (id *IngressDiagnostic) newReport(ingress *networkingv1.Ingress, config DiagnosticConfig, results []output.CheckResult) *output.DiagnosticReport {
 	report := &output.DiagnosticReport{
-		Target:    fmt.Sprintf("ingress/%s", ingressName),
-		Timestamp: time.Now().Format(time.RFC3339),
-		Checks:    results,
+		SchemaVersion: output.SchemaVersion,
+		Resource:      ingressRef(ingress),
+		Target:        fmt.Sprintf("ingress/%s", ingress.Name),
+		Timestamp:     time.Now().Format(time.RFC3339),
+		Checks:        results,
 		ClusterInfo: map[string]string{
 			"namespace": config.Namespace,
 		},
 		Metadata: map[string]interface{}{
 			"resourceType": "Ingress",
-			"resourceName": ingressName,
+			"resourceName": ingress.Name,
 			"namespace":    config.Namespace,
 		},
 	}
 
+	id.output.FinalizeResults(report.Target, report.Checks)
+
 	// Calculate summary
 	summary := output.Summary{Total: len(results)}
 	for _, result := range results {
@@ -104,17 +177,26 @@ func (id *IngressDiagnostic) DiagnoseIngress(ctx context.Context, ingressName st
 			summary.Warnings++
 		case output.StatusSkipped:
 			summary.Skipped++
+		case output.StatusSuppressed:
+			summary.Suppressed++
 		}
 	}
 	report.Summary = summary
 
-	return report, nil
+	return report
 }
 
-// DiagnoseAllIngresses performs diagnostics on all ingress resources in specified namespaces
+// DiagnoseAllIngresses performs diagnostics on all ingress resources in
+// specified namespaces. Every ingress gets its own --timeout, and ingresses
+// that cannot be diagnosed are reported with their checks skipped.
 func (id *IngressDiagnostic) DiagnoseAllIngresses(ctx context.Context, config DiagnosticConfig) ([]*output.DiagnosticReport, error) {
 	var reports []*output.DiagnosticReport
 
+	selection, err := checks.Select("ingress", config.Checks, config.SkipChecks)
+	if err != nil {
+		return nil, err
+	}
+
 	// Get list of ingress resources
 	ingresses, err := id.getIngressResources(ctx, config.Namespace, config.AllNamespaces)
 	if err != nil {
@@ -126,25 +208,41 @@ func (id *IngressDiagnostic) DiagnoseAllIngresses(ctx context.Context, config Di
 		return reports, nil
 	}
 
-	id.output.PrintInfo(fmt.Sprintf("🔍 Analyzing %d ingress resources", len(ingresses)))
+	id.output.PrintInfo(id.output.Icon("🔍") + fmt.Sprintf("Analyzing %d ingress resources", len(ingresses)))
 
-	// Diagnose each ingress
-	for _, ingress := range ingresses {
-		if ctx.Err() != nil {
-			return reports, ctx.Err()
-		}
+	// List backend services and endpoint slices once instead of once per ingress
+	listNamespace := config.Namespace
+	if config.AllNamespaces {
+		listNamespace = metav1.NamespaceAll
+	}
+	if err := id.client.Cache().Prefetch(ctx, listNamespace,
+		client.ResourceServices, client.ResourceEndpointSlices); err != nil {
+		id.output.PrintWarning(fmt.Sprintf("Failed to prefetch backends, falling back to per-ingress lookups: %v", err))
+	}
 
+	// Diagnose ingresses concurrently, keeping the reports in list order
+	reports = checks.Map(config.Parallelism, ingresses, func(ingress *networkingv1.Ingress) *output.DiagnosticReport {
 		ingressConfig := config
 		ingressConfig.Namespace = ingress.Namespace
 
-		report, err := id.DiagnoseIngress(ctx, ingress.Name, ingressConfig)
+		// Every ingress gets the full --timeout, however long the ingresses
+		// before it took
+		ingressCtx := context.WithoutCancel(ctx)
+		if config.Timeout > 0 {
+			var cancel context.CancelFunc
+			ingressCtx, cancel = context.WithTimeout(ingressCtx, config.Timeout)
+			defer cancel()
+		}
+
+		report, err := id.DiagnoseIngress(ingressCtx, ingress.Name, ingressConfig)
 		if err != nil {
 			id.output.PrintError("Failed to diagnose ingress", fmt.Errorf("ingress %s: %w", ingress.Name, err))
-			continue
+			results := checks.Unavailable(id.ingressChecks(ingressConfig), selection, "Failed to read the ingress and its backends", err)
+			output.SetResource(results, ingressRef(ingress))
+			return id.newReport(ingress, ingressConfig, results)
 		}
-
-		reports = append(reports, report)
-	}
+		return report
+	})
 
 	return reports, nil
 }
@@ -152,7 +250,7 @@ func (id *IngressDiagnostic) DiagnoseAllIngresses(ctx context.Context, config Di
 // analyzeIngress performs comprehensive analysis of a single ingress resource
 func (id *IngressDiagnostic) analyzeIngress(ctx context.Context, namespace, name string) (*IngressInfo, error) {
 	// Get the ingress resource
-	ingress, err := id.client.Clientset.NetworkingV1().Ingresses(namespace).Get(ctx, name, metav1.GetOptions{})
+	ingress, err := id.client.Cache().Ingress(ctx, namespace, name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get ingress: %w", err)
 	}
@@ -187,42 +285,108 @@ func (id *IngressDiagnostic) analyzeIngress(ctx context.Context, namespace, name
 	return info, nil
 }
 
-// runIngressChecks runs all diagnostic checks for an ingress resource
-func (id *IngressDiagnostic) runIngressChecks(ctx context.Context, info *IngressInfo, config DiagnosticConfig) []output.CheckResult {
-	var results []output.CheckResult
-
-	// Define available checks
-	allChecks := map[string]func(context.Context, *IngressInfo, DiagnosticConfig) output.CheckResult{
-		"existence": id.checkIngressExists,
-		"config":    id.checkIngressConfiguration,
-		"backends":  id.checkBackendServices,
-		"endpoints": id.checkBackendEndpoints,
-		"ssl":       id.checkSSLConfiguration,
-	}
+var (
+	readIngresses      = checks.Permission{Verbs: []string{"get", "list"}, Resource: "ingresses", Group: "networking.k8s.io"}
+	readServices       = checks.Permission{Verbs: []string{"get", "list"}, Resource: "services"}
+	readEndpointSlices = checks.Permission{Verbs: []string{"list"}, Resource: "endpointslices", Group: "discovery.k8s.io"}
+	readSecrets        = checks.Permission{Verbs: []string{"get"}, Resource: "secrets"}
+)
 
-	// Determine which checks to run
-	checksToRun := config.Checks
-	if len(checksToRun) == 0 {
-		// Default checks
-		checksToRun = []string{"existence", "config", "backends", "endpoints"}
+// Registered ingress checks, in execution order.
+var (
+	existsCheck = checks.Register(checks.Check{
+		ID: "INGRESS-EXISTS", Command: "ingress", Alias: "existence", Category: checks.CategoryAvailability,
+		Name:           "Ingress Existence",
+		Severity:       output.SeverityHigh,
+		Description:    "Ingress exists and is accessible",
+		DefaultEnabled: true,
+		Inputs:         []string{"ingress"},
+		Permissions:    []checks.Permission{readIngresses},
+	})
+	configCheck = checks.Register(checks.Check{
+		ID: "INGRESS-CONFIG", Command: "ingress", Alias: "config", Category: checks.CategoryConfiguration,
+		Name:           "Ingress Configuration",
+		Severity:       output.SeverityMedium,
+		Description:    "Ingress class, rules and load balancer address",
+		DefaultEnabled: true,
+		Inputs:         []string{"ingress"},
+		Permissions:    []checks.Permission{readIngresses},
+	})
+	backendsCheck = checks.Register(checks.Check{
+		ID: "INGRESS-BACKENDS", Command: "ingress", Alias: "backends", Category: checks.CategoryNetworking,
+		Name:           "Backend Services",
+		Severity:       output.SeverityHigh,
+		Description:    "Backend services exist and expose the referenced ports",
+		DefaultEnabled: true,
+		Inputs:         []string{"ingress", "services"},
+		Permissions:    []checks.Permission{readIngresses, readServices},
+	})
+	endpointsCheck = checks.Register(checks.Check{
+		ID: "INGRESS-ENDPOINTS", Command: "ingress", Alias: "endpoints", Category: checks.CategoryNetworking,
+		Name:           "Backend Endpoints",
+		Severity:       output.SeverityHigh,
+		Description:    "Backend services have ready endpoints",
+		DefaultEnabled: true,
+		Inputs:         []string{"ingress", "endpointslices"},
+		Permissions:    []checks.Permission{readIngresses, readEndpointSlices},
+	})
+	tlsCheck = checks.Register(checks.Check{
+		ID: "INGRESS-TLS", Command: "ingress", Alias: "ssl", Category: checks.CategorySecurity,
+		Name:           "SSL Configuration",
+		Severity:       output.SeverityHigh,
+		Description:    "TLS secrets and certificates (by default only for ingresses with TLS)",
+		DefaultEnabled: true,
+		Inputs:         []string{"ingress", "secrets"},
+		Permissions:    []checks.Permission{readIngresses, readSecrets},
+	})
+)
 
-		// Add SSL checks if TLS is configured
-		if len(info.Ingress.Spec.TLS) > 0 {
-			checksToRun = append(checksToRun, "ssl")
-		}
+// ingressChecks binds the registered ingress checks to their implementations.
+func (id *IngressDiagnostic) ingressChecks(config DiagnosticConfig) []checks.Runnable[*IngressInfo] {
+	bind := func(fn func(context.Context, *IngressInfo, DiagnosticConfig) output.CheckResult) func(context.Context, *IngressInfo) []output.CheckResult {
+		return checks.Single(func(ctx context.Context, info *IngressInfo) output.CheckResult {
+			return fn(ctx, info, config)
+		})
 	}
 
-	// Run selected checks
-	for _, checkName := range checksToRun {
-		if checkFunc, exists := allChecks[checkName]; exists {
-			result := checkFunc(ctx, info, config)
-			results = append(results, result)
-		}
+	runnables := []checks.Runnable[*IngressInfo]{
+		{Check: existsCheck, Run: bind(id.checkIngressExists)},
+		{Check: configCheck, Run: bind(id.checkIngressConfiguration)},
+		{Check: backendsCheck, Run: bind(id.checkBackendServices)},
+		{Check: endpointsCheck, Run: bind(id.checkBackendEndpoints)},
+		{
+			Check: tlsCheck,
+			Run:   bind(id.checkSSLConfiguration),
+			// Only check TLS by default when the ingress configures it
+			Applies: func(info *IngressInfo) bool {
+				return len(info.Ingress.Spec.TLS) > 0
+			},
+		},
 	}
 
+	runnables = append(runnables, custom.Runnables(config.CustomChecks, "ingress",
+		func(*IngressInfo) custom.Lister { return id.client.Cache() },
+		func(info *IngressInfo) runtime.Object { return info.Ingress })...)
+
+	return append(runnables, plugin.Runnables(config.Plugins, "ingress",
+		func(*IngressInfo) *client.KubernetesClient { return id.client },
+		func(_ context.Context, info *IngressInfo) ([]runtime.Object, error) {
+			return []runtime.Object{info.Ingress}, nil
+		})...)
+}
+
+// runIngressChecks runs the selected diagnostic checks for an ingress resource
+func (id *IngressDiagnostic) runIngressChecks(ctx context.Context, info *IngressInfo, config DiagnosticConfig, selection checks.Selection) []output.CheckResult {
+	results := checks.Run(ctx, id.ingressChecks(config), selection, info)
+	output.SetResource(results, ingressRef(info.Ingress))
 	return results
 }
 
+// ingressRef returns the resource reference of an ingress.
+func ingressRef(ingress *networkingv1.Ingress) *output.ResourceRef {
+	return &output.ResourceRef{Kind: "Ingress", Namespace: ingress.Namespace, Name: ingress.Name, UID: string(ingress.UID)}
+}
+
 // checkIngressExists verifies that the ingress resource exists and is accessible
 func (id *IngressDiagnostic) checkIngressExists(ctx context.Context, info *IngressInfo, config DiagnosticConfig) output.CheckResult {
 	if info.Ingress == nil {
@@ -525,16 +689,16 @@ func (id *IngressDiagnostic) checkSSLConfiguration(ctx context.Context, info *In
 				secretFound = true
 
 				// Validate secret type
-				if secret.Type != corev1.SecretTypeTLS {
-					warnings = append(warnings, fmt.Sprintf("Secret %s is not of type kubernetes.io/tls", tls.SecretName))
+				secretTypes := config.TLS.secretTypes()
+				if !slices.Contains(secretTypes, string(secret.Type)) {
+					warnings = append(warnings, fmt.Sprintf("Secret %s is not of type %s", tls.SecretName, strings.Join(secretTypes, " or ")))
 				}
 
 				// Check for required keys
-				if _, hasCert := secret.Data["tls.crt"]; !hasCert {
-					issues = append(issues, fmt.Sprintf("Secret %s missing tls.crt", tls.SecretName))
-				}
-				if _, hasKey := secret.Data["tls.key"]; !hasKey {
-					issues = append(issues, fmt.Sprintf("Secret %s missing tls.key", tls.SecretName))
+				for _, key := range config.TLS.requiredKeys() {
+					if _, ok := secret.Data[key]; !ok {
+						issues = append(issues, fmt.Sprintf("Secret %s missing %s", tls.SecretName, key))
+					}
 				}
 				break
 			}
@@ -545,7 +709,7 @@ func (id *IngressDiagnostic) checkSSLConfiguration(ctx context.Context, info *In
 		}
 
 		// Validate hosts
-		if len(tls.Hosts) == 0 {
+		if len(tls.Hosts) == 0 && !config.TLS.AllowMissingHosts {
 			warnings = append(warnings, fmt.Sprintf("TLS block for secret %s has no hosts specified", tls.SecretName))
 		}
 	}
@@ -592,27 +756,11 @@ func (id *IngressDiagnostic) checkSSLConfiguration(ctx context.Context, info *In
 
 // getIngressResources retrieves ingress resources from specified namespace(s)
 func (id *IngressDiagnostic) getIngressResources(ctx context.Context, namespace string, allNamespaces bool) ([]*networkingv1.Ingress, error) {
-	var ingresses []*networkingv1.Ingress
-
 	if allNamespaces {
-		ingressList, err := id.client.Clientset.NetworkingV1().Ingresses("").List(ctx, metav1.ListOptions{})
-		if err != nil {
-			return nil, err
-		}
-		for i := range ingressList.Items {
-			ingresses = append(ingresses, &ingressList.Items[i])
-		}
-	} else {
-		ingressList, err := id.client.Clientset.NetworkingV1().Ingresses(namespace).List(ctx, metav1.ListOptions{})
-		if err != nil {
-			return nil, err
-		}
-		for i := range ingressList.Items {
-			ingresses = append(ingresses, &ingressList.Items[i])
-		}
+		return id.client.Cache().Ingresses(ctx, metav1.NamespaceAll)
 	}
 
-	return ingresses, nil
+	return id.client.Cache().Ingresses(ctx, namespace)
 }
 
 // getBackendServices retrieves all backend services referenced by the ingress
@@ -634,7 +782,7 @@ func (id *IngressDiagnostic) getBackendServices(ctx context.Context, ingress *ne
 
 	// Get each service
 	for serviceName := range serviceNames {
-		service, err := id.client.Clientset.CoreV1().Services(ingress.Namespace).Get(ctx, serviceName, metav1.GetOptions{})
+		service, err := id.client.Cache().Service(ctx, ingress.Namespace, serviceName)
 		if err != nil {
 			// Service not found - we'll report this in the check
 			continue
@@ -665,16 +813,12 @@ func (id *IngressDiagnostic) getBackendEndpoints(ctx context.Context, ingress *n
 	// Get endpoint slices for each service
 	for serviceName := range serviceNames {
 		// List endpoint slices that match the service
-		endpointSlices, err := id.client.Clientset.DiscoveryV1().EndpointSlices(ingress.Namespace).List(ctx, metav1.ListOptions{
-			LabelSelector: fmt.Sprintf("kubernetes.io/service-name=%s", serviceName),
-		})
+		endpointSlices, err := id.client.Cache().EndpointSlicesFor(ctx, ingress.Namespace, serviceName)
 		if err != nil {
 			// EndpointSlices not found - we'll report this in the check
 			continue
 		}
-		for i := range endpointSlices.Items {
-			endpoints = append(endpoints, &endpointSlices.Items[i])
-		}
+		endpoints = append(endpoints, endpointSlices...)
 	}
 
 	return endpoints, nil
@@ -694,7 +838,7 @@ func (id *IngressDiagnostic) getTLSSecrets(ctx context.Context, ingress *network
 
 	// Get each secret
 	for secretName := range secretNames {
-		secret, err := id.client.Clientset.CoreV1().Secrets(ingress.Namespace).Get(ctx, secretName, metav1.GetOptions{})
+		secret, err := id.client.Cache().Secret(ctx, ingress.Namespace, secretName)
 		if err != nil {
 			// Secret not found - we'll report this in the check
 			continue
@@ -2,11 +2,17 @@ package ingress
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 	"k8s.io/utils/ptr"
 
 	"kdebug/internal/client"
@@ -239,3 +245,217 @@ func TestDiagnosticConfig(t *testing.T) {
 		t.Errorf("Expected 2 checks, got %v", len(config.Checks))
 	}
 }
+
+func newFakeIngressDiagnostic(objs ...runtime.Object) *IngressDiagnostic {
+	kubeClient := client.NewKubernetesClientFromClientset(fake.NewClientset(objs...), nil, "fake")
+	return NewIngressDiagnostic(kubeClient, output.NewOutputManager("json", false))
+}
+
+func newTestIngress(name, serviceName string, tlsSecret string) *networkingv1.Ingress {
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ptr.To("nginx"),
+			Rules: []networkingv1.IngressRule{
+				{
+					Host: "example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
+								{
+									Path:     "/",
+									PathType: ptr.To(networkingv1.PathTypePrefix),
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: serviceName,
+											Port: networkingv1.ServiceBackendPort{Number: 80},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if tlsSecret != "" {
+		ingress.Spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{"example.com"}, SecretName: tlsSecret}}
+	}
+	return ingress
+}
+
+func TestDiagnoseIngressWithFakeClient(t *testing.T) {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 80}}},
+	}
+	endpointSlice := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web-abc",
+			Namespace: "default",
+			Labels:    map[string]string{"kubernetes.io/service-name": "web"},
+		},
+		Endpoints: []discoveryv1.Endpoint{
+			{Addresses: []string{"10.0.0.1"}, Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(true)}},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-tls", Namespace: "default"},
+		Type:       corev1.SecretTypeTLS,
+		Data:       map[string][]byte{"tls.crt": []byte("cert"), "tls.key": []byte("key")},
+	}
+
+	diag := newFakeIngressDiagnostic(newTestIngress("web", "web", "web-tls"), service, endpointSlice, secret)
+
+	report, err := diag.DiagnoseIngress(context.Background(), "web", DiagnosticConfig{Namespace: "default"})
+	if err != nil {
+		t.Fatalf("DiagnoseIngress() unexpected error: %v", err)
+	}
+
+	if report.Summary.Total != 5 {
+		t.Fatalf("Expected 5 checks including SSL, got %d", report.Summary.Total)
+	}
+	for _, check := range report.Checks {
+		if check.Status != output.StatusPassed {
+			t.Errorf("Check %q status = %s: %s", check.Name, check.Status, check.Message)
+		}
+	}
+}
+
+func TestDiagnoseIngressMissingBackendWithFakeClient(t *testing.T) {
+	diag := newFakeIngressDiagnostic(newTestIngress("web", "missing", "missing-tls"))
+
+	report, err := diag.DiagnoseIngress(context.Background(), "web", DiagnosticConfig{Namespace: "default"})
+	if err != nil {
+		t.Fatalf("DiagnoseIngress() unexpected error: %v", err)
+	}
+
+	for _, check := range report.Checks {
+		switch check.Name {
+		case "Backend Services", "Backend Endpoints", "SSL Configuration":
+			if check.Status != output.StatusFailed {
+				t.Errorf("Check %q status = %s, want FAILED", check.Name, check.Status)
+			}
+		}
+	}
+}
+
+func TestDiagnoseIngressCheckSelection(t *testing.T) {
+	diag := newFakeIngressDiagnostic(newTestIngress("web", "web", ""))
+
+	tests := []struct {
+		name       string
+		only, skip []string
+		want       int
+	}{
+		{"defaults skip TLS without TLS configured", nil, nil, 4},
+		{"explicit TLS runs anyway", []string{"INGRESS-TLS"}, nil, 1},
+		{"skip by alias", nil, []string{"backends", "endpoints"}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := diag.DiagnoseIngress(context.Background(), "web", DiagnosticConfig{Namespace: "default", Checks: tt.only, SkipChecks: tt.skip})
+			if err != nil {
+				t.Fatalf("DiagnoseIngress() unexpected error: %v", err)
+			}
+			if len(report.Checks) != tt.want {
+				t.Errorf("Expected %d checks, got %d", tt.want, len(report.Checks))
+			}
+		})
+	}
+
+	if _, err := diag.DiagnoseIngress(context.Background(), "web", DiagnosticConfig{Namespace: "default", Checks: []string{"selector"}}); err == nil {
+		t.Error("Expected an error for a check that is not an ingress check")
+	}
+}
+
+func TestDiagnoseAllIngressesWithFakeClient(t *testing.T) {
+	diag := newFakeIngressDiagnostic(newTestIngress("a", "web", ""), newTestIngress("b", "web", ""))
+
+	reports, err := diag.DiagnoseAllIngresses(context.Background(), DiagnosticConfig{Namespace: "default", All: true})
+	if err != nil {
+		t.Fatalf("DiagnoseAllIngresses() unexpected error: %v", err)
+	}
+	if len(reports) != 2 {
+		t.Errorf("Expected 2 reports, got %d", len(reports))
+	}
+}
+
+func TestDiagnoseAllIngressesGivesEachIngressItsOwnTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	diag := newFakeIngressDiagnostic(newTestIngress("a", "web", ""), newTestIngress("b", "web", ""))
+	// The command's deadline passes once the ingresses were listed
+	diag.client.Clientset.(*fake.Clientset).PrependReactor("list", "services", func(k8stesting.Action) (bool, runtime.Object, error) {
+		cancel()
+		return false, nil, nil
+	})
+
+	reports, err := diag.DiagnoseAllIngresses(ctx, DiagnosticConfig{Namespace: "default", All: true, Timeout: time.Minute})
+	if err != nil {
+		t.Fatalf("DiagnoseAllIngresses() unexpected error: %v", err)
+	}
+	if len(reports) != 2 {
+		t.Fatalf("Expected every ingress to be reported, got %d reports", len(reports))
+	}
+	for _, report := range reports {
+		for _, check := range report.Checks {
+			if check.Details["reason"] == "timeout" {
+				t.Errorf("Expected %s not to time out with its own deadline, got %s", report.Target, check.Message)
+			}
+		}
+	}
+}
+
+func TestDiagnoseAllIngressesAPICallsDoNotScaleWithIngresses(t *testing.T) {
+	countCalls := func(ingresses int) int {
+		var objs []runtime.Object
+		for i := 0; i < ingresses; i++ {
+			objs = append(objs, newTestIngress(fmt.Sprintf("web-%d", i), fmt.Sprintf("svc-%d", i), ""))
+		}
+
+		diag := newFakeIngressDiagnostic(objs...)
+		if _, err := diag.DiagnoseAllIngresses(context.Background(), DiagnosticConfig{Namespace: "default", All: true}); err != nil {
+			t.Fatalf("DiagnoseAllIngresses() unexpected error: %v", err)
+		}
+
+		return len(diag.client.Clientset.(*fake.Clientset).Actions())
+	}
+
+	small, large := countCalls(2), countCalls(20)
+	if small != large {
+		t.Errorf("Expected a constant number of API calls, got %d for 2 ingresses and %d for 20 ingresses", small, large)
+	}
+}
+
+func TestCheckSSLConfigurationExpectations(t *testing.T) {
+	ingress := newTestIngress("web", "web", "web-tls")
+	ingress.Spec.TLS[0].Hosts = nil
+	info := &IngressInfo{
+		Ingress: ingress,
+		TLSSecrets: []*corev1.Secret{{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-tls", Namespace: "default"},
+			Type:       corev1.SecretTypeOpaque,
+			Data:       map[string][]byte{"cert.pem": []byte("cert"), "key.pem": []byte("key")},
+		}},
+	}
+	diag := newFakeIngressDiagnostic()
+
+	result := diag.checkSSLConfiguration(context.Background(), info, DiagnosticConfig{})
+	if result.Status != output.StatusFailed || result.Details["issues"] != "Secret web-tls missing tls.crt, Secret web-tls missing tls.key" {
+		t.Errorf("Expected the default keys to be required, got %+v", result)
+	}
+
+	expectations := TLSExpectations{
+		SecretTypes:       []string{string(corev1.SecretTypeOpaque)},
+		RequiredKeys:      []string{"cert.pem", "key.pem"},
+		AllowMissingHosts: true,
+	}
+	result = diag.checkSSLConfiguration(context.Background(), info, DiagnosticConfig{TLS: expectations})
+	if result.Status != output.StatusPassed {
+		t.Errorf("Expected the configured expectations to be met, got %+v", result)
+	}
+}
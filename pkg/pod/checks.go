@@ -4,51 +4,188 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 
+	"kdebug/internal/checks"
+	"kdebug/internal/client"
+	"kdebug/internal/custom"
 	"kdebug/internal/output"
+	"kdebug/internal/plugin"
 )
 
-// runDiagnosticChecks executes all diagnostic checks for a pod.
-func (d *PodDiagnostic) runDiagnosticChecks(ctx context.Context, info *PodInfo, config DiagnosticConfig) []output.CheckResult {
-	// Determine which checks to run
-	checkTypes := config.Checks
-	if len(checkTypes) == 0 {
-		// Run all checks if none specified
-		checkTypes = []string{"basic", "scheduling", "images", "rbac", "logs", "init-containers", "resources", "network"}
-	}
-
-	// Pre-allocate slice with estimated capacity
-	checks := make([]output.CheckResult, 0, len(checkTypes)*3)
-
-	for _, checkType := range checkTypes {
-		switch checkType {
-		case "basic":
-			checks = append(checks, d.checkPodBasicStatus(info))
-		case "scheduling":
-			checks = append(checks, d.checkPodScheduling(ctx, info)...)
-		case "images":
-			checks = append(checks, d.checkImageIssues(info)...)
-		case "rbac":
-			checks = append(checks, d.checkRBACPermissions(ctx, info)...)
-		case "logs":
-			if config.IncludeLogs {
-				checks = append(checks, d.checkContainerLogs(info)...)
+var (
+	readPods            = checks.Permission{Verbs: []string{"get", "list"}, Resource: "pods"}
+	readEvents          = checks.Permission{Verbs: []string{"list"}, Resource: "events"}
+	readNodes           = checks.Permission{Verbs: []string{"get", "list"}, Resource: "nodes"}
+	readServiceAccounts = checks.Permission{Verbs: []string{"get", "list"}, Resource: "serviceaccounts"}
+	readPodLogs         = checks.Permission{Verbs: []string{"get"}, Resource: "pods/log"}
+)
+
+// Registered pod checks, in execution order.
+var (
+	statusCheck = checks.Register(checks.Check{
+		ID: "POD-STATUS", Command: "pod", Alias: "basic", Category: checks.CategoryAvailability,
+		Name:           "Pod Status",
+		Severity:       output.SeverityHigh,
+		Description:    "Pod phase and container readiness",
+		DefaultEnabled: true,
+		Inputs:         []string{"pod"},
+		Permissions:    []checks.Permission{readPods},
+	})
+	schedulingCheck = checks.Register(checks.Check{
+		ID: "POD-SCHEDULING", Command: "pod", Alias: "scheduling", Category: checks.CategoryScheduling,
+		Name:           "Pod Scheduling",
+		Severity:       output.SeverityHigh,
+		Description:    "Scheduling status, node conditions and resource fit on the assigned node",
+		DefaultEnabled: true,
+		Inputs:         []string{"pod", "node"},
+		Permissions:    []checks.Permission{readPods, readNodes},
+	})
+	imagesCheck = checks.Register(checks.Check{
+		ID: "POD-IMAGE-PULL", Command: "pod", Alias: "images", Category: checks.CategoryRuntime,
+		Name:           "Image Pull",
+		Severity:       output.SeverityHigh,
+		Description:    "Image pull errors and image tag hygiene",
+		DefaultEnabled: true,
+		Inputs:         []string{"pod"},
+		Permissions:    []checks.Permission{readPods},
+	})
+	rbacCheck = checks.Register(checks.Check{
+		ID: "POD-RBAC", Command: "pod", Alias: "rbac", Category: checks.CategorySecurity,
+		Name:           "RBAC - Service Account",
+		Severity:       output.SeverityMedium,
+		Description:    "Service account existence and permission errors; with --as-service-account, the service account's own access",
+		DefaultEnabled: true,
+		Inputs:         []string{"pod", "serviceaccount", "events"},
+		Permissions:    []checks.Permission{readPods, readServiceAccounts, readEvents},
+	})
+	logsCheck = checks.Register(checks.Check{
+		ID: "POD-LOGS", Command: "pod", Alias: "logs", Category: checks.CategoryRuntime,
+		Name:           "Container Logs",
+		Severity:       output.SeverityMedium,
+		Description:    "Errors and crash loops in container logs (requires --include-logs)",
+		DefaultEnabled: true,
+		Inputs:         []string{"pod", "logs"},
+		Permissions:    []checks.Permission{readPods, readPodLogs},
+	})
+	initContainersCheck = checks.Register(checks.Check{
+		ID: "POD-INIT-CONTAINERS", Command: "pod", Alias: "init-containers", Category: checks.CategoryRuntime,
+		Name:           "Init Containers",
+		Severity:       output.SeverityHigh,
+		Description:    "Init container failures",
+		DefaultEnabled: true,
+		Inputs:         []string{"pod"},
+		Permissions:    []checks.Permission{readPods},
+	})
+	resourcesCheck = checks.Register(checks.Check{
+		ID: "POD-RESOURCES", Command: "pod", Alias: "resources", Category: checks.CategoryResources,
+		Name:           "Resource Configuration",
+		Severity:       output.SeverityMedium,
+		Description:    "QoS class, resource requests and resource-related events",
+		DefaultEnabled: true,
+		Inputs:         []string{"pod", "events"},
+		Permissions:    []checks.Permission{readPods, readEvents},
+	})
+	networkCheck = checks.Register(checks.Check{
+		ID: "POD-NETWORK", Command: "pod", Alias: "network", Category: checks.CategoryNetworking,
+		Name:           "Network",
+		Severity:       output.SeverityMedium,
+		Description:    "DNS configuration and network-related events",
+		DefaultEnabled: true,
+		Inputs:         []string{"pod", "events"},
+		Permissions:    []checks.Permission{readPods, readEvents},
+	})
+)
+
+// diagnosticChecks binds the registered pod checks to their implementations.
+func (d *PodDiagnostic) diagnosticChecks(config DiagnosticConfig) []checks.Runnable[*PodInfo] {
+	runnables := []checks.Runnable[*PodInfo]{
+		{Check: statusCheck, Run: func(_ context.Context, info *PodInfo) []output.CheckResult {
+			return []output.CheckResult{d.checkPodBasicStatus(info)}
+		}},
+		{Check: schedulingCheck, Run: d.checkPodScheduling},
+		{Check: imagesCheck, Run: func(_ context.Context, info *PodInfo) []output.CheckResult {
+			return d.checkImageIssues(info)
+		}},
+		{Check: rbacCheck, Run: func(ctx context.Context, info *PodInfo) []output.CheckResult {
+			return d.checkRBACPermissions(ctx, info, config)
+		}},
+		{Check: logsCheck, Run: func(_ context.Context, info *PodInfo) []output.CheckResult {
+			if !config.IncludeLogs {
+				return nil
 			}
-		case "init-containers":
-			checks = append(checks, d.checkInitContainers(info)...)
-		case "resources":
-			checks = append(checks, d.checkResourceConstraints(info)...)
-		case "network":
-			checks = append(checks, d.checkNetworkIssues(info)...)
-		}
+			return d.checkContainerLogs(info, config)
+		}},
+		{Check: initContainersCheck, Run: func(_ context.Context, info *PodInfo) []output.CheckResult {
+			return d.checkInitContainers(info)
+		}},
+		{Check: resourcesCheck, Run: func(_ context.Context, info *PodInfo) []output.CheckResult {
+			return d.checkResourceConstraints(info)
+		}},
+		{Check: networkCheck, Run: func(_ context.Context, info *PodInfo) []output.CheckResult {
+			return d.checkNetworkIssues(info)
+		}},
 	}
 
-	return checks
+	runnables = append(runnables, custom.Runnables(config.CustomChecks, "pod",
+		func(*PodInfo) custom.Lister { return d.client.Cache() },
+		func(info *PodInfo) runtime.Object { return info.Pod })...)
+
+	return append(runnables, plugin.Runnables(config.Plugins, "pod",
+		func(*PodInfo) *client.KubernetesClient { return d.client },
+		func(_ context.Context, info *PodInfo) ([]runtime.Object, error) {
+			return []runtime.Object{info.Pod}, nil
+		})...)
+}
+
+// runDiagnosticChecks executes the selected diagnostic checks for a pod.
+func (d *PodDiagnostic) runDiagnosticChecks(ctx context.Context, info *PodInfo, config DiagnosticConfig, selection checks.Selection) []output.CheckResult {
+	results := checks.Run(ctx, d.diagnosticChecks(config), selection, info)
+	output.SetResource(results, podRef(info.Pod))
+	return results
+}
+
+// podRef returns the resource reference of a pod.
+func podRef(pod *corev1.Pod) *output.ResourceRef {
+	return &output.ResourceRef{
+		Kind:       "Pod",
+		Namespace:  pod.Namespace,
+		Name:       pod.Name,
+		UID:        string(pod.UID),
+		Controller: podController(pod),
+	}
+}
+
+// podController returns the workload controlling a pod with a generated
+// name, resolving a Deployment's ReplicaSet to the Deployment itself since
+// the ReplicaSet name changes with every rollout as well. Pods with stable
+// names, such as those of a StatefulSet, have none.
+func podController(pod *corev1.Pod) *output.ControllerRef {
+	owner := metav1.GetControllerOf(pod)
+	if pod.GenerateName == "" || owner == nil {
+		return nil
+	}
+
+	kind, name := owner.Kind, owner.Name
+	hash := pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey]
+	if kind == "ReplicaSet" && hash != "" && strings.HasSuffix(name, "-"+hash) {
+		kind, name = "Deployment", strings.TrimSuffix(name, "-"+hash)
+	}
+
+	return &output.ControllerRef{Kind: kind, Name: name}
+}
+
+// nodeRef returns the resource reference of a node.
+func nodeRef(node *corev1.Node) *output.ResourceRef {
+	return &output.ResourceRef{Kind: "Node", Name: node.Name, UID: string(node.UID)}
 }
 
 // checkPodBasicStatus performs basic pod status checks.
@@ -264,8 +401,10 @@ func (d *PodDiagnostic) checkImageIssues(info *PodInfo) []output.CheckResult {
 	return checks
 }
 
-// checkRBACPermissions validates RBAC permissions for the pod.
-func (d *PodDiagnostic) checkRBACPermissions(_ context.Context, info *PodInfo) []output.CheckResult {
+// checkRBACPermissions validates RBAC permissions for the pod. With
+// AsServiceAccount set, the pod's permissions are also checked with its own
+// service account identity.
+func (d *PodDiagnostic) checkRBACPermissions(ctx context.Context, info *PodInfo, config DiagnosticConfig) []output.CheckResult {
 	checks := make([]output.CheckResult, 0, 2) // Pre-allocate for expected number of checks
 	pod := info.Pod
 
@@ -304,13 +443,26 @@ func (d *PodDiagnostic) checkRBACPermissions(_ context.Context, info *PodInfo) [
 		checks = append(checks, d.checkRBACEvents(info)...)
 	}
 
+	if config.AsServiceAccount && (pod.Spec.ServiceAccountName == "" || info.ServiceAccount != nil) {
+		checks = append(checks, d.checkServiceAccountAccess(ctx, info)...)
+	}
+
 	return checks
 }
 
 // checkContainerLogs analyzes container logs for common issues.
-func (d *PodDiagnostic) checkContainerLogs(info *PodInfo) []output.CheckResult {
+func (d *PodDiagnostic) checkContainerLogs(info *PodInfo, config DiagnosticConfig) []output.CheckResult {
 	checks := make([]output.CheckResult, 0, len(info.Pod.Status.ContainerStatuses)) // Pre-allocate based on container count
 
+	if info.LogsUnavailableReason != "" {
+		checks = append(checks, output.CheckResult{
+			Name:    "Container Logs",
+			Status:  output.StatusSkipped,
+			Message: info.LogsUnavailableReason,
+		})
+		return checks
+	}
+
 	if len(info.ContainerLogs) == 0 {
 		checks = append(checks, output.CheckResult{
 			Name:    "Container Logs",
@@ -334,7 +486,7 @@ func (d *PodDiagnostic) checkContainerLogs(info *PodInfo) []output.CheckResult {
 		}
 
 		// Analyze logs for common patterns
-		logCheck := d.analyzeContainerLogs(containerName, logs)
+		logCheck := d.analyzeContainerLogs(containerName, logs, config.LogPatterns)
 		checks = append(checks, logCheck)
 
 		// Check for crash loop indicators
@@ -502,7 +654,8 @@ func (d *PodDiagnostic) checkNodeConditions(node *corev1.Node) output.CheckResul
 
 	if len(issues) > 0 {
 		return output.CheckResult{
-			Name:       fmt.Sprintf("Node %s - Conditions", node.Name),
+			Name:       "Node Conditions",
+			Resource:   nodeRef(node),
 			Status:     output.StatusWarning,
 			Message:    fmt.Sprintf("Node has issues: %s", strings.Join(issues, ", ")),
 			Suggestion: "Check node resources and system health",
@@ -513,9 +666,10 @@ func (d *PodDiagnostic) checkNodeConditions(node *corev1.Node) output.CheckResul
 	}
 
 	return output.CheckResult{
-		Name:    fmt.Sprintf("Node %s - Conditions", node.Name),
-		Status:  output.StatusPassed,
-		Message: "Node conditions are healthy",
+		Name:     "Node Conditions",
+		Resource: nodeRef(node),
+		Status:   output.StatusPassed,
+		Message:  "Node conditions are healthy",
 		Details: map[string]string{
 			"node": node.Name,
 		},
@@ -602,33 +756,40 @@ func (d *PodDiagnostic) checkRBACEvents(info *PodInfo) []output.CheckResult {
 	return checks
 }
 
-func (d *PodDiagnostic) analyzeContainerLogs(containerName, logs string) output.CheckResult {
+// LogPattern is a log line pattern that marks a container's logs as
+// failing.
+type LogPattern struct {
+	Pattern    *regexp.Regexp
+	Message    string
+	Suggestion string
+}
+
+// defaultLogPatterns are matched after any configured patterns, from the most
+// specific to the catch-all.
+var defaultLogPatterns = []LogPattern{
+	{regexp.MustCompile(`(?i)(connection refused|connection denied)`), "Connection refused error detected", "Check service availability and network connectivity"},
+	{regexp.MustCompile(`(?i)(no such host|host not found)`), "DNS resolution failure detected", "Check DNS configuration and hostname"},
+	{regexp.MustCompile(`(?i)(permission denied|access denied)`), "Permission denied error detected", "Check file permissions and RBAC settings"},
+	{regexp.MustCompile(`(?i)(out of memory|oom|memory limit)`), "Out of memory error detected", "Increase memory limits or optimize memory usage"},
+	{regexp.MustCompile(`(?i)(disk.*full|no space left)`), "Disk space error detected", "Check available disk space and cleanup"},
+	{regexp.MustCompile(`(?i)(authentication.*fail|login.*fail)`), "Authentication failure detected", "Check credentials and authentication configuration"},
+	{regexp.MustCompile(`(?i)(timeout|timed out)`), "Timeout error detected", "Check network latency and increase timeout values"},
+	{regexp.MustCompile(`(?i)(panic|fatal|error|exception)`), "Application error detected", "Check application logs and configuration"},
+}
+
+// analyzeContainerLogs reports the first pattern that matches a log line,
+// trying the extra patterns before the built-in ones.
+func (d *PodDiagnostic) analyzeContainerLogs(containerName, logs string, extra []LogPattern) output.CheckResult {
 	lines := strings.Split(logs, "\n")
 
-	errorPatterns := []struct {
-		pattern    string
-		message    string
-		suggestion string
-	}{
-		{`(?i)(connection refused|connection denied)`, "Connection refused error detected", "Check service availability and network connectivity"},
-		{`(?i)(no such host|host not found)`, "DNS resolution failure detected", "Check DNS configuration and hostname"},
-		{`(?i)(permission denied|access denied)`, "Permission denied error detected", "Check file permissions and RBAC settings"},
-		{`(?i)(out of memory|oom|memory limit)`, "Out of memory error detected", "Increase memory limits or optimize memory usage"},
-		{`(?i)(disk.*full|no space left)`, "Disk space error detected", "Check available disk space and cleanup"},
-		{`(?i)(authentication.*fail|login.*fail)`, "Authentication failure detected", "Check credentials and authentication configuration"},
-		{`(?i)(timeout|timed out)`, "Timeout error detected", "Check network latency and increase timeout values"},
-		{`(?i)(panic|fatal|error|exception)`, "Application error detected", "Check application logs and configuration"},
-	}
-
-	for _, pattern := range errorPatterns {
-		re := regexp.MustCompile(pattern.pattern)
+	for _, pattern := range slices.Concat(extra, defaultLogPatterns) {
 		for _, line := range lines {
-			if re.MatchString(line) {
+			if pattern.Pattern.MatchString(line) {
 				return output.CheckResult{
 					Name:       fmt.Sprintf("Container %s - Log Analysis", containerName),
 					Status:     output.StatusFailed,
-					Message:    pattern.message,
-					Suggestion: pattern.suggestion,
+					Message:    pattern.Message,
+					Suggestion: pattern.Suggestion,
 					Details: map[string]string{
 						"logLine": strings.TrimSpace(line),
 					},
@@ -23,8 +23,11 @@ import (
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/watch"
 
+	"kdebug/internal/checks"
 	"kdebug/internal/client"
+	"kdebug/internal/custom"
 	"kdebug/internal/output"
+	"kdebug/internal/plugin"
 )
 
 // PodDiagnostic performs diagnostic checks for pod-level issues.
@@ -43,20 +46,49 @@ type DiagnosticConfig struct {
 	// Namespace specifies the target namespace for diagnostics
 	Namespace string
 
-	// Checks specifies which diagnostic checks to run (empty = all checks)
+	// Checks lists the IDs or aliases of the checks to run (empty = the
+	// default-enabled checks)
 	Checks []string
 
+	// SkipChecks lists the IDs or aliases of checks not to run
+	SkipChecks []string
+
 	// IncludeLogs enables log analysis for failed containers
 	IncludeLogs bool
 
 	// LogLines specifies number of recent log lines to analyze
 	LogLines int
 
-	// Timeout specifies maximum time for diagnostic operations
+	// Timeout specifies maximum time for diagnostic operations; with
+	// DiagnoseAllPods it bounds listing the pods and then each pod separately
 	Timeout time.Duration
 
 	// Containers specifies which containers to analyze (empty = all containers)
 	Containers []string
+
+	// CheckTimeout bounds each check; a check that runs out of time is
+	// reported as skipped (zero disables the per-check deadline)
+	CheckTimeout time.Duration
+
+	// Parallelism is the number of pods diagnosed concurrently by
+	// DiagnoseAllPods (values below one diagnose pods one at a time)
+	Parallelism int
+
+	// CustomChecks are the loaded custom checks; those targeting pods run
+	// after the built-in checks
+	CustomChecks []*custom.Check
+
+	// Plugins are the checks of external plugins; those run with the pod
+	// command run after the custom checks
+	Plugins []*plugin.Check
+
+	// AsServiceAccount re-runs the RBAC checks while impersonating the pod's
+	// service account, to reproduce what the workload itself can access
+	AsServiceAccount bool
+
+	// LogPatterns are matched against container logs before the built-in
+	// patterns when IncludeLogs is set
+	LogPatterns []LogPattern
 }
 
 // PodInfo contains comprehensive information about a pod for diagnostics.
@@ -69,6 +101,9 @@ type PodInfo struct {
 	ConfigMaps        []corev1.ConfigMap
 	PersistentVolumes []corev1.PersistentVolume
 	Node              *corev1.Node
+
+	// LogsUnavailableReason explains why container logs could not be collected
+	LogsUnavailableReason string
 }
 
 // NewPodDiagnostic creates a new pod diagnostic instance.
@@ -83,6 +118,13 @@ func NewPodDiagnostic(client *client.KubernetesClient, output *output.OutputMana
 func (d *PodDiagnostic) DiagnosePod(podName string, config DiagnosticConfig) (*output.DiagnosticReport, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
 	defer cancel()
+	ctx, tracker := client.TrackData(ctx)
+
+	selection, err := checks.Select("pod", config.Checks, config.SkipChecks)
+	if err != nil {
+		return nil, err
+	}
+	selection = selection.WithTimeout(config.CheckTimeout)
 
 	// Gather pod information
 	podInfo, err := d.gatherPodInfo(ctx, podName, config)
@@ -91,17 +133,22 @@ func (d *PodDiagnostic) DiagnosePod(podName string, config DiagnosticConfig) (*o
 	}
 
 	// Run diagnostic checks
-	checks := d.runDiagnosticChecks(ctx, podInfo, config)
+	target := fmt.Sprintf("pod/%s", podName)
+	results := d.runDiagnosticChecks(ctx, podInfo, config, selection)
+	output.SetDataCompleteness(results, tracker.Partial())
+	d.output.FinalizeResults(target, results)
 
 	// Calculate summary
-	summary := d.calculateSummary(checks)
+	summary := d.calculateSummary(results)
 
 	// Create report
 	report := &output.DiagnosticReport{
-		Target:    fmt.Sprintf("pod/%s", podName),
-		Timestamp: time.Now().Format(time.RFC3339),
-		Checks:    checks,
-		Summary:   summary,
+		SchemaVersion: output.SchemaVersion,
+		Resource:      podRef(podInfo.Pod),
+		Target:        target,
+		Timestamp:     time.Now().Format(time.RFC3339),
+		Checks:        results,
+		Summary:       summary,
 	}
 
 	return report, nil
@@ -112,48 +159,75 @@ func (d *PodDiagnostic) DiagnoseAllPods(config DiagnosticConfig) (*output.Diagno
 	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
 	defer cancel()
 
-	// List all pods in namespace
-	pods, err := d.client.Clientset.CoreV1().Pods(config.Namespace).List(ctx, metav1.ListOptions{})
+	selection, err := checks.Select("pod", config.Checks, config.SkipChecks)
+	if err != nil {
+		return nil, err
+	}
+	selection = selection.WithTimeout(config.CheckTimeout)
+	target := fmt.Sprintf("pods/namespace=%s", config.Namespace)
+
+	// List all pods in namespace; if only some pages could be read, every
+	// check is reported as computed from partial data
+	listCtx, listTracker := client.TrackData(ctx)
+	pods, err := d.client.Cache().Pods(listCtx, config.Namespace)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list pods: %w", err)
 	}
 
-	if len(pods.Items) == 0 {
-		return &output.DiagnosticReport{
-			Target:    fmt.Sprintf("pods/namespace=%s", config.Namespace),
-			Timestamp: time.Now().Format(time.RFC3339),
-			Checks: []output.CheckResult{
-				{
-					Name:    "Pod Discovery",
-					Status:  "SKIPPED",
-					Message: fmt.Sprintf("No pods found in namespace '%s'", config.Namespace),
-				},
+	if len(pods) == 0 {
+		discovery := []output.CheckResult{
+			{
+				ID:       "POD-DISCOVERY",
+				Name:     "Pod Discovery",
+				Status:   output.StatusSkipped,
+				Severity: output.SeverityInfo,
+				Category: string(checks.CategoryAvailability),
+				Message:  fmt.Sprintf("No pods found in namespace '%s'", config.Namespace),
 			},
-			Summary: output.Summary{Total: 1, Skipped: 1},
+		}
+		d.output.FinalizeResults(target, discovery)
+
+		return &output.DiagnosticReport{
+			SchemaVersion: output.SchemaVersion,
+			Target:        target,
+			Timestamp:     time.Now().Format(time.RFC3339),
+			Checks:        discovery,
+			Summary:       d.calculateSummary(discovery),
 		}, nil
 	}
 
-	var allChecks []output.CheckResult
-
-	// Diagnose each pod
-	for i := range pods.Items {
-		pod := &pods.Items[i]
+	// List related objects once instead of once per pod
+	if err := d.client.Cache().Prefetch(ctx, config.Namespace,
+		client.ResourceEvents, client.ResourceServiceAccounts, client.ResourceNodes); err != nil {
+		d.output.PrintWarning(fmt.Sprintf("Failed to prefetch related objects, falling back to per-pod lookups: %v", err))
+	}
 
+	// Diagnose pods concurrently, keeping the checks in pod order
+	podResults := checks.Map(config.Parallelism, pods, func(pod *corev1.Pod) []output.CheckResult {
 		d.output.PrintInfo(fmt.Sprintf("Executing diagnostic analysis for pod '%s' in namespace '%s'", pod.Name, config.Namespace))
 
-		podInfo, err := d.gatherPodInfoFromPod(ctx, pod, config)
+		// Every pod gets the full --timeout, however long the pods before
+		// it took
+		podCtx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+		defer cancel()
+		podCtx, tracker := client.TrackData(podCtx)
+		var podChecks []output.CheckResult
+		podInfo, err := d.gatherPodInfoFromPod(podCtx, pod, config)
 		if err != nil {
+			// The pod stays in the report with its checks skipped
 			d.output.PrintWarning(fmt.Sprintf("Failed to analyze pod %s: %v", pod.Name, err))
-			continue
-		}
-
-		podChecks := d.runDiagnosticChecks(ctx, podInfo, config)
-
-		// Prefix check names with pod name for clarity
-		for j := range podChecks {
-			podChecks[j].Name = fmt.Sprintf("Pod %s: %s", pod.Name, podChecks[j].Name)
+			podChecks = checks.Unavailable(d.diagnosticChecks(config), selection, "Failed to read the pod and its related objects", err)
+			output.SetResource(podChecks, podRef(pod))
+		} else {
+			podChecks = d.runDiagnosticChecks(podCtx, podInfo, config, selection)
 		}
+		output.SetDataCompleteness(podChecks, listTracker.Partial() || tracker.Partial())
+		d.output.FinalizeResults(target, podChecks)
+		return podChecks
+	})
 
+	var allChecks []output.CheckResult
+	for _, podChecks := range podResults {
 		allChecks = append(allChecks, podChecks...)
 	}
 
@@ -162,10 +236,11 @@ func (d *PodDiagnostic) DiagnoseAllPods(config DiagnosticConfig) (*output.Diagno
 
 	// Create report
 	report := &output.DiagnosticReport{
-		Target:    fmt.Sprintf("pods/namespace=%s", config.Namespace),
-		Timestamp: time.Now().Format(time.RFC3339),
-		Checks:    allChecks,
-		Summary:   summary,
+		SchemaVersion: output.SchemaVersion,
+		Target:        target,
+		Timestamp:     time.Now().Format(time.RFC3339),
+		Checks:        allChecks,
+		Summary:       summary,
 	}
 
 	return report, nil
@@ -173,6 +248,10 @@ func (d *PodDiagnostic) DiagnoseAllPods(config DiagnosticConfig) (*output.Diagno
 
 // WatchPod watches a pod and re-runs diagnostics when changes occur.
 func (d *PodDiagnostic) WatchPod(podName string, config DiagnosticConfig) error {
+	if d.client.Offline {
+		return fmt.Errorf("watching pods is %w", client.ErrOffline)
+	}
+
 	d.output.PrintInfo(fmt.Sprintf("Watching pod '%s' for changes...", podName))
 
 	watchlist := &metav1.ListOptions{
@@ -189,6 +268,7 @@ func (d *PodDiagnostic) WatchPod(podName string, config DiagnosticConfig) error
 		switch event.Type {
 		case watch.Modified, watch.Added:
 			d.output.PrintInfo("Pod changed, re-running diagnostics...")
+			d.client.Cache().Reset()
 
 			report, err := d.DiagnosePod(podName, config)
 			if err != nil {
@@ -217,7 +297,7 @@ func (d *PodDiagnostic) WatchPod(podName string, config DiagnosticConfig) error
 // gatherPodInfo collects comprehensive information about a pod and related resources.
 func (d *PodDiagnostic) gatherPodInfo(ctx context.Context, podName string, config DiagnosticConfig) (*PodInfo, error) {
 	// Get the pod
-	pod, err := d.client.Clientset.CoreV1().Pods(config.Namespace).Get(ctx, podName, metav1.GetOptions{})
+	pod, err := d.client.Cache().Pod(ctx, config.Namespace, podName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get pod: %w", err)
 	}
@@ -233,18 +313,16 @@ func (d *PodDiagnostic) gatherPodInfoFromPod(ctx context.Context, pod *corev1.Po
 	}
 
 	// Get pod events
-	events, err := d.client.Clientset.CoreV1().Events(config.Namespace).List(ctx, metav1.ListOptions{
-		FieldSelector: fmt.Sprintf("involvedObject.name=%s,involvedObject.kind=Pod", pod.Name),
-	})
+	events, err := d.client.Cache().EventsFor(ctx, config.Namespace, "Pod", pod.Name)
 	if err != nil {
 		d.output.PrintWarning(fmt.Sprintf("Failed to get events for pod %s: %v", pod.Name, err))
 	} else {
-		info.Events = events.Items
+		info.Events = events
 	}
 
 	// Get service account if specified
 	if pod.Spec.ServiceAccountName != "" {
-		sa, err := d.client.Clientset.CoreV1().ServiceAccounts(config.Namespace).Get(ctx, pod.Spec.ServiceAccountName, metav1.GetOptions{})
+		sa, err := d.client.Cache().ServiceAccount(ctx, config.Namespace, pod.Spec.ServiceAccountName)
 		if err != nil {
 			d.output.PrintWarning(fmt.Sprintf("Failed to get service account %s: %v", pod.Spec.ServiceAccountName, err))
 		} else {
@@ -254,7 +332,7 @@ func (d *PodDiagnostic) gatherPodInfoFromPod(ctx context.Context, pod *corev1.Po
 
 	// Get node information
 	if pod.Spec.NodeName != "" {
-		node, err := d.client.Clientset.CoreV1().Nodes().Get(ctx, pod.Spec.NodeName, metav1.GetOptions{})
+		node, err := d.client.Cache().Node(ctx, pod.Spec.NodeName)
 		if err != nil {
 			d.output.PrintWarning(fmt.Sprintf("Failed to get node %s: %v", pod.Spec.NodeName, err))
 		} else {
@@ -264,7 +342,11 @@ func (d *PodDiagnostic) gatherPodInfoFromPod(ctx context.Context, pod *corev1.Po
 
 	// Get container logs if requested and pod is failing
 	if config.IncludeLogs && d.isPodFailing(pod) {
-		d.gatherContainerLogs(ctx, info, config)
+		if d.client.Offline {
+			d.gatherRecordedLogs(info, config)
+		} else {
+			d.gatherContainerLogs(ctx, info, config)
+		}
 	}
 
 	return info, nil
@@ -273,17 +355,7 @@ func (d *PodDiagnostic) gatherPodInfoFromPod(ctx context.Context, pod *corev1.Po
 // gatherContainerLogs collects logs from containers in the pod.
 func (d *PodDiagnostic) gatherContainerLogs(ctx context.Context, info *PodInfo, config DiagnosticConfig) {
 	pod := info.Pod
-
-	containers := config.Containers
-	if len(containers) == 0 {
-		// Get all containers if none specified
-		for _, container := range pod.Spec.Containers {
-			containers = append(containers, container.Name)
-		}
-		for _, container := range pod.Spec.InitContainers {
-			containers = append(containers, container.Name)
-		}
-	}
+	containers := containersToAnalyze(pod, config)
 
 	logOptions := &corev1.PodLogOptions{
 		TailLines: int64ptr(config.LogLines),
@@ -308,7 +380,7 @@ func (d *PodDiagnostic) gatherContainerLogs(ctx context.Context, info *PodInfo,
 		defer func() {
 			if closeErr := logs.Close(); closeErr != nil {
 				// Log close error but don't fail the check
-				fmt.Printf("Warning: failed to close log stream: %v\n", closeErr)
+				d.output.PrintWarning(fmt.Sprintf("Failed to close log stream: %v", closeErr))
 			}
 		}()
 
@@ -331,8 +403,50 @@ func (d *PodDiagnostic) gatherContainerLogs(ctx context.Context, info *PodInfo,
 	}
 }
 
+// gatherRecordedLogs collects container logs recorded in an offline snapshot.
+func (d *PodDiagnostic) gatherRecordedLogs(info *PodInfo, config DiagnosticConfig) {
+	if d.client.RecordedLogs == nil {
+		info.LogsUnavailableReason = "Container logs cannot be streamed from an offline snapshot"
+		return
+	}
+
+	for _, containerName := range containersToAnalyze(info.Pod, config) {
+		if logs, ok := d.client.RecordedLogs.ContainerLogs(info.Pod.Namespace, info.Pod.Name, containerName); ok {
+			info.ContainerLogs[containerName] = logs
+		}
+	}
+
+	if len(info.ContainerLogs) == 0 {
+		info.LogsUnavailableReason = "No container logs were recorded in the snapshot"
+	}
+}
+
+// containersToAnalyze returns the containers selected by the configuration,
+// defaulting to all containers and init containers of the pod.
+func containersToAnalyze(pod *corev1.Pod, config DiagnosticConfig) []string {
+	if len(config.Containers) > 0 {
+		return config.Containers
+	}
+
+	containers := make([]string, 0, len(pod.Spec.Containers)+len(pod.Spec.InitContainers))
+	for _, container := range pod.Spec.Containers {
+		containers = append(containers, container.Name)
+	}
+	for _, container := range pod.Spec.InitContainers {
+		containers = append(containers, container.Name)
+	}
+
+	return containers
+}
+
 // isPodFailing determines if a pod is in a failing state.
 func (d *PodDiagnostic) isPodFailing(pod *corev1.Pod) bool {
+	return IsPodFailing(pod)
+}
+
+// IsPodFailing reports whether a pod is in a failing state. Container logs are
+// only collected for failing pods.
+func IsPodFailing(pod *corev1.Pod) bool {
 	if pod.Status.Phase == corev1.PodFailed || pod.Status.Phase == corev1.PodPending {
 		return true
 	}
@@ -374,6 +488,8 @@ func (d *PodDiagnostic) calculateSummary(checks []output.CheckResult) output.Sum
 			summary.Warnings++
 		case "SKIPPED":
 			summary.Skipped++
+		case "SUPPRESSED":
+			summary.Suppressed++
 		}
 		summary.Total++
 	}
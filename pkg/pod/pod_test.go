@@ -1,13 +1,24 @@
 package pod
 
 import (
+	"errors"
+	"fmt"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
 
+	authorizationv1 "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/utils/ptr"
 
 	"kdebug/internal/client"
 	"kdebug/internal/output"
@@ -179,6 +190,56 @@ func TestIsPodFailing(t *testing.T) {
 	}
 }
 
+func TestPodController(t *testing.T) {
+	controlled := func(kind, name, generateName string, labels map[string]string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:            generateName + "abcde",
+			GenerateName:    generateName,
+			Labels:          labels,
+			OwnerReferences: []metav1.OwnerReference{{Kind: kind, Name: name, Controller: ptr.To(true)}},
+		}}
+	}
+
+	tests := []struct {
+		name string
+		pod  *corev1.Pod
+		want *output.ControllerRef
+	}{
+		{
+			name: "deployment",
+			pod:  controlled("ReplicaSet", "web-7d4b8c6f9", "web-7d4b8c6f9-", map[string]string{"pod-template-hash": "7d4b8c6f9"}),
+			want: &output.ControllerRef{Kind: "Deployment", Name: "web"},
+		},
+		{
+			name: "bare replica set",
+			pod:  controlled("ReplicaSet", "web", "web-", nil),
+			want: &output.ControllerRef{Kind: "ReplicaSet", Name: "web"},
+		},
+		{
+			name: "daemon set",
+			pod:  controlled("DaemonSet", "agent", "agent-", nil),
+			want: &output.ControllerRef{Kind: "DaemonSet", Name: "agent"},
+		},
+		{
+			name: "stateful set",
+			pod:  controlled("StatefulSet", "db", "", nil),
+		},
+		{
+			name: "bare pod",
+			pod:  &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "debug"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := podController(tt.pod)
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("podController() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestCheckPodBasicStatus(t *testing.T) {
 	diagnostic := &PodDiagnostic{}
 
@@ -634,7 +695,7 @@ func TestAnalyzeContainerLogs(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := diagnostic.analyzeContainerLogs(tt.containerName, tt.logs)
+			result := diagnostic.analyzeContainerLogs(tt.containerName, tt.logs, nil)
 
 			if string(result.Status) != tt.expectedStatus {
 				t.Errorf("Expected status %s, got %s", tt.expectedStatus, result.Status)
@@ -648,6 +709,25 @@ func TestAnalyzeContainerLogs(t *testing.T) {
 	}
 }
 
+func TestAnalyzeContainerLogsExtraPatterns(t *testing.T) {
+	diagnostic := &PodDiagnostic{}
+	extra := []LogPattern{{
+		Pattern:    regexp.MustCompile(`(?i)deadlock detected`),
+		Message:    "Database deadlock detected",
+		Suggestion: "Review transaction ordering",
+	}}
+
+	result := diagnostic.analyzeContainerLogs("app", "ERROR: deadlock detected\nretrying", extra)
+	if result.Message != "Database deadlock detected" || result.Suggestion != "Review transaction ordering" {
+		t.Errorf("Expected the extra pattern to win over the built-in ones, got %+v", result)
+	}
+
+	result = diagnostic.analyzeContainerLogs("app", "dial tcp: connection refused", extra)
+	if result.Message != "Connection refused error detected" {
+		t.Errorf("Expected the built-in patterns to still apply, got %+v", result)
+	}
+}
+
 func TestIsContainerCrashLooping(t *testing.T) {
 	diagnostic := &PodDiagnostic{}
 
@@ -740,6 +820,406 @@ func TestIsContainerCrashLooping(t *testing.T) {
 	}
 }
 
+func newFakePodDiagnostic(objs ...runtime.Object) *PodDiagnostic {
+	k8sClient := client.NewKubernetesClientFromClientset(fake.NewClientset(objs...), nil, "fake")
+	return NewPodDiagnostic(k8sClient, output.NewOutputManager("json", false))
+}
+
+func TestDiagnosePodWithFakeClient(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: corev1.PodSpec{
+			NodeName:           "node-1",
+			ServiceAccountName: "missing-sa",
+			Containers:         []corev1.Container{{Name: "app", Image: "nginx:latest"}},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name:  "app",
+					Image: "nginx:latest",
+					State: corev1.ContainerState{
+						Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "manifest unknown"},
+					},
+				},
+			},
+		},
+	}
+
+	diagnostic := newFakePodDiagnostic(node, pod)
+
+	report, err := diagnostic.DiagnosePod("web", DiagnosticConfig{
+		Namespace: "default",
+		Checks:    []string{"basic", "scheduling", "images", "rbac"},
+		Timeout:   5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("DiagnosePod() unexpected error: %v", err)
+	}
+
+	byName := make(map[string]output.CheckResult)
+	for _, check := range report.Checks {
+		byName[check.Name] = check
+	}
+
+	expected := map[string]output.CheckStatus{
+		"Pod Status":                 output.StatusFailed,
+		"Pod Scheduling":             output.StatusPassed,
+		"Node Conditions":            output.StatusPassed,
+		"Container app - Image Pull": output.StatusFailed,
+		"RBAC - Service Account":     output.StatusFailed,
+	}
+	for name, status := range expected {
+		check, ok := byName[name]
+		if !ok {
+			t.Errorf("Expected check %q in report", name)
+			continue
+		}
+		if check.Status != status {
+			t.Errorf("Check %q status = %s, want %s", name, check.Status, status)
+		}
+	}
+
+	if report.Summary.Total != len(report.Checks) {
+		t.Errorf("Summary total %d does not match %d checks", report.Summary.Total, len(report.Checks))
+	}
+
+	if report.SchemaVersion != output.SchemaVersion {
+		t.Errorf("Expected schema version %s, got %q", output.SchemaVersion, report.SchemaVersion)
+	}
+
+	pull := byName["Container app - Image Pull"]
+	if pull.ID != "POD-IMAGE-PULL" || pull.Category != "runtime" || pull.Severity != output.SeverityHigh {
+		t.Errorf("Expected image pull failure as POD-IMAGE-PULL/runtime/high, got %s/%s/%s", pull.ID, pull.Category, pull.Severity)
+	}
+	if pull.Resource == nil || *pull.Resource != *report.Resource || pull.Resource.Kind != "Pod" || pull.Resource.Name != "web" {
+		t.Errorf("Expected image pull failure to reference pod web, got %+v", pull.Resource)
+	}
+	if node := byName["Node Conditions"].Resource; node == nil || node.Kind != "Node" || node.Name != "node-1" {
+		t.Errorf("Expected node conditions to reference node node-1, got %+v", node)
+	}
+}
+
+func TestDiagnosePodNotFoundWithFakeClient(t *testing.T) {
+	diagnostic := newFakePodDiagnostic()
+
+	_, err := diagnostic.DiagnosePod("missing", DiagnosticConfig{Namespace: "default", Timeout: 5 * time.Second})
+	if err == nil {
+		t.Error("Expected error for missing pod")
+	}
+}
+
+func TestDiagnoseAllPodsWithFakeClient(t *testing.T) {
+	t.Run("empty namespace", func(t *testing.T) {
+		diagnostic := newFakePodDiagnostic()
+
+		report, err := diagnostic.DiagnoseAllPods(DiagnosticConfig{Namespace: "default", Timeout: 5 * time.Second})
+		if err != nil {
+			t.Fatalf("DiagnoseAllPods() unexpected error: %v", err)
+		}
+		if report.Summary.Skipped != 1 || report.Summary.Total != len(report.Checks) {
+			t.Errorf("Expected 1 skipped check counted like the other reports, got %+v", report.Summary)
+		}
+	})
+
+	t.Run("multiple pods", func(t *testing.T) {
+		running := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default"},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.0.0.1"},
+		}
+		failed := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "default"},
+			Status:     corev1.PodStatus{Phase: corev1.PodFailed},
+		}
+		otherNamespace := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "c", Namespace: "other"},
+			Status:     corev1.PodStatus{Phase: corev1.PodFailed},
+		}
+
+		diagnostic := newFakePodDiagnostic(running, failed, otherNamespace)
+
+		report, err := diagnostic.DiagnoseAllPods(DiagnosticConfig{
+			Namespace: "default",
+			Checks:    []string{"basic"},
+			Timeout:   5 * time.Second,
+		})
+		if err != nil {
+			t.Fatalf("DiagnoseAllPods() unexpected error: %v", err)
+		}
+		if report.Summary.Total != 2 {
+			t.Fatalf("Expected 2 checks, got %d", report.Summary.Total)
+		}
+		if report.Summary.Passed != 1 || report.Summary.Failed != 1 {
+			t.Errorf("Expected 1 passed and 1 failed, got %+v", report.Summary)
+		}
+
+		for _, check := range report.Checks {
+			if check.Name != "Pod Status" || check.ID != "POD-STATUS" {
+				t.Errorf("Expected unprefixed POD-STATUS check, got %s %q", check.ID, check.Name)
+			}
+			if check.Resource == nil || check.Resource.Namespace != "default" {
+				t.Errorf("Expected check to reference its pod, got %+v", check.Resource)
+			}
+		}
+	})
+}
+
+func TestDiagnoseAllPodsInParallel(t *testing.T) {
+	var objs []runtime.Object
+	for i := 0; i < 12; i++ {
+		objs = append(objs, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("web-%02d", i), Namespace: "default"},
+			Status:     corev1.PodStatus{Phase: corev1.PodFailed},
+		})
+	}
+	diagnostic := newFakePodDiagnostic(objs...)
+
+	report, err := diagnostic.DiagnoseAllPods(DiagnosticConfig{
+		Namespace:   "default",
+		Checks:      []string{"basic"},
+		Timeout:     5 * time.Second,
+		Parallelism: 4,
+	})
+	if err != nil {
+		t.Fatalf("DiagnoseAllPods() unexpected error: %v", err)
+	}
+
+	if len(report.Checks) != 12 {
+		t.Fatalf("Expected one check per pod, got %d", len(report.Checks))
+	}
+	for i, check := range report.Checks {
+		if want := fmt.Sprintf("web-%02d", i); check.Resource == nil || check.Resource.Name != want {
+			t.Errorf("Expected check %d to be about pod %s, got %+v", i, want, check.Resource)
+		}
+	}
+}
+
+func TestDiagnosePodOfflineSkipsLogs(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Status:     corev1.PodStatus{Phase: corev1.PodFailed},
+	}
+
+	diagnostic := newFakePodDiagnostic(pod)
+	diagnostic.client.Offline = true
+
+	report, err := diagnostic.DiagnosePod("web", DiagnosticConfig{
+		Namespace:   "default",
+		Checks:      []string{"logs"},
+		IncludeLogs: true,
+		Timeout:     5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("DiagnosePod() unexpected error: %v", err)
+	}
+
+	if len(report.Checks) != 1 || report.Checks[0].Status != output.StatusSkipped {
+		t.Fatalf("Expected a single SKIPPED log check, got %+v", report.Checks)
+	}
+	if !strings.Contains(report.Checks[0].Message, "offline snapshot") {
+		t.Errorf("Expected offline reason, got %q", report.Checks[0].Message)
+	}
+
+	if err := diagnostic.WatchPod("web", DiagnosticConfig{Namespace: "default"}); err == nil {
+		t.Error("Expected watch to be rejected in offline mode")
+	}
+}
+
+func TestDiagnoseAllPodsAPICallsDoNotScaleWithPods(t *testing.T) {
+	countCalls := func(pods int) int {
+		objs := []runtime.Object{
+			&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+			&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"}},
+		}
+		for i := 0; i < pods; i++ {
+			objs = append(objs, &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("web-%d", i), Namespace: "default"},
+				Spec: corev1.PodSpec{
+					NodeName:           "node-1",
+					ServiceAccountName: "app",
+					Containers:         []corev1.Container{{Name: "app", Image: "nginx:1.27"}},
+				},
+				Status: corev1.PodStatus{Phase: corev1.PodRunning},
+			})
+		}
+
+		clientset := fake.NewClientset(objs...)
+		diagnostic := NewPodDiagnostic(client.NewKubernetesClientFromClientset(clientset, nil, "fake"), output.NewOutputManager("json", false))
+		if _, err := diagnostic.DiagnoseAllPods(DiagnosticConfig{Namespace: "default", Timeout: 5 * time.Second}); err != nil {
+			t.Fatalf("DiagnoseAllPods() unexpected error: %v", err)
+		}
+
+		return len(clientset.Actions())
+	}
+
+	small, large := countCalls(2), countCalls(20)
+	if small != large {
+		t.Errorf("Expected a constant number of API calls, got %d for 2 pods and %d for 20 pods", small, large)
+	}
+}
+
+func TestDiagnosePodMarksPartialData(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "nginx:1.27"}}},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+
+	for _, failEvents := range []bool{false, true} {
+		clientset := fake.NewClientset(pod)
+		if failEvents {
+			clientset.PrependReactor("list", "events", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				return true, nil, apierrors.NewForbidden(corev1.Resource("events"), "", errors.New("denied"))
+			})
+		}
+
+		diagnostic := NewPodDiagnostic(client.NewKubernetesClientFromClientset(clientset, nil, "fake"), output.NewOutputManager("json", false))
+		report, err := diagnostic.DiagnosePod("web", DiagnosticConfig{Namespace: "default", Timeout: 5 * time.Second})
+		if err != nil {
+			t.Fatalf("DiagnosePod() unexpected error: %v", err)
+		}
+
+		want := output.DataComplete
+		if failEvents {
+			want = output.DataPartial
+		}
+		for _, check := range report.Checks {
+			if check.Data != want {
+				t.Errorf("Check %q data = %q, want %q", check.Name, check.Data, want)
+			}
+		}
+	}
+}
+
+func TestDiagnosePodAsServiceAccount(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+		Spec: corev1.PodSpec{
+			ServiceAccountName: "web",
+			Containers:         []corev1.Container{{Name: "app", Image: "nginx:1.27"}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"}}
+	event := &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "web.1", Namespace: "shop"},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web"},
+		Reason:         "Failed",
+		Message: `configmaps is forbidden: User "system:serviceaccount:shop:web" cannot list resource "configmaps" in API group "" in the namespace "shop"; ` +
+			`deployments.apps is forbidden: User "system:serviceaccount:shop:web" cannot get resource "deployments/scale" in API group "apps" in the namespace "shop"`,
+	}
+
+	// The service account may list configmaps by now, but still not scale deployments
+	saClientset := fake.NewClientset()
+	saClientset.PrependReactor("create", "selfsubjectrulesreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectRulesReview)
+		review.Status.ResourceRules = []authorizationv1.ResourceRule{{Verbs: []string{"get", "list"}, Resources: []string{"configmaps"}}}
+		return true, review, nil
+	})
+	saClientset.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		review.Status.Allowed = review.Spec.ResourceAttributes.Resource == "configmaps"
+		return true, review, nil
+	})
+
+	var impersonated string
+	k8sClient := client.NewKubernetesClientFromClientset(fake.NewClientset(pod, sa, event), nil, "fake")
+	k8sClient.ImpersonatedClientset = func(identity rest.ImpersonationConfig) (kubernetes.Interface, error) {
+		impersonated = identity.UserName
+		return saClientset, nil
+	}
+
+	diagnostic := NewPodDiagnostic(k8sClient, output.NewOutputManager("json", false))
+	report, err := diagnostic.DiagnosePod("web", DiagnosticConfig{
+		Namespace:        "shop",
+		Checks:           []string{"rbac"},
+		Timeout:          5 * time.Second,
+		AsServiceAccount: true,
+	})
+	if err != nil {
+		t.Fatalf("DiagnosePod() unexpected error: %v", err)
+	}
+
+	if impersonated != "system:serviceaccount:shop:web" {
+		t.Errorf("Expected to impersonate the pod's service account, got %q", impersonated)
+	}
+
+	byName := make(map[string]output.CheckResult)
+	for _, check := range report.Checks {
+		byName[check.Name] = check
+	}
+
+	expected := map[string]output.CheckStatus{
+		"RBAC - Service Account Access":     output.StatusPassed,
+		"RBAC - list configmaps":            output.StatusPassed,
+		"RBAC - get deployments.apps/scale": output.StatusFailed,
+	}
+	for name, status := range expected {
+		check, ok := byName[name]
+		if !ok {
+			t.Errorf("Expected check %q in report, got %v", name, report.Checks)
+			continue
+		}
+		if check.Status != status {
+			t.Errorf("Check %q status = %s, want %s", name, check.Status, status)
+		}
+	}
+
+	if rules := byName["RBAC - Service Account Access"].Details["rules"]; rules != "get,list configmaps" {
+		t.Errorf("Unexpected rules summary %q", rules)
+	}
+	if suggestion := byName["RBAC - get deployments.apps/scale"].Suggestion; !strings.Contains(suggestion, "--resource=deployments.apps/scale") ||
+		!strings.Contains(suggestion, "--serviceaccount=shop:web") {
+		t.Errorf("Unexpected suggestion %q", suggestion)
+	}
+}
+
+// recordedLogs is a client.LogSource backed by a map keyed by pod/container.
+type recordedLogs map[string]string
+
+func (r recordedLogs) ContainerLogs(namespace, pod, container string) (string, bool) {
+	logs, ok := r[pod+"/"+container]
+	return logs, ok
+}
+
+func TestDiagnosePodOfflineUsesRecordedLogs(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+		Status:     corev1.PodStatus{Phase: corev1.PodFailed},
+	}
+
+	diagnostic := newFakePodDiagnostic(pod)
+	diagnostic.client.Offline = true
+	diagnostic.client.RecordedLogs = recordedLogs{"web/app": "panic: runtime error: index out of range"}
+
+	report, err := diagnostic.DiagnosePod("web", DiagnosticConfig{
+		Namespace:   "default",
+		Checks:      []string{"logs"},
+		IncludeLogs: true,
+		Timeout:     5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("DiagnosePod() unexpected error: %v", err)
+	}
+
+	for _, check := range report.Checks {
+		if check.Status == output.StatusSkipped {
+			t.Errorf("Expected recorded logs to be analyzed, got SKIPPED check %q", check.Name)
+		}
+	}
+	if len(report.Checks) == 0 {
+		t.Error("Expected log checks from recorded logs")
+	}
+}
+
 // Helper function to check if a string contains a substring (case-insensitive)
 func containsSubstring(s, substr string) bool {
 	return len(s) >= len(substr) &&
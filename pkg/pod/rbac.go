@@ -0,0 +1,237 @@
+package pod
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kdebug/internal/client"
+	"kdebug/internal/output"
+)
+
+// forbiddenPattern matches the request attributes in an authorization
+// failure, e.g. `pods is forbidden: User "system:serviceaccount:shop:web"
+// cannot list resource "pods" in API group "" in the namespace "shop"`.
+var forbiddenPattern = regexp.MustCompile(`cannot (\S+) resource "([^"]+)" in API group "([^"]*)"(?: in the namespace "([^"]+)")?`)
+
+// deniedRequest is a request the pod was refused, as reported in its events
+// or container logs.
+type deniedRequest struct {
+	Verb        string
+	Resource    string
+	Subresource string
+	Group       string
+	Namespace   string
+}
+
+// String renders the request like kubectl auth can-i, e.g. "list pods".
+func (r deniedRequest) String() string {
+	return r.Verb + " " + r.resourceArg()
+}
+
+// resourceArg renders the resource in kubectl's resource.group/subresource
+// form, e.g. "deployments.apps/scale".
+func (r deniedRequest) resourceArg() string {
+	resource := r.Resource
+	if r.Group != "" {
+		resource += "." + r.Group
+	}
+	if r.Subresource != "" {
+		resource += "/" + r.Subresource
+	}
+	return resource
+}
+
+// serviceAccountName returns the service account a pod runs as.
+func serviceAccountName(pod *corev1.Pod) string {
+	if pod.Spec.ServiceAccountName == "" {
+		return "default"
+	}
+	return pod.Spec.ServiceAccountName
+}
+
+// checkServiceAccountAccess impersonates the pod's service account to report
+// what the workload itself is allowed to do, and re-checks every request the
+// pod was denied with the workload's identity instead of the caller's.
+func (d *PodDiagnostic) checkServiceAccountAccess(ctx context.Context, info *PodInfo) []output.CheckResult {
+	pod := info.Pod
+	saName := serviceAccountName(pod)
+	identity := client.ServiceAccountIdentity(pod.Namespace, saName)
+
+	if d.client.Offline {
+		return []output.CheckResult{{
+			Name:    "RBAC - Service Account Access",
+			Status:  output.StatusSkipped,
+			Message: "Impersonating the service account is not available in offline snapshot mode",
+		}}
+	}
+
+	saClient, err := d.client.Impersonate(identity)
+	if err != nil {
+		return []output.CheckResult{{
+			Name:    "RBAC - Service Account Access",
+			Status:  output.StatusWarning,
+			Message: fmt.Sprintf("Cannot impersonate service account '%s'", saName),
+			Error:   err.Error(),
+		}}
+	}
+
+	review, err := saClient.Clientset.AuthorizationV1().SelfSubjectRulesReviews().Create(ctx, &authorizationv1.SelfSubjectRulesReview{
+		Spec: authorizationv1.SelfSubjectRulesReviewSpec{Namespace: pod.Namespace},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		check := output.CheckResult{
+			Name:    "RBAC - Service Account Access",
+			Status:  output.StatusWarning,
+			Message: fmt.Sprintf("Failed to review permissions of service account '%s'", saName),
+			Error:   err.Error(),
+		}
+		if apierrors.IsForbidden(err) {
+			check.Message = fmt.Sprintf("Not allowed to impersonate service account '%s'", saName)
+			check.Suggestion = "Grant your user the 'impersonate' verb on users, groups and serviceaccounts, or run without --as-service-account"
+		}
+		return []output.CheckResult{check}
+	}
+
+	checks := []output.CheckResult{{
+		Name:    "RBAC - Service Account Access",
+		Status:  output.StatusPassed,
+		Message: fmt.Sprintf("Service account '%s' has %d resource rules in namespace '%s'", saName, len(review.Status.ResourceRules), pod.Namespace),
+		Details: map[string]string{
+			"identity": identity.UserName,
+			"rules":    summarizeRules(review.Status.ResourceRules),
+		},
+	}}
+	if review.Status.Incomplete {
+		checks[0].Details["incomplete"] = review.Status.EvaluationError
+	}
+
+	for _, request := range deniedRequests(info) {
+		checks = append(checks, d.recheckDeniedRequest(ctx, saClient, saName, pod.Namespace, request))
+	}
+
+	return checks
+}
+
+// recheckDeniedRequest asks the API server, as the service account, whether
+// a previously denied request would be allowed now.
+func (d *PodDiagnostic) recheckDeniedRequest(ctx context.Context, saClient *client.KubernetesClient, saName, podNamespace string, request deniedRequest) output.CheckResult {
+	name := fmt.Sprintf("RBAC - %s", request)
+
+	review, err := saClient.Clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace:   request.Namespace,
+				Verb:        request.Verb,
+				Group:       request.Group,
+				Resource:    request.Resource,
+				Subresource: request.Subresource,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return output.CheckResult{
+			Name:    name,
+			Status:  output.StatusWarning,
+			Message: fmt.Sprintf("Failed to re-check denied request as service account '%s'", saName),
+			Error:   err.Error(),
+		}
+	}
+
+	if review.Status.Allowed {
+		return output.CheckResult{
+			Name:    name,
+			Status:  output.StatusPassed,
+			Message: fmt.Sprintf("Service account '%s' was denied earlier but is allowed to %s now", saName, request),
+		}
+	}
+
+	check := output.CheckResult{
+		Name:       name,
+		Status:     output.StatusFailed,
+		Message:    fmt.Sprintf("Service account '%s' is not allowed to %s", saName, request),
+		Suggestion: grantSuggestion(saName, podNamespace, request),
+		Details: map[string]string{
+			"serviceAccount": saName,
+		},
+	}
+	if review.Status.Reason != "" {
+		check.Details["reason"] = review.Status.Reason
+	}
+
+	return check
+}
+
+// deniedRequests extracts the distinct denied requests from a pod's events and
+// collected container logs, in a stable order.
+func deniedRequests(info *PodInfo) []deniedRequest {
+	var sources []string
+	for _, event := range info.Events {
+		sources = append(sources, event.Message)
+	}
+	for _, logs := range info.ContainerLogs {
+		sources = append(sources, logs)
+	}
+
+	seen := make(map[deniedRequest]bool)
+	var requests []deniedRequest
+	for _, source := range sources {
+		for _, match := range forbiddenPattern.FindAllStringSubmatch(source, -1) {
+			request := deniedRequest{Verb: match[1], Group: match[3], Namespace: match[4]}
+			request.Resource, request.Subresource, _ = strings.Cut(match[2], "/")
+
+			if !seen[request] {
+				seen[request] = true
+				requests = append(requests, request)
+			}
+		}
+	}
+
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].String()+requests[i].Namespace < requests[j].String()+requests[j].Namespace
+	})
+
+	return requests
+}
+
+// grantSuggestion returns the kubectl commands granting a denied request to a
+// service account.
+func grantSuggestion(saName, podNamespace string, request deniedRequest) string {
+	resource := request.resourceArg()
+	roleName := fmt.Sprintf("%s-%s-%s", saName, request.Verb, strings.NewReplacer("/", "-", ".", "-").Replace(resource))
+	subject := fmt.Sprintf("--serviceaccount=%s:%s", podNamespace, saName)
+
+	if request.Namespace == "" {
+		return fmt.Sprintf("kubectl create clusterrole %s --verb=%s --resource=%s && kubectl create clusterrolebinding %s --clusterrole=%s %s",
+			roleName, request.Verb, resource, roleName, roleName, subject)
+	}
+
+	return fmt.Sprintf("kubectl create role %s --verb=%s --resource=%s -n %s && kubectl create rolebinding %s --role=%s %s -n %s",
+		roleName, request.Verb, resource, request.Namespace, roleName, roleName, subject, request.Namespace)
+}
+
+// summarizeRules renders resource rules compactly, e.g.
+// "get,list pods; watch configmaps".
+func summarizeRules(rules []authorizationv1.ResourceRule) string {
+	if len(rules) == 0 {
+		return "none"
+	}
+
+	parts := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		resources := strings.Join(rule.Resources, ",")
+		if resources == "" {
+			resources = "-"
+		}
+		parts = append(parts, strings.Join(rule.Verbs, ",")+" "+resources)
+	}
+
+	return strings.Join(parts, "; ")
+}
@@ -20,9 +20,13 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 
+	"kdebug/internal/checks"
 	"kdebug/internal/client"
+	"kdebug/internal/custom"
 	"kdebug/internal/output"
+	"kdebug/internal/plugin"
 )
 
 // ServiceDiagnostic performs diagnostic checks for service-level issues.
@@ -35,10 +39,29 @@ type ServiceDiagnostic struct {
 type DiagnosticConfig struct {
 	Namespace     string
 	Checks        []string
+	SkipChecks    []string
 	TestDNS       bool
 	AllNamespaces bool
-	Timeout       time.Duration
 	Verbose       bool
+
+	// Timeout bounds the diagnostics; DiagnoseAllServices bounds each
+	// service separately by it (zero leaves services unbounded)
+	Timeout time.Duration
+
+	// CheckTimeout bounds each check (zero disables the per-check deadline)
+	CheckTimeout time.Duration
+
+	// Parallelism is the number of services diagnosed concurrently by
+	// DiagnoseAllServices
+	Parallelism int
+
+	// CustomChecks are the loaded custom checks; those targeting services
+	// run after the built-in checks
+	CustomChecks []*custom.Check
+
+	// Plugins are the checks of external plugins; those run with the
+	// service command run after the custom checks
+	Plugins []*plugin.Check
 }
 
 // ServiceInfo contains comprehensive information about a service and its health.
@@ -49,6 +72,88 @@ type ServiceInfo struct {
 	Events      []corev1.Event
 }
 
+var (
+	readServices  = checks.Permission{Verbs: []string{"get", "list"}, Resource: "services"}
+	readEndpoints = checks.Permission{Verbs: []string{"get", "list"}, Resource: "endpoints"}
+	readPods      = checks.Permission{Verbs: []string{"list"}, Resource: "pods"}
+)
+
+// Registered service checks, in execution order.
+var (
+	existsCheck = checks.Register(checks.Check{
+		ID: "SERVICE-EXISTS", Command: "service", Alias: "existence", Category: checks.CategoryAvailability,
+		Name:           "Service Existence",
+		Severity:       output.SeverityHigh,
+		Description:    "Service exists and is accessible",
+		DefaultEnabled: true,
+		Inputs:         []string{"service"},
+		Permissions:    []checks.Permission{readServices},
+	})
+	configCheck = checks.Register(checks.Check{
+		ID: "SERVICE-CONFIG", Command: "service", Alias: "config", Category: checks.CategoryConfiguration,
+		Name:           "Service Configuration",
+		Severity:       output.SeverityMedium,
+		Description:    "Service type, cluster IP and external access configuration",
+		DefaultEnabled: true,
+		Inputs:         []string{"service"},
+		Permissions:    []checks.Permission{readServices},
+	})
+	selectorCheck = checks.Register(checks.Check{
+		ID: "SERVICE-SELECTOR", Command: "service", Alias: "selector", Category: checks.CategoryConfiguration,
+		Name:           "Service Selector",
+		Severity:       output.SeverityHigh,
+		Description:    "Selector matches running pods",
+		DefaultEnabled: true,
+		Inputs:         []string{"service", "pods"},
+		Permissions:    []checks.Permission{readServices, readPods},
+	})
+	endpointsCheck = checks.Register(checks.Check{
+		ID: "SERVICE-ENDPOINTS", Command: "service", Alias: "endpoints", Category: checks.CategoryNetworking,
+		Name:           "Endpoint Health",
+		Severity:       output.SeverityHigh,
+		Description:    "Endpoints exist and are ready",
+		DefaultEnabled: true,
+		Inputs:         []string{"service", "endpoints", "pods"},
+		Permissions:    []checks.Permission{readServices, readEndpoints, readPods},
+	})
+	portsCheck = checks.Register(checks.Check{
+		ID: "SERVICE-PORTS", Command: "service", Alias: "ports", Category: checks.CategoryNetworking,
+		Name:           "Port Configuration",
+		Severity:       output.SeverityMedium,
+		Description:    "Service ports match the container ports of backend pods",
+		DefaultEnabled: true,
+		Inputs:         []string{"service", "pods"},
+		Permissions:    []checks.Permission{readServices, readPods},
+	})
+)
+
+// serviceChecks binds the registered service checks to their implementations.
+func (sd *ServiceDiagnostic) serviceChecks(config DiagnosticConfig) []checks.Runnable[*ServiceInfo] {
+	bind := func(fn func(context.Context, *ServiceInfo, DiagnosticConfig) output.CheckResult) func(context.Context, *ServiceInfo) []output.CheckResult {
+		return checks.Single(func(ctx context.Context, info *ServiceInfo) output.CheckResult {
+			return fn(ctx, info, config)
+		})
+	}
+
+	runnables := []checks.Runnable[*ServiceInfo]{
+		{Check: existsCheck, Run: bind(sd.checkServiceExists)},
+		{Check: configCheck, Run: bind(sd.checkServiceConfiguration)},
+		{Check: selectorCheck, Run: bind(sd.checkServiceSelector)},
+		{Check: endpointsCheck, Run: bind(sd.checkEndpointHealth)},
+		{Check: portsCheck, Run: bind(sd.checkPortConfiguration)},
+	}
+
+	runnables = append(runnables, custom.Runnables(config.CustomChecks, "service",
+		func(*ServiceInfo) custom.Lister { return sd.client.Cache() },
+		func(info *ServiceInfo) runtime.Object { return info.Service })...)
+
+	return append(runnables, plugin.Runnables(config.Plugins, "service",
+		func(*ServiceInfo) *client.KubernetesClient { return sd.client },
+		func(_ context.Context, info *ServiceInfo) ([]runtime.Object, error) {
+			return []runtime.Object{info.Service}, nil
+		})...)
+}
+
 // NewServiceDiagnostic creates a new service diagnostic instance.
 func NewServiceDiagnostic(kubeClient *client.KubernetesClient, outputMgr *output.OutputManager) *ServiceDiagnostic {
 	return &ServiceDiagnostic{
@@ -59,7 +164,14 @@ func NewServiceDiagnostic(kubeClient *client.KubernetesClient, outputMgr *output
 
 // DiagnoseService performs comprehensive diagnostics on a specific service.
 func (sd *ServiceDiagnostic) DiagnoseService(ctx context.Context, serviceName string, config DiagnosticConfig) (*output.DiagnosticReport, error) {
-	sd.output.PrintInfo(fmt.Sprintf("🔍 Analyzing service: %s", serviceName))
+	sd.output.PrintInfo(sd.output.Icon("🔍") + fmt.Sprintf("Analyzing service: %s", serviceName))
+	ctx, tracker := client.TrackData(ctx)
+
+	selection, err := checks.Select("service", config.Checks, config.SkipChecks)
+	if err != nil {
+		return nil, err
+	}
+	selection = selection.WithTimeout(config.CheckTimeout)
 
 	// Get service information
 	serviceInfo, err := sd.getServiceInfo(ctx, serviceName, config.Namespace)
@@ -67,94 +179,111 @@ func (sd *ServiceDiagnostic) DiagnoseService(ctx context.Context, serviceName st
 		return nil, fmt.Errorf("failed to get service information: %w", err)
 	}
 
-	// Create diagnostic report
+	// Run diagnostic checks
+	results := checks.Run(ctx, sd.serviceChecks(config), selection, serviceInfo)
+	output.SetDataCompleteness(results, tracker.Partial())
+
+	return sd.newReport(serviceInfo.Service, config, results), nil
+}
+
+// newReport builds the report on a service from its check results.
+func (sd *ServiceDiagnostic) newReport(service *corev1.Service, config DiagnosticConfig, results []output.CheckResult) *output.DiagnosticReport {
 	report := &output.DiagnosticReport{
-		Target:    fmt.Sprintf("Service %s/%s", config.Namespace, serviceName),
-		Timestamp: time.Now().Format(time.RFC3339),
-		Checks:    []output.CheckResult{},
+		SchemaVersion: output.SchemaVersion,
+		Resource:      serviceRef(service),
+		Target:        fmt.Sprintf("Service %s/%s", config.Namespace, service.Name),
+		Timestamp:     time.Now().Format(time.RFC3339),
+		Checks:        append([]output.CheckResult{}, results...),
 		Metadata: map[string]interface{}{
 			"resourceType": "Service",
-			"resourceName": serviceName,
+			"resourceName": service.Name,
 			"namespace":    config.Namespace,
 		},
 	}
 
-	// Run diagnostic checks
-	checks := []func(context.Context, *ServiceInfo, DiagnosticConfig) output.CheckResult{
-		sd.checkServiceExists,
-		sd.checkServiceConfiguration,
-		sd.checkServiceSelector,
-		sd.checkEndpointHealth,
-		sd.checkPortConfiguration,
-	}
-
-	// Run checks
-	for _, checkFunc := range checks {
-		if ctx.Err() != nil {
-			return nil, ctx.Err()
-		}
-
-		check := checkFunc(ctx, serviceInfo, config)
-		report.Checks = append(report.Checks, check)
-	}
+	output.SetResource(report.Checks, report.Resource)
+	sd.output.FinalizeResults(report.Target, report.Checks)
 
 	// Calculate summary
 	report.Summary = sd.calculateSummary(report.Checks)
 
-	return report, nil
+	return report
 }
 
-// DiagnoseAllServices performs diagnostics on all services in the specified namespace(s).
+// DiagnoseAllServices performs diagnostics on all services in the specified
+// namespace(s). Every service gets its own --timeout, and services that
+// cannot be diagnosed are reported with their checks skipped.
 func (sd *ServiceDiagnostic) DiagnoseAllServices(ctx context.Context, config DiagnosticConfig) ([]*output.DiagnosticReport, error) {
 	var reports []*output.DiagnosticReport
 
+	selection, err := checks.Select("service", config.Checks, config.SkipChecks)
+	if err != nil {
+		return nil, err
+	}
+
 	// Get list of services
 	services, err := sd.getServiceList(ctx, config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list services: %w", err)
 	}
 
-	if len(services.Items) == 0 {
+	if len(services) == 0 {
 		sd.output.PrintInfo("No services found in the specified namespace(s)")
 		return reports, nil
 	}
 
-	sd.output.PrintInfo(fmt.Sprintf("🔍 Analyzing %d services", len(services.Items)))
+	sd.output.PrintInfo(sd.output.Icon("🔍") + fmt.Sprintf("Analyzing %d services", len(services)))
 
-	// Diagnose each service
-	for _, service := range services.Items {
-		if ctx.Err() != nil {
-			return reports, ctx.Err()
-		}
+	// List related objects once instead of once per service
+	if err := sd.client.Cache().Prefetch(ctx, listNamespace(config),
+		client.ResourceEndpoints, client.ResourcePods, client.ResourceEvents); err != nil {
+		sd.output.PrintWarning(fmt.Sprintf("Failed to prefetch related objects, falling back to per-service lookups: %v", err))
+	}
 
+	// Diagnose services concurrently, keeping the reports in list order
+	reports = checks.Map(config.Parallelism, services, func(service *corev1.Service) *output.DiagnosticReport {
 		serviceConfig := config
 		serviceConfig.Namespace = service.Namespace
 
-		report, err := sd.DiagnoseService(ctx, service.Name, serviceConfig)
+		// Every service gets the full --timeout, however long the services
+		// before it took
+		serviceCtx := context.WithoutCancel(ctx)
+		if config.Timeout > 0 {
+			var cancel context.CancelFunc
+			serviceCtx, cancel = context.WithTimeout(serviceCtx, config.Timeout)
+			defer cancel()
+		}
+
+		report, err := sd.DiagnoseService(serviceCtx, service.Name, serviceConfig)
 		if err != nil {
 			sd.output.PrintError("Failed to diagnose service", fmt.Errorf("service %s: %w", service.Name, err))
-			continue
+			results := checks.Unavailable(sd.serviceChecks(serviceConfig), selection, "Failed to read the service and its backends", err)
+			return sd.newReport(service, serviceConfig, results)
 		}
-
-		reports = append(reports, report)
-	}
+		return report
+	})
 
 	return reports, nil
 }
 
+// serviceRef returns the resource reference of a service.
+func serviceRef(service *corev1.Service) *output.ResourceRef {
+	return &output.ResourceRef{Kind: "Service", Namespace: service.Namespace, Name: service.Name, UID: string(service.UID)}
+}
+
 // getServiceInfo retrieves comprehensive information about a service.
 func (sd *ServiceDiagnostic) getServiceInfo(ctx context.Context, serviceName, namespace string) (*ServiceInfo, error) {
 	info := &ServiceInfo{}
 
 	// Get service
-	service, err := sd.client.Clientset.CoreV1().Services(namespace).Get(ctx, serviceName, metav1.GetOptions{})
+	service, err := sd.client.Cache().Service(ctx, namespace, serviceName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get service: %w", err)
 	}
 	info.Service = service
 
 	// Get endpoints
-	endpoints, err := sd.client.Clientset.CoreV1().Endpoints(namespace).Get(ctx, serviceName, metav1.GetOptions{})
+	endpoints, err := sd.client.Cache().Endpoints(ctx, namespace, serviceName)
 	if err != nil {
 		// Endpoints might not exist yet, which is not necessarily an error
 		if !strings.Contains(err.Error(), "not found") {
@@ -167,33 +296,32 @@ func (sd *ServiceDiagnostic) getServiceInfo(ctx context.Context, serviceName, na
 	// Get backend pods if service has selectors
 	if len(service.Spec.Selector) > 0 {
 		selector := labels.SelectorFromSet(service.Spec.Selector)
-		pods, err := sd.client.Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
-			LabelSelector: selector.String(),
-		})
+		pods, err := sd.client.Cache().PodsMatching(ctx, namespace, selector)
 		if err == nil {
-			for i := range pods.Items {
-				info.BackendPods = append(info.BackendPods, &pods.Items[i])
-			}
+			info.BackendPods = append(info.BackendPods, pods...)
 		}
 	}
 
 	// Get recent events
-	events, err := sd.client.Clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
-		FieldSelector: fmt.Sprintf("involvedObject.name=%s,involvedObject.kind=Service", serviceName),
-	})
+	events, err := sd.client.Cache().EventsFor(ctx, namespace, "Service", serviceName)
 	if err == nil {
-		info.Events = events.Items
+		info.Events = events
 	}
 
 	return info, nil
 }
 
 // getServiceList retrieves a list of services based on the configuration.
-func (sd *ServiceDiagnostic) getServiceList(ctx context.Context, config DiagnosticConfig) (*corev1.ServiceList, error) {
+func (sd *ServiceDiagnostic) getServiceList(ctx context.Context, config DiagnosticConfig) ([]*corev1.Service, error) {
+	return sd.client.Cache().Services(ctx, listNamespace(config))
+}
+
+// listNamespace returns the namespace to list in, or metav1.NamespaceAll.
+func listNamespace(config DiagnosticConfig) string {
 	if config.AllNamespaces {
-		return sd.client.Clientset.CoreV1().Services("").List(ctx, metav1.ListOptions{})
+		return metav1.NamespaceAll
 	}
-	return sd.client.Clientset.CoreV1().Services(config.Namespace).List(ctx, metav1.ListOptions{})
+	return config.Namespace
 }
 
 // checkServiceExists verifies that the service exists and is accessible.
@@ -542,6 +670,7 @@ func (sd *ServiceDiagnostic) calculateSummary(checks []output.CheckResult) outpu
 	failed := 0
 	warnings := 0
 	skipped := 0
+	suppressed := 0
 
 	for _, check := range checks {
 		switch check.Status {
@@ -553,15 +682,18 @@ func (sd *ServiceDiagnostic) calculateSummary(checks []output.CheckResult) outpu
 			warnings++
 		case output.StatusSkipped:
 			skipped++
+		case output.StatusSuppressed:
+			suppressed++
 		}
 	}
 
 	return output.Summary{
-		Total:    len(checks),
-		Passed:   passed,
-		Failed:   failed,
-		Warnings: warnings,
-		Skipped:  skipped,
+		Total:      len(checks),
+		Passed:     passed,
+		Failed:     failed,
+		Warnings:   warnings,
+		Skipped:    skipped,
+		Suppressed: suppressed,
 	}
 }
 
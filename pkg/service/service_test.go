@@ -2,12 +2,18 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 
 	"kdebug/internal/client"
 	"kdebug/internal/output"
@@ -506,6 +512,231 @@ func TestCalculateSummary(t *testing.T) {
 	})
 }
 
+func newFakeServiceDiagnostic(objs ...runtime.Object) *ServiceDiagnostic {
+	kubeClient := client.NewKubernetesClientFromClientset(fake.NewClientset(objs...), nil, "fake")
+	return NewServiceDiagnostic(kubeClient, output.NewOutputManager("json", false))
+}
+
+func TestDiagnoseServiceWithFakeClient(t *testing.T) {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: map[string]string{"app": "web"},
+			Ports: []corev1.ServicePort{
+				{Name: "http", Port: 80, TargetPort: intstr.FromInt(8080), Protocol: corev1.ProtocolTCP},
+			},
+		},
+	}
+	endpoints := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Subsets: []corev1.EndpointSubset{
+			{Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}}},
+		},
+	}
+	readyPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default", Labels: map[string]string{"app": "web"}},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		},
+	}
+	unrelatedPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-1", Namespace: "default", Labels: map[string]string{"app": "db"}},
+	}
+
+	serviceDiag := newFakeServiceDiagnostic(service, endpoints, readyPod, unrelatedPod)
+
+	report, err := serviceDiag.DiagnoseService(context.Background(), "web", DiagnosticConfig{Namespace: "default"})
+	if err != nil {
+		t.Fatalf("DiagnoseService() unexpected error: %v", err)
+	}
+
+	if report.Summary.Total != 5 {
+		t.Fatalf("Expected 5 checks, got %d", report.Summary.Total)
+	}
+	if report.Summary.Passed != 5 {
+		for _, check := range report.Checks {
+			if check.Status != output.StatusPassed {
+				t.Errorf("Check %q status = %s: %s", check.Name, check.Status, check.Message)
+			}
+		}
+	}
+}
+
+func TestDiagnoseServiceWithoutBackendsWithFakeClient(t *testing.T) {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: map[string]string{"app": "web"},
+			Ports: []corev1.ServicePort{
+				{Port: 80, TargetPort: intstr.FromInt(8080), Protocol: corev1.ProtocolTCP},
+			},
+		},
+	}
+
+	serviceDiag := newFakeServiceDiagnostic(service)
+
+	report, err := serviceDiag.DiagnoseService(context.Background(), "web", DiagnosticConfig{Namespace: "default"})
+	if err != nil {
+		t.Fatalf("DiagnoseService() unexpected error: %v", err)
+	}
+
+	for _, check := range report.Checks {
+		switch check.Name {
+		case "Service Selector", "Endpoint Health":
+			if check.Status != output.StatusFailed {
+				t.Errorf("Check %q status = %s, want FAILED", check.Name, check.Status)
+			}
+		}
+	}
+}
+
+func TestDiagnoseServiceCheckSelection(t *testing.T) {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP, Ports: []corev1.ServicePort{{Port: 80}}},
+	}
+	serviceDiag := newFakeServiceDiagnostic(service)
+
+	report, err := serviceDiag.DiagnoseService(context.Background(), "web", DiagnosticConfig{
+		Namespace:  "default",
+		Checks:     []string{"config", "SERVICE-PORTS", "selector"},
+		SkipChecks: []string{"selector"},
+	})
+	if err != nil {
+		t.Fatalf("DiagnoseService() unexpected error: %v", err)
+	}
+	if report.Summary.Total != 2 {
+		t.Errorf("Expected 2 checks, got %d", report.Summary.Total)
+	}
+}
+
+func TestDiagnoseAllServicesWithFakeClient(t *testing.T) {
+	newService := func(name, namespace string) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: corev1.ServiceSpec{
+				Type:  corev1.ServiceTypeClusterIP,
+				Ports: []corev1.ServicePort{{Port: 80, Protocol: corev1.ProtocolTCP}},
+			},
+		}
+	}
+
+	serviceDiag := newFakeServiceDiagnostic(newService("a", "default"), newService("b", "default"), newService("c", "other"))
+
+	reports, err := serviceDiag.DiagnoseAllServices(context.Background(), DiagnosticConfig{Namespace: "default"})
+	if err != nil {
+		t.Fatalf("DiagnoseAllServices() unexpected error: %v", err)
+	}
+	if len(reports) != 2 {
+		t.Errorf("Expected 2 reports for namespace default, got %d", len(reports))
+	}
+
+	reports, err = serviceDiag.DiagnoseAllServices(context.Background(), DiagnosticConfig{AllNamespaces: true})
+	if err != nil {
+		t.Fatalf("DiagnoseAllServices() unexpected error: %v", err)
+	}
+	if len(reports) != 3 {
+		t.Errorf("Expected 3 reports across namespaces, got %d", len(reports))
+	}
+}
+
+func TestDiagnoseAllServicesGivesEachServiceItsOwnTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clientset := fake.NewClientset(
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default"}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "default"}},
+	)
+	// The command's deadline passes once the services were listed
+	clientset.PrependReactor("list", "endpoints", func(k8stesting.Action) (bool, runtime.Object, error) {
+		cancel()
+		return false, nil, nil
+	})
+	serviceDiag := NewServiceDiagnostic(client.NewKubernetesClientFromClientset(clientset, nil, "fake"), output.NewOutputManager("json", false))
+
+	reports, err := serviceDiag.DiagnoseAllServices(ctx, DiagnosticConfig{Namespace: "default", Timeout: time.Minute})
+	if err != nil {
+		t.Fatalf("DiagnoseAllServices() unexpected error: %v", err)
+	}
+	if len(reports) != 2 {
+		t.Fatalf("Expected every service to be reported, got %d reports", len(reports))
+	}
+	for _, report := range reports {
+		for _, check := range report.Checks {
+			if check.Details["reason"] == "timeout" {
+				t.Errorf("Expected %s not to time out with its own deadline, got %s", report.Target, check.Message)
+			}
+		}
+	}
+}
+
+func TestDiagnoseAllServicesReportsServicesThatCannotBeRead(t *testing.T) {
+	clientset := fake.NewClientset(
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default"}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "default"}},
+	)
+	forbidden := func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("endpoints are forbidden")
+	}
+	clientset.PrependReactor("list", "endpoints", forbidden)
+	clientset.PrependReactor("get", "endpoints", forbidden)
+	serviceDiag := NewServiceDiagnostic(client.NewKubernetesClientFromClientset(clientset, nil, "fake"), output.NewOutputManager("json", false))
+
+	reports, err := serviceDiag.DiagnoseAllServices(context.Background(), DiagnosticConfig{Namespace: "default"})
+	if err != nil {
+		t.Fatalf("DiagnoseAllServices() unexpected error: %v", err)
+	}
+	if len(reports) != 2 {
+		t.Fatalf("Expected services that cannot be read to stay in the report, got %d reports", len(reports))
+	}
+	for _, report := range reports {
+		if report.Summary.Total == 0 || report.Summary.Skipped != report.Summary.Total {
+			t.Errorf("Expected every check of %s to be skipped, got %+v", report.Target, report.Summary)
+		}
+		for _, check := range report.Checks {
+			if check.Resource == nil || check.Resource.Name != report.Resource.Name {
+				t.Errorf("Expected skipped results to reference %s, got %+v", report.Target, check.Resource)
+			}
+		}
+	}
+}
+
+func TestDiagnoseAllServicesAPICallsDoNotScaleWithServices(t *testing.T) {
+	countCalls := func(services int) int {
+		var objs []runtime.Object
+		for i := 0; i < services; i++ {
+			name := fmt.Sprintf("web-%d", i)
+			objs = append(objs,
+				&corev1.Service{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+					Spec: corev1.ServiceSpec{
+						Type:     corev1.ServiceTypeClusterIP,
+						Selector: map[string]string{"app": name},
+						Ports:    []corev1.ServicePort{{Port: 80, Protocol: corev1.ProtocolTCP}},
+					},
+				},
+				&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": name}}},
+			)
+		}
+
+		clientset := fake.NewClientset(objs...)
+		serviceDiag := NewServiceDiagnostic(client.NewKubernetesClientFromClientset(clientset, nil, "fake"), output.NewOutputManager("json", false))
+		if _, err := serviceDiag.DiagnoseAllServices(context.Background(), DiagnosticConfig{Namespace: "default"}); err != nil {
+			t.Fatalf("DiagnoseAllServices() unexpected error: %v", err)
+		}
+
+		return len(clientset.Actions())
+	}
+
+	small, large := countCalls(2), countCalls(20)
+	if small != large {
+		t.Errorf("Expected a constant number of API calls, got %d for 2 services and %d for 20 services", small, large)
+	}
+}
+
 func TestIsPodReady(t *testing.T) {
 	t.Run("ready pod", func(t *testing.T) {
 		pod := &corev1.Pod{
@@ -13,6 +13,7 @@ import (
 	"testing"
 	"time"
 
+	"kdebug/internal/exitcode"
 	"kdebug/internal/output"
 )
 
@@ -148,9 +149,9 @@ func TestClusterDiagnosticsWithIssues(t *testing.T) {
 		// Should still run but might have warnings/failures
 		if err != nil {
 			if exitError, ok := err.(*exec.ExitError); ok {
-				// Exit code 1 is expected when issues are found
-				if exitError.ExitCode() != 1 {
-					t.Errorf("Expected exit code 1 for cluster with issues, got %d", exitError.ExitCode())
+				// Failed checks exit with 3 under the default --fail-on=failed
+				if exitError.ExitCode() != exitcode.Failures {
+					t.Errorf("Expected exit code %d for cluster with issues, got %d", exitcode.Failures, exitError.ExitCode())
 				}
 			}
 		}